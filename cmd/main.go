@@ -28,24 +28,38 @@ func main() {
 	}()
 
 	if len(os.Args) <= 1 {
-		zap.L().Warn("Please provide a command: init or run")
+		zap.L().Warn("Please provide a command: init, run or zones download")
 		os.Exit(1)
 	}
 	cmd := os.Args[1]
+	flagsStartIndex := 2
+
+	// Zones command has its own sub-commands
+	if cmd == "zones" {
+		if len(os.Args) <= 2 {
+			zap.L().Warn("Please provide a zones command: download")
+			os.Exit(1)
+		}
+		cmd += " " + os.Args[2]
+		flagsStartIndex = 3
+	}
 
 	// Define CLI argument flags
-	purgeCLIArg := flag.Bool("purge", false, "purges the database & all cache files")
+	purgeCLIArg := flag.Bool("purge", false, "purges the database & all cache files, user data folder is kept")
 	configFilePathCLIArg := flag.String("config", "./config.toml", "config file location (default: ./config.toml)")
 	cacheFolderCLIArg := flag.String("cache", "./cache", "cache folder location (default: ./cache)")
 	zoneFilesFolderCLIArg := flag.String(
 		"zoneFiles", "./zone-files", "zone files folder location (default: ./zone-files)",
 	)
+	userDataFolderCLIArg := flag.String(
+		"userData", "./user-data", "user data folder location, i.e. saved searches (default: ./user-data)",
+	)
 	// TODO [HP]: Get rid of queryCLIArg once WebUI is available.
 	queryCLIArg := flag.String("query", "", "user query to run after indexing is finished")
 
 	// Parse the CLI arguments
 	// Remove the command and leave only the flags in os.Args
-	os.Args = append(os.Args[:1], os.Args[2:]...)
+	os.Args = append(os.Args[:1], os.Args[flagsStartIndex:]...)
 
 	flag.Parse()
 
@@ -53,11 +67,13 @@ func main() {
 	overridePathArgWithEnvVar(configFilePathCLIArg, "ZFSE_CONF_FILE_PATH")
 	overridePathArgWithEnvVar(cacheFolderCLIArg, "ZFSE_CACHE_DIR")
 	overridePathArgWithEnvVar(zoneFilesFolderCLIArg, "ZFSE_ZONE_FILES_DIR")
+	overridePathArgWithEnvVar(userDataFolderCLIArg, "ZFSE_USER_DATA_DIR")
 
 	// Finally set default paths
 	path_manager.SetConfigFilePath(*configFilePathCLIArg)
 	path_manager.SetCacheFolderPath(*cacheFolderCLIArg)
 	path_manager.SetZoneFilesFolderPath(*zoneFilesFolderCLIArg)
+	path_manager.SetUserDataFolderPath(*userDataFolderCLIArg)
 
 	// Check if 'config' command is executed
 	if cmd == "init" {
@@ -73,8 +89,10 @@ func main() {
 
 		a.Run(*queryCLIArg)
 
+	} else if cmd == "zones download" {
+		downloadZoneFiles()
 	} else {
-		zap.L().Warn(fmt.Sprintf("Unknown command: %s\n", os.Args[1]))
+		zap.L().Warn(fmt.Sprintf("Unknown command: %s\n", cmd))
 		os.Exit(1)
 	}
 }
@@ -119,6 +137,12 @@ func initializeFilesAndFolders() {
 		zap.L().Fatal("Unable to create cache folder.", zap.String("err", err.Error()))
 	}
 
+	// Create the user data folder
+	err = helper.CreateFolder(path_manager.GetUserDataFolderPath())
+	if err != nil {
+		zap.L().Fatal("Unable to create user data folder.", zap.String("err", err.Error()))
+	}
+
 	// Create zone files folder
 	err = helper.CreateFolder(path_manager.GetZoneFilesFolderPath())
 	if err != nil {
@@ -149,7 +173,11 @@ func initializeFilesAndFolders() {
 // TODO [MP]: This method is intended as a development helper but we should actually
 // allow per zone file cache deletion.
 func purgeCache() {
-	zap.L().Warn("Purging application cache folders.")
+	// Design Note: Saved searches are kept in the user data folder, thus they survive the purge.
+	zap.L().Warn(
+		"Purging application cache folders. User data folder is kept.",
+		zap.String("user_data_folder", path_manager.GetUserDataFolderPath()),
+	)
 
 	// Delete cache folders
 	err := helper.DeleteFolder(path_manager.GetCacheFolderPath())
@@ -0,0 +1,85 @@
+// Copyright (C) 2023 by Anthony V. Ozdemir
+// MIT License (refer to LICENSE)
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/czds"
+	"github.com/anthony-ozdemir/zfse/internal/helper"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+)
+
+// Downloads the approved zone files from ICANN CZDS into the zone files folder.
+func downloadZoneFiles() {
+	conf := config.NewApplicationConfig()
+	czdsOptions := conf.CZDSOptions
+	if password, ok := os.LookupEnv("ZFSE_CZDS_PASSWORD"); ok {
+		czdsOptions.Password = password
+	}
+	if czdsOptions.Username == "" || czdsOptions.Password == "" {
+		zap.L().Fatal("CZDS username & password need to be configured under [CZDS] section of config.toml.")
+	}
+
+	err := helper.CreateFolder(path_manager.GetZoneFilesFolderPath())
+	if err != nil {
+		zap.L().Fatal("Unable to create zone files folder.", zap.String("err", err.Error()))
+	}
+
+	client := czds.NewClient(czdsOptions.AuthURL, czdsOptions.APIURL, nil)
+	err = client.Authenticate(czdsOptions.Username, czdsOptions.Password)
+	if err != nil {
+		zap.L().Fatal("Unable to authenticate to CZDS.", zap.String("err", err.Error()))
+	}
+
+	links, err := client.ListZoneLinks()
+	if err != nil {
+		zap.L().Fatal("Unable to list approved zone files.", zap.String("err", err.Error()))
+	}
+
+	bIsRequestedTLD := make(map[string]bool)
+	for _, tld := range czdsOptions.TLDs {
+		bIsRequestedTLD[strings.ToLower(strings.Trim(tld, "."))] = true
+	}
+
+	downloader := czds.NewDownloader(client, path_manager.GetZoneDownloadFolderPath())
+	bHasFailed := false
+	for _, link := range links {
+		zoneName := czds.GetZoneName(link)
+		if len(bIsRequestedTLD) > 0 && !bIsRequestedTLD[zoneName] {
+			continue
+		}
+		delete(bIsRequestedTLD, zoneName)
+
+		// Design Note: CZDS serves gzip compressed zone files, which are read without extracting them.
+		zoneFilePath := filepath.Join(path_manager.GetZoneFilesFolderPath(), zoneName+".txt.gz")
+
+		zap.L().Info("Downloading zone file.", zap.String("zone_name", zoneName))
+		bIsUpdated, err := downloader.Download(link, zoneFilePath)
+		if err != nil {
+			zap.L().Error("Unable to download zone file.", zap.String("zone_name", zoneName), zap.String("err", err.Error()))
+			bHasFailed = true
+			continue
+		}
+
+		if bIsUpdated {
+			zap.L().Info("Zone file is updated.", zap.String("zone_name", zoneName), zap.String("path", zoneFilePath))
+		} else {
+			zap.L().Info("Zone file is unchanged.", zap.String("zone_name", zoneName))
+		}
+	}
+
+	for tld := range bIsRequestedTLD {
+		zap.L().Warn("Zone file is not approved on CZDS.", zap.String("zone_name", tld))
+	}
+
+	if bHasFailed {
+		os.Exit(1)
+	}
+}
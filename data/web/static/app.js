@@ -0,0 +1,235 @@
+"use strict";
+
+const pageSize = 10;
+const suggestionDelayInMilliseconds = 150;
+
+const searchForm = document.getElementById("search-form");
+const searchInput = document.getElementById("search-input");
+const searchSuggestions = document.getElementById("search-suggestions");
+const searchStatus = document.getElementById("search-status");
+const searchResults = document.getElementById("search-results");
+const previousPageButton = document.getElementById("previous-page");
+const nextPageButton = document.getElementById("next-page");
+const pageInfo = document.getElementById("page-info");
+const searchFacets = document.getElementById("search-facets");
+const selectedFacetsElement = document.getElementById("selected-facets");
+
+const facetTitles = {
+    "zone": "Zone",
+    "ns": "Nameserver Provider",
+    "lang": "Language",
+};
+
+let currentQuery = "";
+let currentPage = 0;
+let totalPages = 0;
+let suggestionTimeout = null;
+// Selected facets in "field:value" format
+let selectedFacets = [];
+
+// Search results are linked through the click endpoint, which records the click before redirecting. Positions are
+// signed by the server, see click_token.
+function getClickURL(result, position) {
+    return "/v1/click?q=" + encodeURIComponent(currentQuery) + "&id=" + encodeURIComponent(result.id) +
+        "&pos=" + position + "&token=" + encodeURIComponent(result.click_token);
+}
+
+function createResultCard(result, position) {
+    const card = document.createElement("div");
+    card.className = "result-card";
+
+    const domain = document.createElement("div");
+    domain.className = "result-domain";
+    domain.textContent = result.domain_name;
+    card.appendChild(domain);
+
+    const title = document.createElement("a");
+    title.className = "result-title";
+    title.href = getClickURL(result, position);
+    title.title = result.url;
+    title.rel = "noopener noreferrer";
+    title.textContent = result.string_properties.title || result.domain_name;
+    card.appendChild(title);
+
+    if (result.snippet) {
+        // Design Note: Snippets are HTML escaped by the server, only <mark> tags are expected.
+        const snippetElement = document.createElement("p");
+        snippetElement.className = "result-description";
+        snippetElement.innerHTML = result.snippet;
+        card.appendChild(snippetElement);
+    } else if (result.string_properties.description) {
+        const descriptionElement = document.createElement("p");
+        descriptionElement.className = "result-description";
+        descriptionElement.textContent = result.string_properties.description;
+        card.appendChild(descriptionElement);
+    }
+
+    const scores = document.createElement("div");
+    scores.className = "result-scores";
+    scores.textContent = "Score: " + result.final_score.toFixed(4) +
+        " | Indexer Score: " + result.indexer_score.toFixed(4);
+    card.appendChild(scores);
+
+    return card;
+}
+
+function renderPage(results) {
+    searchResults.replaceChildren();
+
+    results.forEach((result, index) => {
+        searchResults.appendChild(createResultCard(result, currentPage * pageSize + index + 1));
+    });
+
+    previousPageButton.hidden = currentPage <= 0;
+    nextPageButton.hidden = currentPage + 1 >= totalPages;
+    pageInfo.textContent = totalPages > 1 ? "Page " + (currentPage + 1) + " of " + totalPages : "";
+}
+
+function renderFacets(facets) {
+    searchFacets.replaceChildren();
+
+    for (const [field, facetCounts] of Object.entries(facets || {})) {
+        if (facetCounts.length === 0) {
+            continue;
+        }
+
+        const group = document.createElement("div");
+        group.className = "facet-group";
+
+        const title = document.createElement("h3");
+        title.textContent = facetTitles[field] || field;
+        group.appendChild(title);
+
+        const list = document.createElement("ul");
+        for (const facetCount of facetCounts) {
+            const selection = field + ":" + facetCount.value;
+            if (selectedFacets.includes(selection)) {
+                continue;
+            }
+
+            const item = document.createElement("li");
+            const button = document.createElement("button");
+            button.type = "button";
+            button.textContent = facetCount.value + " ";
+            const count = document.createElement("span");
+            count.className = "facet-count";
+            count.textContent = "(" + facetCount.count + ")";
+            button.appendChild(count);
+            button.addEventListener("click", () => {
+                selectedFacets.push(selection);
+                search(currentQuery, 0);
+            });
+            item.appendChild(button);
+            list.appendChild(item);
+        }
+        group.appendChild(list);
+        searchFacets.appendChild(group);
+    }
+}
+
+function renderSelectedFacets() {
+    selectedFacetsElement.replaceChildren();
+
+    for (const selection of selectedFacets) {
+        const button = document.createElement("button");
+        button.type = "button";
+        button.title = "Remove filter";
+        button.textContent = selection + " \u00d7";
+        button.addEventListener("click", () => {
+            selectedFacets = selectedFacets.filter((selectedFacet) => selectedFacet !== selection);
+            search(currentQuery, 0);
+        });
+        selectedFacetsElement.appendChild(button);
+    }
+}
+
+async function search(userQuery, page) {
+    searchStatus.textContent = "Searching...";
+    currentQuery = userQuery;
+    currentPage = page;
+    totalPages = 0;
+    renderPage([]);
+    renderSelectedFacets();
+
+    let facetParams = "";
+    for (const selection of selectedFacets) {
+        facetParams += "&facet=" + encodeURIComponent(selection);
+    }
+
+    try {
+        const response = await fetch(
+            "/v1/search?q=" + encodeURIComponent(userQuery) + "&from=" + (page * pageSize) + "&size=" + pageSize +
+            facetParams
+        );
+        const reply = await response.json();
+        if (!reply.success) {
+            searchStatus.textContent = "Search failed: " + reply.error;
+            return;
+        }
+
+        totalPages = Math.ceil(reply.available_hits / pageSize);
+        searchStatus.textContent = reply.total_hits + " results";
+        renderPage(reply.results);
+        renderFacets(reply.facets);
+    } catch (err) {
+        searchStatus.textContent = "Search failed: " + err;
+    }
+}
+
+async function suggest(prefix) {
+    try {
+        const response = await fetch("/v1/suggest?prefix=" + encodeURIComponent(prefix));
+        const reply = await response.json();
+        if (!reply.success) {
+            return;
+        }
+
+        searchSuggestions.replaceChildren();
+        for (const suggestion of reply.suggestions) {
+            const option = document.createElement("option");
+            option.value = suggestion;
+            searchSuggestions.appendChild(option);
+        }
+    } catch (err) {
+        // Suggestions are optional, we can ignore errors
+    }
+}
+
+searchInput.addEventListener("input", () => {
+    clearTimeout(suggestionTimeout);
+    const prefix = searchInput.value;
+    if (prefix.trim() === "") {
+        searchSuggestions.replaceChildren();
+        return;
+    }
+    suggestionTimeout = setTimeout(() => suggest(prefix), suggestionDelayInMilliseconds);
+});
+
+searchForm.addEventListener("submit", (event) => {
+    event.preventDefault();
+    const userQuery = searchInput.value.trim();
+    if (userQuery === "") {
+        return;
+    }
+    history.pushState(null, "", "/?q=" + encodeURIComponent(userQuery));
+    // Facet selections are specific to the previous query
+    selectedFacets = [];
+    search(userQuery, 0);
+});
+
+previousPageButton.addEventListener("click", () => {
+    search(currentQuery, currentPage - 1);
+    window.scrollTo(0, 0);
+});
+
+nextPageButton.addEventListener("click", () => {
+    search(currentQuery, currentPage + 1);
+    window.scrollTo(0, 0);
+});
+
+// Run the query from the URL, if there's any
+const initialQuery = new URLSearchParams(window.location.search).get("q");
+if (initialQuery) {
+    searchInput.value = initialQuery;
+    search(initialQuery, 0);
+}
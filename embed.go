@@ -1,9 +1,8 @@
 package embedding
 
 import (
-	// Blank import is needed as we are storing main.go under "./cmd".
-	// TODO [LP]: Find a better solution. This might embed all the files on all binaries.
-	_ "embed"
+	"embed"
+	"io/fs"
 )
 
 //go:embed data/config/config.toml
@@ -21,3 +20,24 @@ var exampleZoneFile []byte
 func GetExampleZoneFile() []byte {
 	return exampleZoneFile
 }
+
+//go:embed data/api/openapi.json
+var openAPISpec []byte
+
+// GetOpenAPISpec Returns embedded "openapi.json", the OpenAPI document of the HTTP API.
+func GetOpenAPISpec() []byte {
+	return openAPISpec
+}
+
+//go:embed data/web
+var webFiles embed.FS
+
+// GetWebFiles Returns embedded Web UI files, rooted at "data/web".
+func GetWebFiles() fs.FS {
+	webFilesRoot, err := fs.Sub(webFiles, "data/web")
+	if err != nil {
+		// Design Note: This can only happen if the embedded folder is renamed.
+		panic("Programming error.")
+	}
+	return webFilesRoot
+}
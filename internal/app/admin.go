@@ -0,0 +1,338 @@
+package app
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+	"github.com/anthony-ozdemir/zfse/internal/helper"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+)
+
+// Maximum number of IDs to delete from the index at once
+const adminDeleteBatchSize = 10000
+
+var (
+	errAdminUnknownZone     = fmt.Errorf("unknown zone")
+	errAdminPipelineRunning = fmt.Errorf("pipeline is running")
+)
+
+// Checks the API key of the request against the configured admin API keys.
+func (a *Application) isAuthorizedAdminRequest(r *http.Request) bool {
+	apiKey := r.Header.Get("X-API-Key")
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		apiKey = strings.TrimSpace(authorization[len("Bearer "):])
+	}
+	if apiKey == "" {
+		return false
+	}
+
+	bIsAuthorized := false
+	for _, adminAPIKey := range a.config.GeneralOptions.AdminAPIKeys {
+		// Design Note: Keys are compared in constant time to avoid timing attacks. We also don't break early, so
+		// that the position of the matching key isn't leaked.
+		if adminAPIKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(adminAPIKey)) == 1 {
+			bIsAuthorized = true
+		}
+	}
+	return bIsAuthorized
+}
+
+// Rejects requests without a valid admin API key.
+func (a *Application) getAdminAuthHandler(next http.HandlerFunc) http.Handler {
+	middle := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store") // No cache of any kind (private or shared)
+
+		if len(a.config.GeneralOptions.AdminAPIKeys) == 0 {
+			a.helperSendJSONError(&w, newAPIError(http.StatusForbidden, "admin API is disabled"))
+			return
+		}
+
+		if !a.isAuthorizedAdminRequest(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			a.helperSendJSONError(&w, newAPIError(http.StatusUnauthorized, "unauthorized"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(middle)
+}
+
+// Blocks until all running queries are finished and prevents new queries from running, until released.
+func (a *Application) acquireAllQuerySlots() {
+	for i := 0; i < cap(a.availableQuerySlots); i++ {
+		<-a.availableQuerySlots
+	}
+}
+
+func (a *Application) releaseAllQuerySlots() {
+	for i := 0; i < cap(a.availableQuerySlots); i++ {
+		a.availableQuerySlots <- struct{}{}
+	}
+}
+
+// Checks whether the pipeline tasks are idle, i.e. zone data can be altered safely.
+func (a *Application) isPipelineIdle() bool {
+	task := a.applicationStateManager.GetApplicationState().task
+	return task == enum.ReadyToSearch || task == enum.Errored
+}
+
+// Removes the zone from the index, along with its cache files & task states. The pipeline needs to be idle.
+func (a *Application) purgeZone(zoneName string) error {
+	a.adminMutex.Lock()
+	defer a.adminMutex.Unlock()
+
+	return a.purgeZoneLocked(zoneName)
+}
+
+func (a *Application) purgeZoneLocked(zoneName string) error {
+	if _, ok := a.zoneFileRegistry[zoneName]; !ok {
+		return errAdminUnknownZone
+	}
+	if !a.isPipelineIdle() {
+		return errAdminPipelineRunning
+	}
+
+	deleter, ok := (*a.indexer).(interfaces.Deleter)
+	if !ok {
+		return fmt.Errorf("indexer %v doesn't support deletion", (*a.indexer).GetType())
+	}
+
+	// Design Note: Queries read the post-crawl cache file of the IDs returned by the Indexer. Thus, we need to wait
+	// for the running queries to finish and block new ones until the zone is removed from both.
+	a.acquireAllQuerySlots()
+	defer a.releaseAllQuerySlots()
+
+	// Index IDs are derived from the post-crawl cache file lines
+	postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath(zoneName)
+	totalLines, err := helper.CountLinesInFile(postCrawlCacheFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	ids := make([]string, 0, adminDeleteBatchSize)
+	for lineIndex := 0; lineIndex < totalLines; lineIndex++ {
+		ids = append(ids, createIndexID(zoneName, lineIndex))
+		if len(ids) >= adminDeleteBatchSize || lineIndex+1 == totalLines {
+			err = deleter.Delete(ids)
+			if err != nil {
+				return err
+			}
+			ids = ids[:0]
+		}
+	}
+
+	for _, cacheFile := range []string{
+		path_manager.GetZoneDeltaFilePath(zoneName),
+		path_manager.GetZoneStaleDomainsFilePath(zoneName),
+		path_manager.GetPreCrawlFilterOutputFilePath(zoneName),
+		postCrawlCacheFile,
+	} {
+		err = os.Remove(cacheFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	err = a.db.DeleteZoneTaskStates(zoneName)
+	if err != nil {
+		return err
+	}
+
+	// The zone will be processed from scratch, thus its next snapshot needs to be treated as the first one
+	err = a.db.DeleteZoneSnapshot(zoneName)
+	if err != nil {
+		return err
+	}
+
+	a.invalidateRankingCache()
+
+	zap.L().Info("Purged zone.", zap.String("zone_name", zoneName))
+	return nil
+}
+
+// Purges the zone and restarts the pipeline, so that the zone is processed from scratch.
+func (a *Application) rerunZone(zoneName string) error {
+	a.adminMutex.Lock()
+	defer a.adminMutex.Unlock()
+
+	err := a.purgeZoneLocked(zoneName)
+	if err != nil {
+		return err
+	}
+
+	// Filters may hold state from the previous run, i.e. the unique domain filter.
+	err = a.reinitializeFilters()
+	if err != nil {
+		return err
+	}
+
+	if !a.applicationStateManager.OnRestart() {
+		return errAdminPipelineRunning
+	}
+	zap.L().Info("Restarted pipeline.", zap.String("zone_name", zoneName))
+	return nil
+}
+
+func (a *Application) reinitializeFilters() error {
+	for i, preCrawlFilterOptions := range a.config.PreCrawlFilterOptions {
+		err := (*a.preCrawlFilterArray[i]).Initialize(preCrawlFilterOptions)
+		if err != nil {
+			return err
+		}
+	}
+	for i, postCrawlFilterOptions := range a.config.PostCrawlFilterOptions {
+		err := (*a.postCrawlFilterArray[i]).Initialize(postCrawlFilterOptions)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reloads the ranker weights from the config file. Rankers themselves can't be added or removed at runtime.
+func (a *Application) reloadRankerWeights() (map[string]float64, error) {
+	applicationConfig, err := config.LoadApplicationConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	rankerWeightMap, err := a.getNormalizedRankerWeights(applicationConfig.RankerOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	a.rankerWeightMutex.Lock()
+	a.rankerWeightMap = rankerWeightMap
+	a.rankerWeightMutex.Unlock()
+
+	zap.L().Info("Reloaded ranker weights.")
+	return rankerWeightMap, nil
+}
+
+// API Methods
+type AdminPipelineRepJSON struct {
+	Success         bool   `json:"success"`
+	Error           string `json:"error,omitempty"`
+	ApplicationTask string `json:"application_task"`
+	BIsPaused       bool   `json:"b_is_paused"`
+}
+
+type AdminZoneReqJSON struct {
+	ZoneName *string `json:"zone_name"` // pointer so we can test for field absence
+}
+
+type AdminZoneRepJSON struct {
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+	ZoneName string `json:"zone_name,omitempty"`
+}
+
+type AdminRankerWeightsRepJSON struct {
+	Success       bool               `json:"success"`
+	Error         string             `json:"error,omitempty"`
+	RankerWeights map[string]float64 `json:"ranker_weights,omitempty"`
+}
+
+func (a *Application) adminPipelineHandler(bPause bool) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		if bPause {
+			a.applicationStateManager.Pause()
+			zap.L().Info("Paused pipeline.")
+		} else {
+			a.applicationStateManager.Resume()
+			zap.L().Info("Resumed pipeline.")
+		}
+
+		currentApplicationState := a.applicationStateManager.GetApplicationState()
+		jsonRep := AdminPipelineRepJSON{
+			Success:         true,
+			ApplicationTask: currentApplicationState.task.String(),
+			BIsPaused:       currentApplicationState.bIsPaused,
+		}
+		a.helperSendJSONSuccess(&w, jsonRep)
+	}
+}
+
+func (a *Application) adminZoneHandler(zoneOperation func(zoneName string) error) func(
+	w http.ResponseWriter, r *http.Request,
+) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		// Try decode Json request
+		decoder := json.NewDecoder(r.Body)
+		jsonReq := AdminZoneReqJSON{}
+
+		errJsonDecode := decoder.Decode(&jsonReq)
+		if errJsonDecode != nil {
+			a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "json decode error"))
+			return
+		}
+
+		// Check existence of mandatory fields on Json request
+		if jsonReq.ZoneName == nil {
+			a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "json field missing"))
+			return
+		}
+
+		err := zoneOperation(*jsonReq.ZoneName)
+		if err != nil {
+			statusCode := http.StatusBadRequest
+			if err == errAdminUnknownZone {
+				statusCode = http.StatusNotFound
+			} else if err == errAdminPipelineRunning {
+				statusCode = http.StatusConflict
+			}
+
+			a.helperSendJSONError(&w, newAPIError(statusCode, err.Error()))
+			return
+		}
+
+		jsonRep := AdminZoneRepJSON{
+			Success:  true,
+			ZoneName: *jsonReq.ZoneName,
+		}
+		a.helperSendJSONSuccess(&w, jsonRep)
+	}
+}
+
+func (a *Application) adminRankersReloadHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		rankerWeightMap, err := a.reloadRankerWeights()
+		if err != nil {
+			a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		jsonRep := AdminRankerWeightsRepJSON{
+			Success:       true,
+			RankerWeights: rankerWeightMap,
+		}
+		a.helperSendJSONSuccess(&w, jsonRep)
+	}
+}
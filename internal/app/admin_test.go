@@ -0,0 +1,248 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/task_handlers/seed_sources"
+)
+
+const testAdminAPIKey = "test-admin-key"
+
+func newTestAdminRequest(method string, target string, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+testAdminAPIKey)
+	return r
+}
+
+func TestAdminAuthHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	router := a.getRouter()
+
+	// Admin API is disabled by default
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminPipelinePause, ""))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	a.config.GeneralOptions.AdminAPIKeys = []string{"other-key", testAdminAPIKey}
+
+	// Missing key
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, apiPathAdminPipelinePause, nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// Invalid key
+	r := httptest.NewRequest(http.MethodPost, apiPathAdminPipelinePause, nil)
+	r.Header.Set("X-API-Key", "invalid-key")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// Valid keys
+	r = httptest.NewRequest(http.MethodPost, apiPathAdminPipelinePause, nil)
+	r.Header.Set("X-API-Key", testAdminAPIKey)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminPipelineResume, ""))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestAdminPipelinePauseResume(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	router := a.getRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminPipelinePause, ""))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	jsonRep := AdminPipelineRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.True(t, jsonRep.BIsPaused)
+
+	// Pipeline tasks should be blocked until resumed
+	resumed := make(chan struct{})
+	go func() {
+		a.applicationStateManager.WaitIfPaused()
+		close(resumed)
+	}()
+
+	select {
+	case <-resumed:
+		t.Fatal("Pipeline task wasn't paused.")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminPipelineResume, ""))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Fatal("Pipeline task wasn't resumed.")
+	}
+}
+
+func TestAdminZonePurge(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	a.db.SaveIndexerTaskState("test", database.IndexerTaskState{BIsFinished: true, LineIndex: 2})
+	router := a.getRouter()
+
+	// Unknown zone
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(
+		recorder, newTestAdminRequest(http.MethodPost, apiPathAdminZonePurge, `{"zone_name": "unknown"}`),
+	)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Missing zone name
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminZonePurge, `{}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminZonePurge, `{"zone_name": "test"}`))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Zone should be removed from the index, cache & database
+	assert.Len(t, mustSearch(t, a, mustParseQuery(t, "example"), 0, 10).Results, 0)
+	_, err := os.Stat(path_manager.GetPostCrawlFilterOutputFilePath("test"))
+	assert.True(t, os.IsNotExist(err))
+	assert.False(t, a.db.GetIndexerTaskState("test").BIsFinished)
+
+	// Application should still be ready to search
+	assert.Equal(t, enum.ReadyToSearch, a.applicationStateManager.GetApplicationState().task)
+}
+
+func TestAdminZoneRerun(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	router := a.getRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminZoneRerun, `{"zone_name": "test"}`))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, enum.Initializing, a.applicationStateManager.GetApplicationState().task)
+
+	// Zones can't be altered while the pipeline is running
+	a.applicationStateManager.OnPreCrawlFiltersStarted()
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminZoneRerun, `{"zone_name": "test"}`))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Pipeline isn't restarted once the application is shutting down
+	a.applicationStateManager.OnShutdown()
+	assert.False(t, a.applicationStateManager.OnRestart())
+	assert.Equal(t, enum.Shutdown, a.applicationStateManager.GetApplicationState().task)
+}
+
+func TestAdminZoneRerunPartialRegistry(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	a.seedSourceRegistry = map[string]interfaces.SeedSource{zoneFileSeedSourceType: &seed_sources.ZoneFileSource{}}
+	initializeTestCrawler(a)
+	router := a.getRouter()
+
+	// Both zones are indexed, "other" zone will be re-run
+	zoneFolderPath := t.TempDir()
+	zoneFiles := map[string]string{
+		"test":  "$ORIGIN com.\na 3600 IN NS ns1.example.com.\n",
+		"other": "localhost. 3600 IN NS ns1.example.com.\n",
+	}
+	for zoneName, content := range zoneFiles {
+		zoneFile := filepath.Join(zoneFolderPath, zoneName+".txt")
+		require.NoError(t, os.WriteFile(zoneFile, []byte(content), 0600))
+		a.zoneFileRegistry[zoneName] = zoneFile
+
+		a.db.SavePreCrawlFilterTaskState(zoneName, database.PreCrawlFilterTaskState{BIsFinished: true, LineIndex: 1})
+		a.db.SavePostCrawlFilterTaskState(zoneName, database.PostCrawlFilterTaskState{BIsFinished: true, LineIndex: 1})
+		a.db.SaveIndexerTaskState(zoneName, database.IndexerTaskState{BIsFinished: true, LineIndex: 1})
+	}
+	writeTestPreCrawlCacheFile(t, "test", []string{"a.com"})
+	writeTestPreCrawlCacheFile(t, "other", []string{"localhost"})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminZoneRerun, `{"zone_name": "other"}`))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Documents of the other zones are searchable while the pipeline runs
+	requireSearchable := func() {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example", nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		jsonRep := SearchRepJSON{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+		require.Len(t, jsonRep.Results, 1)
+		assert.Equal(t, "a.com", jsonRep.Results[0].DomainName)
+	}
+	requireSearchable()
+
+	a.applicationStateManager.OnPreCrawlFiltersStarted()
+	runTestPipelineTask(t, a.runPreCrawlFilters)
+	require.Equal(t, enum.FinishedPreCrawlFilters, a.applicationStateManager.GetApplicationState().task)
+	requireSearchable()
+
+	// Re-run zone is dropped by the crawler, thus it doesn't have a post-crawl cache file
+	a.applicationStateManager.OnPostCrawlFiltersStarted()
+	runTestPipelineTask(t, a.runPostCrawlFilters)
+	require.Equal(t, enum.FinishedPostCrawlFilters, a.applicationStateManager.GetApplicationState().task)
+	requireSearchable()
+
+	a.applicationStateManager.OnIndexingStarted()
+	runTestPipelineTask(t, a.runIndexer)
+	require.Equal(t, enum.ReadyToSearch, a.applicationStateManager.GetApplicationState().task)
+	requireSearchable()
+
+	for zoneName := range zoneFiles {
+		assert.True(t, a.db.GetIndexerTaskState(zoneName).BIsFinished, zoneName)
+	}
+	assert.Equal(t, database.IndexerTaskState{BIsFinished: true, LineIndex: 1}, a.db.GetIndexerTaskState("test"))
+}
+
+func TestAdminRankersReload(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	router := a.getRouter()
+
+	configFilePath := filepath.Join(t.TempDir(), "config.toml")
+	path_manager.SetConfigFilePath(configFilePath)
+
+	writeConfig := func(rankers string) {
+		content := "[General]\nversion = \"1.0\"\n" + rankers
+		require.NoError(t, os.WriteFile(configFilePath, []byte(content), 0600))
+	}
+
+	writeConfig("[[Rankers]]\ntype = \"builtin.indexer_ranker\"\nweight = 2.0\n")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminRankersReload, ""))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	jsonRep := AdminRankerWeightsRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.Equal(t, map[string]float64{"builtin.indexer_ranker": 1.0}, jsonRep.RankerWeights)
+
+	// Initialized rankers need to be configured
+	writeConfig("[[Rankers]]\ntype = \"builtin.random_ranker\"\nweight = 1.0\n")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminRankersReload, ""))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, 1.0, a.rankerWeightMap["builtin.indexer_ranker"])
+}
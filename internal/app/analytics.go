@@ -0,0 +1,213 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+)
+
+const (
+	defaultAnalyticsPeriodInHours = 24
+	maxAnalyticsPeriodInHours     = 24 * 365
+	defaultAnalyticsLimit         = 10
+	maxAnalyticsLimit             = 100
+)
+
+// Query log is disabled unless a retention is configured.
+func (a *Application) isQueryLogEnabled() bool {
+	return a.config.GeneralOptions.QueryLogRetentionInDays > 0
+}
+
+// Returns the salted hash of the client address, so that unique clients can be counted without storing addresses.
+func (a *Application) getClientHash(remoteAddress string) (string, error) {
+	a.queryLogSaltMutex.Lock()
+	defer a.queryLogSaltMutex.Unlock()
+
+	if a.queryLogSalt == "" {
+		salt, err := a.db.GetQueryLogSalt()
+		if err != nil {
+			return "", err
+		}
+		a.queryLogSalt = salt
+	}
+
+	hash := sha256.Sum256([]byte(a.queryLogSalt + "\x00" + remoteAddress))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Records the search query to the query log. Query log errors don't affect the search, thus they are logged rather
+// than returned.
+func (a *Application) recordQuery(
+	r *http.Request, query *query_parser.Query, startTime time.Time, resultCount uint64,
+) {
+	if !a.isQueryLogEnabled() {
+		return
+	}
+
+	clientHash, err := a.getClientHash(a.getRequestRemoteAddress(r))
+	if err != nil {
+		zap.L().Warn("Unable to hash query log client.", zap.String("err", err.Error()))
+		return
+	}
+
+	err = a.db.SaveQueryLogEntry(
+		database.QueryLogEntry{
+			Timestamp:       startTime,
+			Query:           query.Raw,
+			NormalizedQuery: query.Normalize(),
+			Latency:         time.Since(startTime),
+			ResultCount:     resultCount,
+			ClientHash:      clientHash,
+		},
+		time.Duration(a.config.GeneralOptions.QueryLogRetentionInDays)*24*time.Hour,
+	)
+	if err != nil {
+		zap.L().Warn("Unable to save query log.", zap.String("err", err.Error()))
+	}
+}
+
+type QueryCountJSON struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+type LatencyPercentilesJSON struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+type AdminAnalyticsRepJSON struct {
+	Success                          bool                   `json:"success"`
+	Error                            string                 `json:"error,omitempty"`
+	Since                            string                 `json:"since"`
+	TotalQueries                     int                    `json:"total_queries"`
+	UniqueClients                    int                    `json:"unique_clients"`
+	ZeroResultQueryCount             int                    `json:"zero_result_query_count"`
+	TopQueries                       []QueryCountJSON       `json:"top_queries"`
+	ZeroResultQueries                []QueryCountJSON       `json:"zero_result_queries"`
+	LatencyPercentilesInMilliseconds LatencyPercentilesJSON `json:"latency_percentiles_in_milliseconds"`
+}
+
+// Returns the most frequent queries, ties are ordered alphabetically.
+func getTopQueries(queryCounts map[string]int, limit int) []QueryCountJSON {
+	topQueries := make([]QueryCountJSON, 0, len(queryCounts))
+	for query, count := range queryCounts {
+		topQueries = append(topQueries, QueryCountJSON{Query: query, Count: count})
+	}
+
+	sort.Slice(
+		topQueries, func(i, j int) bool {
+			if topQueries[i].Count != topQueries[j].Count {
+				return topQueries[i].Count > topQueries[j].Count
+			}
+			return topQueries[i].Query < topQueries[j].Query
+		},
+	)
+
+	if len(topQueries) > limit {
+		topQueries = topQueries[:limit]
+	}
+	return topQueries
+}
+
+// Returns the nearest-rank percentile of the sorted latencies, in milliseconds.
+func getLatencyPercentile(sortedLatencies []time.Duration, percentile float64) float64 {
+	if len(sortedLatencies) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(percentile/100*float64(len(sortedLatencies)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return float64(sortedLatencies[rank].Microseconds()) / 1000
+}
+
+// Aggregates the query log entries. Queries are grouped by their normalized form.
+func getQueryAnalytics(entries []database.QueryLogEntry, since time.Time, limit int) AdminAnalyticsRepJSON {
+	queryCounts := make(map[string]int)
+	zeroResultQueryCounts := make(map[string]int)
+	clientHashes := make(map[string]struct{})
+	latencies := make([]time.Duration, 0, len(entries))
+	zeroResultQueryCount := 0
+
+	for _, entry := range entries {
+		queryCounts[entry.NormalizedQuery]++
+		if entry.ResultCount == 0 {
+			zeroResultQueryCounts[entry.NormalizedQuery]++
+			zeroResultQueryCount++
+		}
+		clientHashes[entry.ClientHash] = struct{}{}
+		latencies = append(latencies, entry.Latency)
+	}
+
+	sort.Slice(
+		latencies, func(i, j int) bool {
+			return latencies[i] < latencies[j]
+		},
+	)
+
+	return AdminAnalyticsRepJSON{
+		Success:              true,
+		Since:                since.UTC().Format(time.RFC3339),
+		TotalQueries:         len(entries),
+		UniqueClients:        len(clientHashes),
+		ZeroResultQueryCount: zeroResultQueryCount,
+		TopQueries:           getTopQueries(queryCounts, limit),
+		ZeroResultQueries:    getTopQueries(zeroResultQueryCounts, limit),
+		LatencyPercentilesInMilliseconds: LatencyPercentilesJSON{
+			P50: getLatencyPercentile(latencies, 50),
+			P90: getLatencyPercentile(latencies, 90),
+			P95: getLatencyPercentile(latencies, 95),
+			P99: getLatencyPercentile(latencies, 99),
+		},
+	}
+}
+
+func (a *Application) adminAnalyticsHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		if !a.isQueryLogEnabled() {
+			a.helperSendJSONError(&w, newAPIError(http.StatusNotFound, "query log is disabled"))
+			return
+		}
+
+		hours, err := helperParseIntQueryParam(r, "hours", defaultAnalyticsPeriodInHours)
+		if err != nil || hours == 0 || hours > maxAnalyticsPeriodInHours {
+			a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid hours parameter"))
+			return
+		}
+
+		limit, err := helperParseIntQueryParam(r, "limit", defaultAnalyticsLimit)
+		if err != nil || limit == 0 || limit > maxAnalyticsLimit {
+			a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid limit parameter"))
+			return
+		}
+
+		// TODO [LP]: Entries are aggregated in memory, consider keeping pre-aggregated counters once the query log
+		//  gets too large.
+		since := time.Now().Add(-time.Duration(hours) * time.Hour)
+		entries, err := a.db.GetQueryLogEntries(since)
+		if err != nil {
+			zap.L().Error("Unable to read query log.", zap.String("err", err.Error()))
+			a.helperSendJSONError(&w, errInternalServerError)
+			return
+		}
+
+		a.helperSendJSONSuccess(&w, getQueryAnalytics(entries, since, limit))
+	}
+}
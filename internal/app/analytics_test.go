@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+)
+
+func TestQueryAnalytics(t *testing.T) {
+	newEntry := func(
+		normalizedQuery string, latencyInMilliseconds int, resultCount uint64, clientHash string,
+	) database.QueryLogEntry {
+		return database.QueryLogEntry{
+			NormalizedQuery: normalizedQuery,
+			Latency:         time.Duration(latencyInMilliseconds) * time.Millisecond,
+			ResultCount:     resultCount,
+			ClientHash:      clientHash,
+		}
+	}
+
+	entries := []database.QueryLogEntry{
+		newEntry("mmorpg", 10, 5, "a"),
+		newEntry("mmorpg", 20, 5, "b"),
+		newEntry("mmorpg", 30, 5, "a"),
+		newEntry("game", 40, 3, "c"),
+		newEntry("unknown", 50, 0, "c"),
+		newEntry("typo", 60, 0, "a"),
+		newEntry("typo", 70, 0, "b"),
+		newEntry("a", 80, 1, "a"),
+		newEntry("b", 90, 1, "a"),
+		newEntry("c", 100, 1, "a"),
+	}
+
+	since := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	analytics := getQueryAnalytics(entries, since, 3)
+	assert.Equal(t, "2023-01-01T00:00:00Z", analytics.Since)
+	assert.Equal(t, 10, analytics.TotalQueries)
+	assert.Equal(t, 3, analytics.UniqueClients)
+	assert.Equal(t, 3, analytics.ZeroResultQueryCount)
+	assert.Equal(
+		t, []QueryCountJSON{{Query: "mmorpg", Count: 3}, {Query: "typo", Count: 2}, {Query: "a", Count: 1}},
+		analytics.TopQueries,
+	)
+	assert.Equal(
+		t, []QueryCountJSON{{Query: "typo", Count: 2}, {Query: "unknown", Count: 1}}, analytics.ZeroResultQueries,
+	)
+	assert.Equal(
+		t, LatencyPercentilesJSON{P50: 50, P90: 90, P95: 100, P99: 100}, analytics.LatencyPercentilesInMilliseconds,
+	)
+
+	// Empty log
+	analytics = getQueryAnalytics(nil, since, 3)
+	assert.Equal(t, 0, analytics.TotalQueries)
+	assert.Len(t, analytics.TopQueries, 0)
+	assert.Equal(t, LatencyPercentilesJSON{}, analytics.LatencyPercentilesInMilliseconds)
+}
+
+func TestAdminAnalyticsHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	router := a.getRouter()
+
+	getAnalytics := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, newTestAdminRequest(http.MethodGet, target, ""))
+		return recorder
+	}
+
+	search := func(target string, remoteAddr string) {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	// Query log is disabled by default
+	search(apiPathSearch+"?q=MMORPG", "192.0.2.1:1234")
+	requireJSONError(t, getAnalytics(apiPathAdminAnalytics), http.StatusNotFound)
+
+	a.config.GeneralOptions.QueryLogRetentionInDays = 30
+	search(apiPathSearch+"?q=MMORPG", "192.0.2.1:1234")
+	search(apiPathSearch+"?q=mmorpg", "192.0.2.2:1234")
+	search(apiPathSearch+"?q=game", "192.0.2.1:1234")
+	// Further pages are not recorded
+	search(apiPathSearch+"?q=game&from=10", "192.0.2.1:1234")
+
+	recorder := getAnalytics(apiPathAdminAnalytics + "?limit=1")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	jsonRep := AdminAnalyticsRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.Equal(t, 3, jsonRep.TotalQueries)
+	assert.Equal(t, 2, jsonRep.UniqueClients)
+	assert.Equal(t, []QueryCountJSON{{Query: "mmorpg", Count: 2}}, jsonRep.TopQueries)
+	assert.Len(t, jsonRep.ZeroResultQueries, 0)
+
+	// Client addresses are not stored
+	entries, err := a.db.GetQueryLogEntries(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "MMORPG", entries[0].Query)
+	assert.NotContains(t, entries[0].ClientHash, "192.0.2.1")
+	assert.Equal(t, entries[0].ClientHash, entries[2].ClientHash)
+
+	requireJSONError(t, getAnalytics(apiPathAdminAnalytics+"?hours=0"), http.StatusBadRequest)
+	requireJSONError(t, getAnalytics(apiPathAdminAnalytics+"?limit=101"), http.StatusBadRequest)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestAdminRequest(http.MethodPost, apiPathAdminAnalytics, ""))
+	requireJSONError(t, recorder, http.StatusMethodNotAllowed)
+
+	// Query log is disabled without a retention
+	assert.Error(t, a.db.SaveQueryLogEntry(database.QueryLogEntry{Timestamp: time.Now()}, 0))
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"net/http"
+)
+
+// APIError is an error reported to API clients, along with its HTTP status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func newAPIError(statusCode int, message string) *APIError {
+	return &APIError{StatusCode: statusCode, Message: message}
+}
+
+var (
+	errNotFound            = newAPIError(http.StatusNotFound, "not found")
+	errMethodNotAllowed    = newAPIError(http.StatusMethodNotAllowed, "method not allowed")
+	errRateLimitExceeded   = newAPIError(http.StatusTooManyRequests, "rate limit exceeded")
+	errIndexNotReady       = newAPIError(http.StatusServiceUnavailable, "index not yet ready")
+	errSearchUnavailable   = newAPIError(http.StatusServiceUnavailable, "search is temporarily unavailable")
+	errInternalServerError = newAPIError(http.StatusInternalServerError, "internal server error")
+)
@@ -3,30 +3,34 @@ package app
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/anthony-ozdemir/zfse/internal/common"
 	"github.com/anthony-ozdemir/zfse/internal/config"
 	"github.com/anthony-ozdemir/zfse/internal/crawler"
 	"github.com/anthony-ozdemir/zfse/internal/database"
 	"github.com/anthony-ozdemir/zfse/internal/enum"
-	"github.com/anthony-ozdemir/zfse/internal/filebuf"
 	"github.com/anthony-ozdemir/zfse/internal/interfaces"
 	"github.com/anthony-ozdemir/zfse/internal/metrics_manager"
+	"github.com/anthony-ozdemir/zfse/internal/openapi"
 	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+	"github.com/anthony-ozdemir/zfse/internal/rate_limiter"
 	"github.com/anthony-ozdemir/zfse/internal/task_handlers/indexers"
 	"github.com/anthony-ozdemir/zfse/internal/task_handlers/post_crawl_filters"
 	"github.com/anthony-ozdemir/zfse/internal/task_handlers/pre_crawl_filters"
 	"github.com/anthony-ozdemir/zfse/internal/task_handlers/rankers"
+	"github.com/anthony-ozdemir/zfse/internal/task_handlers/seed_sources"
 )
 
 type Application struct {
@@ -39,11 +43,17 @@ type Application struct {
 	crawler                 *crawler.Crawler
 	httpServer              *http.Server
 	applicationStateManager *ApplicationStateManager
+	// Saved searches are kept in the user data folder, so that purging the cache folder doesn't destroy them
+	userDB *database.Database
 
 	// Zone File Registry
+	// Design Note: Every seed file is registered as a zone named after the file, regardless of its seed source.
 	zoneFileRegistry map[string]string
+	// Seed source options of the zones, zones without options are read as zone files
+	zoneSeedSourceOptions map[string]config.TaskHandlerOptions
 
 	// Task Handler Registry
+	seedSourceRegistry      map[string]interfaces.SeedSource
 	preCrawlFilterRegistry  map[string]interfaces.PreConnectionFilter
 	postCrawlFilterRegistry map[string]interfaces.PostConnectionFilter
 	indexerRegistry         map[string]interfaces.Indexer
@@ -56,15 +66,50 @@ type Application struct {
 	rankerArray          []*interfaces.Ranker
 
 	// Normalized Ranker Weight Map
-	rankerWeightMap map[string]float64
+	// Design Note: Weights can be reloaded while queries are running, thus the map is guarded by a mutex.
+	rankerWeightMap   map[string]float64
+	rankerWeightMutex sync.RWMutex
+
+	// Query Engine
+	availableQuerySlots chan struct{}
+
+	// HTTP Limits
+	rateLimiter    *rate_limiter.RateLimiter
+	trustedProxies []*net.IPNet
+
+	// OpenAPI document of the HTTP API, used to validate requests
+	apiSpec *openapi.Spec
+
+	// Query Log
+	// Design Note: Salt is loaded lazily from the database, thus it is guarded by a mutex.
+	queryLogSalt      string
+	queryLogSaltMutex sync.Mutex
+
+	// Clicks
+	// Design Note: Secret is loaded lazily from the database, thus it is guarded by a mutex.
+	clickTokenSecret      string
+	clickTokenSecretMutex sync.Mutex
+
+	// Saved Searches
+	// Design Note: Alert queue & its worker are created on first use, see queueSavedSearchAlert.
+	savedSearchAlertQueue     chan savedSearchAlertDelivery
+	savedSearchAlertOnce      sync.Once
+	pendingSavedSearchAlerts  sync.WaitGroup
+	savedSearchAlertFileMutex sync.Mutex
+
+	// Admin API
+	// Design Note: Serializes zone operations, so that zones can't be altered by multiple requests at once.
+	adminMutex sync.Mutex
 }
 
 func NewApplication(applicationConfig config.ApplicationConfig) *Application {
 	a := Application{
 		// Initialize and allocate all built-in containers
 		// Zone File Registry
-		zoneFileRegistry: make(map[string]string),
+		zoneFileRegistry:      make(map[string]string),
+		zoneSeedSourceOptions: make(map[string]config.TaskHandlerOptions),
 		// Task Handler Registry
+		seedSourceRegistry:      make(map[string]interfaces.SeedSource),
 		preCrawlFilterRegistry:  make(map[string]interfaces.PreConnectionFilter),
 		postCrawlFilterRegistry: make(map[string]interfaces.PostConnectionFilter),
 		indexerRegistry:         make(map[string]interfaces.Indexer),
@@ -86,6 +131,7 @@ func NewApplication(applicationConfig config.ApplicationConfig) *Application {
 
 	// Register built-in Task Handlers
 	// TODO [MP]: We need to create a registry manager system so that plugins can also register Task Handlers
+	a.registerSeedSources()
 	a.registerPreCrawlFilters()
 	a.registerPostCrawlFilters()
 	a.registerIndexers()
@@ -94,40 +140,18 @@ func NewApplication(applicationConfig config.ApplicationConfig) *Application {
 	// Initialize Task Handlers
 	a.initializeTaskHandlers()
 
+	// Setup query engine
+	a.initializeQuerySlots()
+
 	// Setup metrics
 	a.metricsManager = metrics_manager.New()
+	a.registerMetrics()
 
 	// Prepare zoneFileRegistry
-	zoneFilesFolderPath := path_manager.GetZoneFilesFolderPath()
-	zoneFiles, err := os.ReadDir(zoneFilesFolderPath)
-	if err != nil {
-		zap.L().Fatal(
-			"Unable to read zone zoneFiles directory.",
-			zap.String("path", zoneFilesFolderPath),
-			zap.String("err", err.Error()),
-		)
-	}
-
-	for _, zoneFile := range zoneFiles {
-		filePath := filepath.Join(zoneFilesFolderPath, zoneFile.Name())
-		if !zoneFile.IsDir() {
-			// Design Note: Remove extension from zoneFile name
-			zoneFileName := strings.TrimSuffix(zoneFile.Name(), filepath.Ext(zoneFile.Name()))
-
-			a.zoneFileRegistry[zoneFileName] = filePath
-		}
-	}
-
-	if len(a.zoneFileRegistry) <= 0 {
-		zap.L().Fatal(
-			fmt.Sprintf(
-				"No zone files found. Please ensure that zone files are correctly "+
-					"located under %v", path_manager.GetZoneFilesFolderPath(),
-			),
-		)
-	}
+	a.registerSeedFiles()
 
 	// Setup HTTP-Server
+	a.initializeHTTPLimits()
 	a.httpServer = &http.Server{
 		Handler:        a.getRouter(),
 		Addr:           a.config.GeneralOptions.ListenAddr + ":" + a.config.GeneralOptions.ListenPort,
@@ -144,6 +168,9 @@ func NewApplication(applicationConfig config.ApplicationConfig) *Application {
 		MinContentLengthInBytes: a.config.GeneralOptions.MinContentLengthInBytes,
 		MaxContentLengthInBytes: a.config.GeneralOptions.MaxContentLengthInBytes,
 		ContentReadLimitInBytes: a.config.GeneralOptions.ContentReadLimitInBytes,
+		OnBytesReadCB: func(bytesRead int64) {
+			a.metricsManager.IncCounter(metricFetchedBytes, bytesRead)
+		},
 	}
 	a.crawler = crawler.NewCrawler(crawlerOpts)
 
@@ -161,10 +188,37 @@ func NewApplication(applicationConfig config.ApplicationConfig) *Application {
 
 	a.db = db
 
+	userDBPath := path_manager.GetUserDatabaseFilePath()
+	userDB, errDB := database.NewDatabase(userDBPath)
+	if errDB != nil {
+		zap.L().Fatal(
+			"Unable to create user database.",
+			zap.String("path", userDBPath),
+			zap.String("err", errDB.Error()),
+		)
+	}
+
+	a.userDB = userDB
+
+	// Recreated indexes are indexed again from the post-crawl cache files
+	if reindexer, ok := (*a.indexer).(interfaces.Reindexer); ok && reindexer.NeedsReindex() {
+		for zoneName := range a.zoneFileRegistry {
+			a.db.SaveIndexerTaskState(zoneName, database.IndexerTaskState{BIsFinished: false, LineIndex: 0})
+		}
+	}
+
 	return &a
 }
 
 // Functions for registering Task Handlers.
+func (a *Application) registerSeedSources() {
+	zap.L().Info("Registering built-in Seed Sources")
+	a.seedSourceRegistry[zoneFileSeedSourceType] = &seed_sources.ZoneFileSource{}
+	a.seedSourceRegistry["builtin.domain_list"] = &seed_sources.DomainListSource{}
+	a.seedSourceRegistry["builtin.ranked_csv"] = &seed_sources.RankedCSVSource{}
+	a.seedSourceRegistry["builtin.ct_jsonl"] = &seed_sources.CTJSONLSource{}
+}
+
 func (a *Application) registerPreCrawlFilters() {
 	zap.L().Info("Registering built-in Pre-Crawl Filters")
 	a.preCrawlFilterRegistry["builtin.unique_domain"] = &pre_crawl_filters.UniqueDomainFilter{}
@@ -187,6 +241,7 @@ func (a *Application) registerRankers() {
 	zap.L().Info("Registering built-in Rankers")
 	a.rankerRegistry["builtin.random_ranker"] = &rankers.RandomRanker{}
 	a.rankerRegistry["builtin.indexer_ranker"] = &rankers.IndexerRanker{}
+	a.rankerRegistry["builtin.click_ranker"] = &rankers.ClickRanker{}
 }
 
 // Initializes Task Handlers (PreCrawlFilter, PostCrawlFilter, Indexer, Ranker).
@@ -259,7 +314,6 @@ func (a *Application) initializeTaskHandlers() {
 	}
 
 	// Rankers
-	totalRankerWeights := 0.0
 	for _, rankerOptions := range a.config.RankerOptions {
 		rankerType := rankerOptions.Type
 		ranker, ok := a.rankerRegistry[rankerType]
@@ -277,51 +331,66 @@ func (a *Application) initializeTaskHandlers() {
 				zap.String("err", err.Error()),
 			)
 		}
+		if clickStatsConsumer, ok := ranker.(interfaces.ClickStatsConsumer); ok {
+			clickStatsConsumer.SetClickStatsProvider(&clickStatsProvider{a: a})
+		}
 		a.rankerArray = append(a.rankerArray, &ranker)
-		rankerWeight := rankerOptions.FloatOptions["weight"]
-		totalRankerWeights += rankerWeight
 	}
 
 	// Let's normalize ranker weights
+	rankerWeightMap, err := a.getNormalizedRankerWeights(a.config.RankerOptions)
+	if err != nil {
+		zap.L().Fatal("Invalid ranker weights.", zap.String("err", err.Error()))
+	}
+	a.rankerWeightMap = rankerWeightMap
+}
+
+// Returns the ranker weights of the initialized rankers, normalized by the total weight of the ranker options.
+func (a *Application) getNormalizedRankerWeights(rankerOptions []config.TaskHandlerOptions) (
+	map[string]float64, error,
+) {
+	totalRankerWeights := 0.0
+	for _, rankerOption := range rankerOptions {
+		totalRankerWeights += rankerOption.FloatOptions["weight"]
+	}
+	if totalRankerWeights <= 0 {
+		return nil, fmt.Errorf("total ranker weight must be positive")
+	}
+
+	rankerWeightMap := make(map[string]float64)
 	for _, ranker := range a.rankerArray {
 		rankerType := (*ranker).GetType()
-		foundRankerOption := config.TaskHandlerOptions{}
-		for _, taskHandlerConfig := range a.config.RankerOptions {
-			if taskHandlerConfig.Type == rankerType {
-				foundRankerOption = taskHandlerConfig
+		bIsFound := false
+		for _, rankerOption := range rankerOptions {
+			if rankerOption.Type == rankerType {
+				rankerWeightMap[rankerType] = rankerOption.FloatOptions["weight"] / totalRankerWeights
+				bIsFound = true
 				break
 			}
 		}
-		rankerWeight := foundRankerOption.FloatOptions["weight"]
-		normalizedRankerWeight := rankerWeight / totalRankerWeights
-		a.rankerWeightMap[rankerType] = normalizedRankerWeight
+		if !bIsFound {
+			return nil, fmt.Errorf("ranker %v is not configured", rankerType)
+		}
 	}
+
+	return rankerWeightMap, nil
 }
 
-func (a *Application) Rank(userQuery string) {
-	a.applicationStateManager.OnRankingStarted()
+// Rank runs a query and logs the ranked output.
+// Design Note: Ranked outputs are cached in the database by Search, see ranking_cache.go.
+func (a *Application) Rank(userQuery string) []common.DomainProperties {
 	zap.L().Info("Starting query.", zap.String("user_query", userQuery))
-	// Query
-	indexerOutput := a.queryIndexer(userQuery)
-
-	// Ready to rank at this stage.
-	rankerOutput := a.runRankers(indexerOutput, userQuery)
-
-	// Let's cache the ranker output
-	rankerOutputBufferOpts := filebuf.FileOutputBufferOptions{
-		BulkOutputLimit: a.config.GeneralOptions.FileBulkOutputQty,
-		FilePath:        path_manager.GetRankingFilePath(""),
+	query, err := query_parser.Parse(userQuery)
+	if err != nil {
+		zap.L().Error("Invalid query.", zap.String("user_query", userQuery), zap.String("err", err.Error()))
+		return nil
 	}
-	rankerOutputBuffer := filebuf.NewFileOutputBuffer(rankerOutputBufferOpts)
-
-	for _, output := range rankerOutput {
-		jsonString, err := output.ToJSONString()
-		if err != nil {
-			zap.L().Fatal("Unable to unmarshall JSON", zap.String("err", err.Error()))
-		}
-		rankerOutputBuffer.AppendToFile(jsonString)
+	searchOutput, err := a.Search(query, 0, int(a.config.GeneralOptions.IndexerOutputLimit))
+	if err != nil {
+		zap.L().Error("Unable to search.", zap.String("user_query", userQuery), zap.String("err", err.Error()))
+		return nil
 	}
-	rankerOutputBuffer.Flush()
+	rankerOutput := searchOutput.Results
 
 	// TODO [HP]: Delete once WebUI is available
 	queryOutput := make([]zap.Field, 0)
@@ -330,8 +399,14 @@ func (a *Application) Rank(userQuery string) {
 	}
 	zap.L().Info("Ranker results", queryOutput...)
 
-	a.applicationStateManager.OnReadyToSearch()
 	zap.L().Info(fmt.Sprintf("Ranking finished. Ready to search."))
+
+	return rankerOutput
+}
+
+// Returns the URL that the crawler has used for the domain.
+func (a *Application) getDomainURL(domainName string) string {
+	return a.config.GeneralOptions.ConnectionProtocol + "://" + domainName
 }
 
 func (a *Application) outputMetrics(applicationState ApplicationState) {
@@ -370,8 +445,7 @@ func (a *Application) Run(userQuery string) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		// TODO [HP]: Implement webUI
-		zap.L().Info(fmt.Sprintf("Starting server on http://%v", a.httpServer.Addr))
+		zap.L().Info(fmt.Sprintf("Starting server & Web UI on http://%v", a.httpServer.Addr))
 		err := a.httpServer.ListenAndServe()
 		if err != http.ErrServerClosed {
 			zap.L().Fatal("Server launch failed.", zap.String("err", err.Error()))
@@ -438,10 +512,17 @@ func (a *Application) Run(userQuery string) {
 
 	wg.Wait() // Wait for all goroutines to finish
 
+	// Pending saved search alerts are appended to the alerts file during shutdown
+	a.pendingSavedSearchAlerts.Wait()
+
 	errClose := a.db.Close()
 	if errClose != nil {
 		zap.L().Fatal("Unable to close the db.", zap.String("err", errClose.Error()))
 	}
+	errClose = a.userDB.Close()
+	if errClose != nil {
+		zap.L().Fatal("Unable to close the user db.", zap.String("err", errClose.Error()))
+	}
 	zap.L().Info("Application shut-down.")
 
 }
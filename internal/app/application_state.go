@@ -15,15 +15,26 @@ type ApplicationState struct {
 	remainingWorkItems             int
 	estimateRemainingTimeInSeconds int
 	errorDetails                   string
+	bIsPaused                      bool
+	// Index was ready once, thus it stays searchable while zones are re-run
+	bIsSearchable bool
 }
 
 type ApplicationStateManager struct {
 	mutex            sync.Mutex
 	applicationState ApplicationState
+	// Signaled when the pipeline is resumed or shut down
+	resumeCond *sync.Cond
+	// Notified after every state transition
+	subscribers map[chan ApplicationState]struct{}
 }
 
+// Design Note: Subscribers are notified without blocking, otherwise a slow subscriber would stall the pipeline. Thus,
+// buffer size should be large enough to hold a burst of transitions.
+const applicationStateSubscriberBufferSize = 16
+
 func NewApplicationStateManager() *ApplicationStateManager {
-	return &ApplicationStateManager{
+	a := &ApplicationStateManager{
 		applicationState: ApplicationState{
 			task:                           enum.Initializing,
 			totalWorkItems:                 0,
@@ -32,6 +43,40 @@ func NewApplicationStateManager() *ApplicationStateManager {
 			errorDetails:                   "",
 		},
 	}
+	a.resumeCond = sync.NewCond(&a.mutex)
+	a.subscribers = make(map[chan ApplicationState]struct{})
+	return a
+}
+
+// Subscribe returns a channel which receives the application state after every state transition, including pause &
+// resume. Transitions are dropped for subscribers which are too slow to keep up.
+func (a *ApplicationStateManager) Subscribe() chan ApplicationState {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	subscription := make(chan ApplicationState, applicationStateSubscriberBufferSize)
+	a.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+func (a *ApplicationStateManager) Unsubscribe(subscription chan ApplicationState) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if _, ok := a.subscribers[subscription]; ok {
+		delete(a.subscribers, subscription)
+		close(subscription)
+	}
+}
+
+// Caller must hold the mutex.
+func (a *ApplicationStateManager) publish() {
+	for subscription := range a.subscribers {
+		select {
+		case subscription <- a.applicationState:
+		default:
+		}
+	}
 }
 
 func (a *ApplicationStateManager) GetApplicationState() ApplicationState {
@@ -65,6 +110,41 @@ func (a *ApplicationStateManager) SetProcessedWorkItems(processedWorkItems int)
 	a.applicationState.estimateRemainingTimeInSeconds = int(estimateRemainingDurationInSeconds)
 }
 
+// Pause pauses the pipeline tasks. Queries are not affected.
+func (a *ApplicationStateManager) Pause() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.applicationState.bIsPaused = true
+
+	a.publish()
+}
+
+func (a *ApplicationStateManager) Resume() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.applicationState.bIsPaused = false
+	a.resumeCond.Broadcast()
+
+	a.publish()
+}
+
+// WaitIfPaused blocks the calling pipeline task until the pipeline is resumed or shut down.
+func (a *ApplicationStateManager) WaitIfPaused() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for a.applicationState.bIsPaused && a.applicationState.task != enum.Shutdown {
+		a.resumeCond.Wait()
+	}
+}
+
+// IsSearchable checks whether queries can be served. Re-running a zone restarts the pipeline, yet the documents of the
+// other zones remain in the index. Thus, queries are served from the first time the index is ready until shutdown.
+func (a *ApplicationStateManager) IsSearchable() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.applicationState.bIsSearchable && a.applicationState.task != enum.Shutdown
+}
+
 // Design Note: We need to create a small state-machine here, where
 // application can only move to a limited set of states from an initial state.
 
@@ -78,6 +158,8 @@ func (a *ApplicationStateManager) OnPreCrawlFiltersStarted() {
 
 	a.applicationState.task = enum.RunningPreCrawlFilters
 	a.applicationState.taskStartTime = time.Now()
+
+	a.publish()
 }
 
 func (a *ApplicationStateManager) OnPreCrawlFiltersFinished() {
@@ -92,6 +174,8 @@ func (a *ApplicationStateManager) OnPreCrawlFiltersFinished() {
 	a.applicationState.totalWorkItems = 0
 	a.applicationState.processedWorkItems = 0
 	a.applicationState.estimateRemainingTimeInSeconds = 0
+
+	a.publish()
 }
 
 func (a *ApplicationStateManager) OnPostCrawlFiltersStarted() {
@@ -104,6 +188,8 @@ func (a *ApplicationStateManager) OnPostCrawlFiltersStarted() {
 
 	a.applicationState.task = enum.RunningPostCrawlFilters
 	a.applicationState.taskStartTime = time.Now()
+
+	a.publish()
 }
 
 func (a *ApplicationStateManager) OnPostCrawlFiltersFinished() {
@@ -118,6 +204,8 @@ func (a *ApplicationStateManager) OnPostCrawlFiltersFinished() {
 	a.applicationState.totalWorkItems = 0
 	a.applicationState.processedWorkItems = 0
 	a.applicationState.estimateRemainingTimeInSeconds = 0
+
+	a.publish()
 }
 
 func (a *ApplicationStateManager) OnIndexingStarted() {
@@ -130,32 +218,47 @@ func (a *ApplicationStateManager) OnIndexingStarted() {
 
 	a.applicationState.task = enum.RunningIndexer
 	a.applicationState.taskStartTime = time.Now()
+
+	a.publish()
 }
 
 func (a *ApplicationStateManager) OnReadyToSearch() {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	if a.applicationState.task != enum.RunningIndexer && a.applicationState.task != enum.RunningRankers {
+	if a.applicationState.task != enum.RunningIndexer {
 		panic("Programming error.")
 	}
 
 	a.applicationState.task = enum.ReadyToSearch
+	a.applicationState.bIsSearchable = true
 	a.applicationState.totalWorkItems = 0
 	a.applicationState.processedWorkItems = 0
 	a.applicationState.estimateRemainingTimeInSeconds = 0
+
+	a.publish()
 }
 
-func (a *ApplicationStateManager) OnRankingStarted() {
+// OnRestart restarts the pipeline, i.e. after a zone is scheduled for a re-run. Finished zones are skipped by the
+// pipeline tasks. Returns false if the pipeline isn't idle.
+func (a *ApplicationStateManager) OnRestart() bool {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	if a.applicationState.task != enum.ReadyToSearch {
-		panic("Programming error.")
+	// Design Note: Restarts are requested by admins, thus the state may have changed since the caller checked it
+	// (i.e. the application is shutting down). The state is checked under the mutex instead of panicking.
+	if a.applicationState.task != enum.ReadyToSearch && a.applicationState.task != enum.Errored {
+		return false
 	}
 
-	a.applicationState.task = enum.RunningRankers
-	a.applicationState.taskStartTime = time.Now()
+	a.applicationState.task = enum.Initializing
+	a.applicationState.errorDetails = ""
+	a.applicationState.totalWorkItems = 0
+	a.applicationState.processedWorkItems = 0
+	a.applicationState.estimateRemainingTimeInSeconds = 0
+
+	a.publish()
+	return true
 }
 
 func (a *ApplicationStateManager) OnErrored(errorDetails string) {
@@ -164,6 +267,8 @@ func (a *ApplicationStateManager) OnErrored(errorDetails string) {
 
 	a.applicationState.task = enum.Errored
 	a.applicationState.errorDetails = errorDetails
+
+	a.publish()
 }
 
 func (a *ApplicationStateManager) OnShutdown() {
@@ -171,4 +276,7 @@ func (a *ApplicationStateManager) OnShutdown() {
 	defer a.mutex.Unlock()
 
 	a.applicationState.task = enum.Shutdown
+	a.resumeCond.Broadcast()
+
+	a.publish()
 }
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+	"github.com/anthony-ozdemir/zfse/internal/rate_limiter"
+)
+
+const (
+	// Position bias is modeled as propensity(position) = (1 / position) ^ exponent
+	clickPositionBiasExponent = 1.0
+	// Design Note: Clicks are weighted by the inverse propensity of their position, thus a single click on a deep
+	// position could outweigh many clicks on top positions. Positions beyond this one are weighted as this one.
+	maxClickPositionWeight = 20
+	// Repeated clicks of a client on the same result of the same query are counted once within this window
+	clickDeduplicationWindow = 24 * time.Hour
+)
+
+// Returns the inverse propensity weight of a click on the 1-based position.
+func getClickWeight(position int) float64 {
+	if position < 1 {
+		position = 1
+	} else if position > maxClickPositionWeight {
+		position = maxClickPositionWeight
+	}
+	return math.Pow(float64(position), clickPositionBiasExponent)
+}
+
+// Normalizes the user query the same way as the ranking cache, so that click stats are shared by similar queries.
+// Queries which can't be parsed are only lowercased & whitespace collapsed.
+func normalizeUserQuery(userQuery string) string {
+	query, err := query_parser.Parse(userQuery)
+	if err != nil {
+		return strings.Join(strings.Fields(strings.ToLower(userQuery)), " ")
+	}
+	return query.Normalize()
+}
+
+// Returns the token of the search result position, so that the click handler can trust the position of the click.
+// Design Note: Click weights depend on the position, thus positions are signed by the server while building the search
+// results. Otherwise, a single forged click on a deep position would count as many clicks.
+func (a *Application) getClickToken(userQuery string, indexID string, position int) (string, error) {
+	a.clickTokenSecretMutex.Lock()
+	defer a.clickTokenSecretMutex.Unlock()
+
+	if a.clickTokenSecret == "" {
+		secret, err := a.db.GetClickTokenSecret()
+		if err != nil {
+			return "", err
+		}
+		a.clickTokenSecret = secret
+	}
+
+	mac := hmac.New(sha256.New, []byte(a.clickTokenSecret))
+	mac.Write([]byte(normalizeUserQuery(userQuery) + "\x00" + indexID + "\x00" + strconv.Itoa(position)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Returns the position of the click if its token is valid, otherwise the click is weighted as a top position click.
+func (a *Application) getVerifiedClickPosition(userQuery string, indexID string, position int, token string) int {
+	expectedToken, err := a.getClickToken(userQuery, indexID, position)
+	if err != nil {
+		zap.L().Warn("Unable to verify click token.", zap.String("err", err.Error()))
+		return 1
+	}
+	if !hmac.Equal([]byte(token), []byte(expectedToken)) {
+		return 1
+	}
+	return position
+}
+
+// Provides click stats to the rankers supporting them, see interfaces.ClickStatsConsumer.
+type clickStatsProvider struct {
+	a *Application
+}
+
+func (p *clickStatsProvider) GetClickStats(userQuery string, domainName string) (common.ClickStats, error) {
+	return p.a.db.GetClickStats(normalizeUserQuery(userQuery), domainName)
+}
+
+// Records the click on the search result. Click events are kept as long as the query log, whereas click stats are
+// always updated since they don't carry any client data. Click stats count the clicks of a client on the same result
+// of the same query once within the deduplication window. Errors only affect the click ranker, thus they are logged
+// rather than returned.
+// Design Note: Rankings cached before the click are served until they expire, see ranking_cache_ttl_in_seconds.
+func (a *Application) recordClick(
+	r *http.Request, userQuery string, indexID string, domainName string, position int,
+) {
+	normalizedQuery := normalizeUserQuery(userQuery)
+
+	// Design Note: Clients are deduplicated the same way they are rate limited, i.e. IPv6 clients per /64 prefix.
+	clientHash, err := a.getClientHash(rate_limiter.GetClientKey(a.getRequestRemoteAddress(r)))
+	if err != nil {
+		zap.L().Warn("Unable to hash click client.", zap.String("err", err.Error()))
+		return
+	}
+	deduplicationHash := sha256.Sum256([]byte(clientHash + "\x00" + normalizedQuery + "\x00" + domainName))
+	bIsNewClick, err := a.db.MarkClickSeen(hex.EncodeToString(deduplicationHash[:]), clickDeduplicationWindow)
+	if err != nil {
+		zap.L().Warn("Unable to deduplicate click.", zap.String("err", err.Error()))
+	}
+
+	if bIsNewClick {
+		err = a.db.AddClickStats(normalizedQuery, domainName, getClickWeight(position))
+		if err != nil {
+			zap.L().Warn("Unable to save click stats.", zap.String("err", err.Error()))
+		}
+	}
+
+	if !a.isQueryLogEnabled() {
+		return
+	}
+
+	clientHash, err = a.getClientHash(a.getRequestRemoteAddress(r))
+	if err != nil {
+		zap.L().Warn("Unable to hash click log client.", zap.String("err", err.Error()))
+		return
+	}
+
+	err = a.db.SaveClickEvent(
+		database.ClickEvent{
+			Timestamp:       time.Now(),
+			Query:           userQuery,
+			NormalizedQuery: normalizedQuery,
+			IndexID:         indexID,
+			DomainName:      domainName,
+			Position:        position,
+			ClientHash:      clientHash,
+		},
+		time.Duration(a.config.GeneralOptions.QueryLogRetentionInDays)*24*time.Hour,
+	)
+	if err != nil {
+		zap.L().Warn("Unable to save click event.", zap.String("err", err.Error()))
+	}
+}
+
+// Records the click and redirects to the search result. Web UI links the search results through this handler.
+func (a *Application) clickGetHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Headers
+		w.Header().Set("Cache-Control", "no-store") // Every click needs to reach the server
+		w.Header().Set("Referrer-Policy", "no-referrer")
+
+		if r.Method != http.MethodGet {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		// Check existence of mandatory query parameters
+		userQuery := strings.TrimSpace(r.URL.Query().Get("q"))
+		indexID := r.URL.Query().Get("id")
+		if userQuery == "" || indexID == "" {
+			a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "query parameter missing"))
+			return
+		}
+
+		position, err := helperParseIntQueryParam(r, "pos", 1)
+		if err != nil || position == 0 {
+			a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid pos parameter"))
+			return
+		}
+
+		// Design Note: Only indexed domains can be redirected to, otherwise the handler would be an open redirect.
+		// Zone name is also verified, since it is a part of the post-crawl cache file path.
+		zoneName, _, err := parseIndexID(indexID)
+		if _, ok := a.zoneFileRegistry[zoneName]; err != nil || !ok {
+			a.helperSendJSONError(&w, newAPIError(http.StatusNotFound, "unknown result"))
+			return
+		}
+
+		domainProperties, err := readIndexedDomainProperties(indexID)
+		if err != nil || domainProperties.DomainName == "" {
+			a.helperSendJSONError(&w, newAPIError(http.StatusNotFound, "unknown result"))
+			return
+		}
+
+		position = a.getVerifiedClickPosition(userQuery, indexID, position, r.URL.Query().Get("token"))
+		a.recordClick(r, userQuery, indexID, domainProperties.DomainName, position)
+
+		http.Redirect(w, r, a.getDomainURL(domainProperties.DomainName), http.StatusFound)
+	}
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/task_handlers/rankers"
+)
+
+func TestGetClickWeight(t *testing.T) {
+	assert.Equal(t, 1.0, getClickWeight(0))
+	assert.Equal(t, 1.0, getClickWeight(1))
+	assert.Equal(t, 5.0, getClickWeight(5))
+	assert.Equal(t, float64(maxClickPositionWeight), getClickWeight(1000))
+}
+
+func TestClickGetHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	router := a.getRouter()
+
+	click := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	// Similar queries share the click tokens
+	token, err := a.getClickToken("mmorpg", "test_1", 3)
+	require.NoError(t, err)
+	recorder := click(apiPathClick + "?q=MMORPG&id=test_1&pos=3&token=" + token)
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	assert.Equal(t, "https://b.com", recorder.Header().Get("Location"))
+
+	// Clicks on lower positions weigh more
+	recorder = click(apiPathClick + "?q=mmorpg&id=test_0")
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	assert.Equal(t, "https://a.com", recorder.Header().Get("Location"))
+
+	clickStats, err := a.db.GetClickStats("mmorpg", "b.com")
+	require.NoError(t, err)
+	assert.Equal(t, common.ClickStats{QueryDomainClicks: 3, QueryClicks: 4, DomainClicks: 3, TotalClicks: 4}, clickStats)
+
+	// Repeated clicks of the same client are counted once
+	click(apiPathClick + "?q=mmorpg&id=test_1&pos=3&token=" + token)
+	clickStats, err = a.db.GetClickStats("mmorpg", "b.com")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, clickStats.QueryDomainClicks)
+
+	// Positions without a valid token are weighted as the first position
+	for _, target := range []string{
+		apiPathClick + "?q=game&id=test_1&pos=20",
+		apiPathClick + "?q=rpg&id=test_1&pos=20&token=" + token,
+		apiPathClick + "?q=mmo&id=test_0&pos=20&token=" + token,
+	} {
+		assert.Equal(t, http.StatusFound, click(target).Code)
+	}
+	clickStats, err = a.db.GetClickStats("game", "b.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, clickStats.QueryDomainClicks)
+	clickStats, err = a.db.GetClickStats("rpg", "b.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, clickStats.QueryDomainClicks)
+	clickStats, err = a.db.GetClickStats("mmo", "a.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, clickStats.QueryDomainClicks)
+
+	// Query log is disabled, thus click events are not recorded
+	clickEvents, err := a.db.GetClickEvents(time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, clickEvents, 0)
+
+	a.config.GeneralOptions.QueryLogRetentionInDays = 30
+	token, err = a.getClickToken("game", "test_0", 2)
+	require.NoError(t, err)
+	click(apiPathClick + "?q=game&id=test_0&pos=2&token=" + token)
+	clickEvents, err = a.db.GetClickEvents(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, clickEvents, 1)
+	assert.Equal(t, "game", clickEvents[0].Query)
+	assert.Equal(t, "a.com", clickEvents[0].DomainName)
+	assert.Equal(t, 2, clickEvents[0].Position)
+	assert.WithinDuration(t, time.Now(), clickEvents[0].Timestamp, time.Minute)
+
+	// Only indexed domains can be redirected to
+	for _, target := range []string{
+		apiPathClick + "?q=game&id=test_2",
+		apiPathClick + "?q=game&id=other_0",
+		apiPathClick + "?q=game&id=../../test_0",
+		apiPathClick + "?q=game&id=test",
+	} {
+		requireJSONError(t, click(target), http.StatusNotFound)
+	}
+	requireJSONError(t, click(apiPathClick+"?q=game"), http.StatusBadRequest)
+	requireJSONError(t, click(apiPathClick+"?q=game&id=test_0&pos=0"), http.StatusBadRequest)
+}
+
+func TestClickRankerSearch(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com", "c.com"})
+
+	var ranker interfaces.Ranker = &rankers.ClickRanker{}
+	require.NoError(t, ranker.Initialize(newTestTaskHandlerOptions("builtin.click_ranker")))
+	ranker.(interfaces.ClickStatsConsumer).SetClickStatsProvider(&clickStatsProvider{a: a})
+	a.rankerArray = append(a.rankerArray, &ranker)
+	// Random indexer scores are normalized between 0.0 and 1.0, thus clicks need to outweigh them
+	a.rankerWeightMap["builtin.indexer_ranker"] = 0.25
+	a.rankerWeightMap["builtin.click_ranker"] = 0.75
+
+	// Clicks of different clients are counted separately
+	router := a.getRouter()
+	for i := 0; i < 3; i++ {
+		request := httptest.NewRequest(http.MethodGet, apiPathClick+"?q=MMORPG&id=test_2&pos=3", nil)
+		request.RemoteAddr = fmt.Sprintf("192.0.2.%d:1234", i+1)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusFound, recorder.Code)
+	}
+
+	// Similar queries share the click stats
+	output := mustSearch(t, a, mustParseQuery(t, "mmorpg"), 0, 10)
+	require.Len(t, output.Results, 3)
+	assert.Equal(t, "c.com", output.Results[0].DomainName)
+}
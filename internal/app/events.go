@@ -0,0 +1,208 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+)
+
+const (
+	eventTypeState    = "state"
+	eventTypeProgress = "progress"
+	eventTypeError    = "error"
+)
+
+const (
+	eventProgressInterval    = 1 * time.Second
+	eventKeepAliveInterval   = 15 * time.Second
+	eventRetryInMilliseconds = 5000
+)
+
+type StateEventJSON struct {
+	ApplicationTask string `json:"application_task"`
+	BIsPaused       bool   `json:"b_is_paused"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+type ProgressEventJSON struct {
+	ApplicationTask                string  `json:"application_task"`
+	TotalWorkItems                 int     `json:"total_work_items"`
+	ProcessedWorkItems             int     `json:"processed_work_items"`
+	RemainingWorkItems             int     `json:"remaining_work_items"`
+	ThroughputPerSecond            float64 `json:"throughput_per_second"`
+	EstimateRemainingTimeInSeconds int     `json:"estimate_remaining_time_in_seconds"`
+	Timestamp                      int64   `json:"timestamp"`
+}
+
+type ErrorEventJSON struct {
+	ApplicationTask string `json:"application_task"`
+	ErrorDetails    string `json:"error_details"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// Writes Server-Sent Events to a single client.
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	// Time of the last write, used to decide when a keep-alive is needed
+	lastWriteTime time.Time
+	// Used to compute the throughput between progress events
+	lastProgressTask      enum.ApplicationTask
+	lastProcessedItems    int
+	lastProgressEventTime time.Time
+}
+
+func (e *eventStream) writeRaw(message string) error {
+	_, err := fmt.Fprint(e.w, message)
+	if err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	e.lastWriteTime = time.Now()
+	return nil
+}
+
+func (e *eventStream) writeEvent(eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		zap.L().Error("Json Marshal error.", zap.String("err", err.Error()))
+		return err
+	}
+	return e.writeRaw(fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, data))
+}
+
+func (e *eventStream) writeStateEvent(applicationState ApplicationState) error {
+	err := e.writeEvent(
+		eventTypeState, StateEventJSON{
+			ApplicationTask: applicationState.task.String(),
+			BIsPaused:       applicationState.bIsPaused,
+			Timestamp:       time.Now().Unix(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if applicationState.task == enum.Errored {
+		return e.writeEvent(
+			eventTypeError, ErrorEventJSON{
+				ApplicationTask: applicationState.task.String(),
+				ErrorDetails:    applicationState.errorDetails,
+				Timestamp:       time.Now().Unix(),
+			},
+		)
+	}
+	return nil
+}
+
+func (e *eventStream) writeProgressEvent(applicationState ApplicationState) error {
+	now := time.Now()
+
+	// Throughput is only meaningful within the same task
+	throughputPerSecond := 0.0
+	if applicationState.task == e.lastProgressTask && applicationState.processedWorkItems >= e.lastProcessedItems {
+		elapsedTime := now.Sub(e.lastProgressEventTime).Seconds()
+		if elapsedTime > 0 {
+			throughputPerSecond = float64(applicationState.processedWorkItems-e.lastProcessedItems) / elapsedTime
+		}
+	}
+	e.lastProgressTask = applicationState.task
+	e.lastProcessedItems = applicationState.processedWorkItems
+	e.lastProgressEventTime = now
+
+	return e.writeEvent(
+		eventTypeProgress, ProgressEventJSON{
+			ApplicationTask:                applicationState.task.String(),
+			TotalWorkItems:                 applicationState.totalWorkItems,
+			ProcessedWorkItems:             applicationState.processedWorkItems,
+			RemainingWorkItems:             applicationState.remainingWorkItems,
+			ThroughputPerSecond:            throughputPerSecond,
+			EstimateRemainingTimeInSeconds: applicationState.estimateRemainingTimeInSeconds,
+			Timestamp:                      now.Unix(),
+		},
+	)
+}
+
+func isPipelineTaskRunning(task enum.ApplicationTask) bool {
+	return task == enum.RunningPreCrawlFilters || task == enum.RunningPostCrawlFilters || task == enum.RunningIndexer
+}
+
+// Streams application state transitions, pipeline progress & errors as Server-Sent Events.
+// Design Note: Streams are still bound by the server write timeout. Clients are expected to reconnect, as hinted by
+// the retry field.
+func (a *Application) eventsGetHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			a.helperSendJSONError(&w, newAPIError(http.StatusInternalServerError, "streaming unsupported"))
+			return
+		}
+
+		// Design Note: We need to subscribe before reading the current state, otherwise we could miss a transition.
+		subscription := a.applicationStateManager.Subscribe()
+		defer a.applicationStateManager.Unsubscribe(subscription)
+
+		// Headers
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no") // Disable reverse proxy buffering
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusOK)
+
+		stream := &eventStream{w: w, flusher: flusher}
+		err := stream.writeRaw(fmt.Sprintf("retry: %d\n\n", eventRetryInMilliseconds))
+		if err != nil {
+			return
+		}
+
+		applicationState := a.applicationStateManager.GetApplicationState()
+		err = stream.writeStateEvent(applicationState)
+		if err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(eventProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case applicationState, ok = <-subscription:
+				if !ok {
+					return
+				}
+				err = stream.writeStateEvent(applicationState)
+				if err != nil {
+					return
+				}
+
+				// Streams would otherwise block the graceful shutdown of the server
+				if applicationState.task == enum.Shutdown {
+					return
+				}
+			case <-ticker.C:
+				applicationState = a.applicationStateManager.GetApplicationState()
+				if isPipelineTaskRunning(applicationState.task) && !applicationState.bIsPaused {
+					err = stream.writeProgressEvent(applicationState)
+				} else if time.Since(stream.lastWriteTime) >= eventKeepAliveInterval {
+					err = stream.writeRaw(": keep-alive\n\n")
+				}
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	eventType string
+	data      string
+}
+
+// Reads the next event from the stream, skipping comments & fields other than event type & data.
+func readTestEvent(t *testing.T, reader *bufio.Reader) testEvent {
+	event := testEvent{}
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		if line == "" {
+			if event.eventType != "" {
+				return event
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "event":
+			event.eventType = value
+		case "data":
+			event.data = value
+		}
+	}
+}
+
+// Progress events are sent periodically, thus they can be interleaved with other events.
+func readTestEventOfType(t *testing.T, reader *bufio.Reader, eventType string) testEvent {
+	event := readTestEvent(t, reader)
+	for event.eventType != eventType && event.eventType == eventTypeProgress {
+		event = readTestEvent(t, reader)
+	}
+	require.Equal(t, eventType, event.eventType)
+	return event
+}
+
+func TestEventsGetHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	server := httptest.NewServer(a.getRouter())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + apiPathEvents)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+
+	retryLine, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "retry: 5000\n", retryLine)
+
+	// Current state is sent first
+	event := readTestEvent(t, reader)
+	require.Equal(t, eventTypeState, event.eventType)
+	stateEvent := StateEventJSON{}
+	require.NoError(t, json.Unmarshal([]byte(event.data), &stateEvent))
+	assert.Equal(t, "ReadyToSearch", stateEvent.ApplicationTask)
+
+	// Transitions are streamed
+	a.applicationStateManager.Pause()
+	event = readTestEvent(t, reader)
+	require.Equal(t, eventTypeState, event.eventType)
+	require.NoError(t, json.Unmarshal([]byte(event.data), &stateEvent))
+	assert.True(t, stateEvent.BIsPaused)
+
+	a.applicationStateManager.Resume()
+	require.True(t, a.applicationStateManager.OnRestart())
+	a.applicationStateManager.OnPreCrawlFiltersStarted()
+	a.applicationStateManager.SetTotalWorkItems(10)
+	a.applicationStateManager.SetProcessedWorkItems(4)
+
+	expectedTasks := []string{"ReadyToSearch", "Initializing", "RunningPreCrawlFilters"}
+	for _, expectedTask := range expectedTasks {
+		event = readTestEventOfType(t, reader, eventTypeState)
+		require.NoError(t, json.Unmarshal([]byte(event.data), &stateEvent))
+		assert.Equal(t, expectedTask, stateEvent.ApplicationTask)
+	}
+
+	// Progress is streamed while pipeline tasks are running
+	progressEvent := ProgressEventJSON{}
+	for progressEvent.ProcessedWorkItems != 4 {
+		event = readTestEventOfType(t, reader, eventTypeProgress)
+		require.NoError(t, json.Unmarshal([]byte(event.data), &progressEvent))
+	}
+	assert.Equal(t, 10, progressEvent.TotalWorkItems)
+	assert.Equal(t, 4, progressEvent.ProcessedWorkItems)
+	assert.Equal(t, 6, progressEvent.RemainingWorkItems)
+
+	// Errors are reported along with the state
+	a.applicationStateManager.OnErrored("disk is full")
+	readTestEventOfType(t, reader, eventTypeState)
+	event = readTestEvent(t, reader)
+	require.Equal(t, eventTypeError, event.eventType)
+	errorEvent := ErrorEventJSON{}
+	require.NoError(t, json.Unmarshal([]byte(event.data), &errorEvent))
+	assert.Equal(t, "disk is full", errorEvent.ErrorDetails)
+
+	// Stream should be closed on shutdown
+	a.applicationStateManager.OnShutdown()
+	event = readTestEventOfType(t, reader, eventTypeState)
+	require.NoError(t, json.Unmarshal([]byte(event.data), &stateEvent))
+	assert.Equal(t, "Shutdown", stateEvent.ApplicationTask)
+	_, err = reader.ReadString('\n')
+	assert.Error(t, err)
+}
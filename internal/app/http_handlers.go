@@ -2,77 +2,134 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
-	"os"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
-	"github.com/anthony-ozdemir/zfse/internal/enum"
+	embedding "github.com/anthony-ozdemir/zfse"
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/openapi"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+	"github.com/anthony-ozdemir/zfse/internal/rate_limiter"
 )
 
-func (a *Application) helperSendJSONError(w *http.ResponseWriter, jsonErrReply interface{}) {
-	jsonRes, err := json.Marshal(jsonErrReply)
+// Sends the API error as a JSON error reply with its HTTP status code.
+func (a *Application) helperSendJSONError(w *http.ResponseWriter, apiErr *APIError) {
+	jsonErrReply := ErrorRepJSON{
+		Success: false,
+		Error:   apiErr.Message,
+	}
+	a.helperSendJSON(w, apiErr.StatusCode, jsonErrReply)
+}
+
+func (a *Application) helperSendJSONSuccess(w *http.ResponseWriter, jsonReply interface{}) {
+	a.helperSendJSON(w, http.StatusOK, jsonReply)
+}
+
+// Design Note: Errors while replying only affect a single request, i.e. a client that disconnects mid-write. Thus, we
+// log them instead of shutting down the application.
+func (a *Application) helperSendJSON(w *http.ResponseWriter, statusCode int, jsonReply interface{}) {
+	jsonRes, err := json.Marshal(jsonReply)
 	if err != nil {
-		zap.L().Fatal(
+		zap.L().Error(
 			"Json Marshal error.",
 			zap.String("err", err.Error()),
 		)
+		http.Error(*w, errInternalServerError.Message, errInternalServerError.StatusCode)
+		return
 	}
 
 	(*w).Header().Set("Content-Type", "application/json")
-	(*w).WriteHeader(http.StatusBadRequest)
+	(*w).WriteHeader(statusCode)
 	_, err = (*w).Write(jsonRes)
 	if err != nil {
-		zap.L().Fatal(
-			"Unable to write JSON Error message.",
+		zap.L().Warn(
+			"Unable to write JSON message.",
 			zap.String("err", err.Error()),
 		)
 	}
+}
 
-	return
+// Records the status code & size of the response for logging.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode   int
+	responseSize int64
+	bWroteHeader bool
 }
 
-func (a *Application) helperSendJSONSuccess(w *http.ResponseWriter, jsonErrReply interface{}) {
-	jsonRes, err := json.Marshal(jsonErrReply)
-	if err != nil {
-		zap.L().Fatal(
-			"Json Marshal error.",
-			zap.String("err", err.Error()),
-		)
-	}
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+}
 
-	(*w).Header().Set("Content-Type", "application/json")
-	(*w).WriteHeader(http.StatusOK)
-	_, err = (*w).Write(jsonRes)
-	if err != nil {
-		zap.L().Fatal(
-			"Unable to write JSON Success message.",
-			zap.String("err", err.Error()),
-		)
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.bWroteHeader {
+		r.statusCode = statusCode
+		r.bWroteHeader = true
 	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
 
-	return
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.bWroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.responseSize += int64(n)
+	return n, err
+}
+
+// Flush is needed for streaming responses.
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.bWroteHeader = true
+		flusher.Flush()
+	}
 }
 
 // Helper Handlers
-// GoLang HTTP Handlers normally do not crash the entire program when there is a panic inside the HTTP Handler's
-// goroutine. However, this is not wanted in our case. We want to gracefully handle the panics inside the goroutine
-// instead. Thus, we will let panicking HTTP Handlers crash the application.
-func (a *Application) getHTTPCrashOnPanicHandler(next http.HandlerFunc) http.Handler {
+// GoLang HTTP Handlers recover panics of the handler goroutine by closing the connection abruptly. Instead, we will
+// log the panic and reply with an internal server error, so that a single failing request neither crashes the
+// application nor leaves the client without a reply.
+func (a *Application) getHTTPRecoverHandler(next http.HandlerFunc) http.Handler {
 	middle := func(w http.ResponseWriter, r *http.Request) {
+		recorder, ok := w.(*responseRecorder)
+		if !ok {
+			recorder = newResponseRecorder(w)
+		}
+
 		defer func() {
 			err := recover()
 			if err != nil {
-				buf := make([]byte, 1<<20)
-				n := runtime.Stack(buf, true)
-				fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", err, buf[:n])
-				os.Exit(1)
+				if err == http.ErrAbortHandler {
+					// Handler intentionally aborted the response
+					panic(err)
+				}
+
+				buf := make([]byte, 1<<16)
+				n := runtime.Stack(buf, false)
+				zap.L().Error(
+					"HTTP handler panic.",
+					zap.String("uri", r.URL.String()),
+					zap.Any("err", err),
+					zap.String("stack", string(buf[:n])),
+				)
+
+				// We can only reply if the handler hasn't started replying yet
+				if !recorder.bWroteHeader {
+					a.helperSendJSONError(&w, errInternalServerError)
+				}
 			}
 		}()
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(recorder, r)
 	}
 	return http.HandlerFunc(middle)
 }
@@ -114,20 +171,13 @@ func (a *Application) getLogHTTPRequestHandler(next http.HandlerFunc) http.Handl
 			ri.userAgent = "N/A"
 		}
 
-		// TODO [HP]: Implement, ensure to sanitize properly
-		// ri.ipaddr = a.RequestGetRemoteAddress(r)
+		ri.ipaddr = a.getRequestRemoteAddress(r)
 
-		// There's no rate-limit error, serve the next handler.
-		next.ServeHTTP(w, r)
+		recorder := newResponseRecorder(w)
+		next.ServeHTTP(recorder, r)
 
-		// There is a chance that there is no response
-		if r.Response != nil {
-			ri.responseCode = r.Response.StatusCode
-			ri.responseSize = r.Response.ContentLength
-		} else {
-			ri.responseCode = 0
-			ri.responseSize = 0
-		}
+		ri.responseCode = recorder.statusCode
+		ri.responseSize = recorder.responseSize
 		ri.duration = time.Since(start)
 
 		zap.L().Info(
@@ -147,16 +197,215 @@ func (a *Application) getLogHTTPRequestHandler(next http.HandlerFunc) http.Handl
 
 }
 
+type ErrorRepJSON struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Sets up the per-IP rate limiter & trusted proxies from the config.
+func (a *Application) initializeHTTPLimits() {
+	if a.config.GeneralOptions.RateLimitPerSecond > 0 {
+		a.rateLimiter = rate_limiter.New(
+			a.config.GeneralOptions.RateLimitPerSecond, a.config.GeneralOptions.RateLimitBurst,
+		)
+	}
+
+	a.trustedProxies = make([]*net.IPNet, 0)
+	for _, trustedProxy := range a.config.GeneralOptions.TrustedProxies {
+		// Single IP addresses are also accepted
+		if !strings.Contains(trustedProxy, "/") {
+			if strings.Contains(trustedProxy, ":") {
+				trustedProxy += "/128"
+			} else {
+				trustedProxy += "/32"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(trustedProxy)
+		if err != nil {
+			zap.L().Fatal(
+				"Invalid trusted proxy.", zap.String("trusted_proxy", trustedProxy),
+				zap.String("err", err.Error()),
+			)
+		}
+		a.trustedProxies = append(a.trustedProxies, ipNet)
+	}
+}
+
+func (a *Application) isTrustedProxy(ip net.IP) bool {
+	for _, trustedProxy := range a.trustedProxies {
+		if trustedProxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the client IP address of the request. X-Forwarded-For header is only honored when the request is sent by a
+// trusted proxy, otherwise any client could spoof its address.
+func (a *Application) getRequestRemoteAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remoteIP := net.ParseIP(host)
+	if remoteIP == nil {
+		return host
+	}
+	if !a.isTrustedProxy(remoteIP) {
+		return remoteIP.String()
+	}
+
+	// Design Note: Each proxy appends the address it received the request from. Thus, the client address is the
+	// right-most address which isn't a trusted proxy.
+	forwardedAddresses := make([]string, 0)
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwardedAddresses = append(forwardedAddresses, strings.Split(header, ",")...)
+	}
+
+	clientIP := remoteIP
+	for i := len(forwardedAddresses) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwardedAddresses[i]))
+		if forwardedIP == nil {
+			// Malformed entries can't be trusted any further
+			break
+		}
+		clientIP = forwardedIP
+		if !a.isTrustedProxy(forwardedIP) {
+			break
+		}
+	}
+
+	return clientIP.String()
+}
+
+// Rejects requests exceeding the per-IP rate limit, IPv6 clients are limited per /64 prefix.
+func (a *Application) getRateLimitHandler(next http.HandlerFunc) http.Handler {
+	middle := func(w http.ResponseWriter, r *http.Request) {
+		if a.rateLimiter != nil {
+			clientKey := rate_limiter.GetClientKey(a.getRequestRemoteAddress(r))
+			if !a.rateLimiter.Allow(clientKey) {
+				retryAfterInSeconds := int(math.Ceil(a.rateLimiter.RetryAfter(clientKey).Seconds()))
+				if retryAfterInSeconds < 1 {
+					retryAfterInSeconds = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterInSeconds))
+
+				a.helperSendJSONError(&w, errRateLimitExceeded)
+				return
+			}
+		}
+
+		// There's no rate-limit error, serve the next handler.
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(middle)
+}
+
+// Rejects requests exceeding the query length limit & caps the request body size.
+func (a *Application) getRequestSizeLimitHandler(next http.HandlerFunc) http.Handler {
+	middle := func(w http.ResponseWriter, r *http.Request) {
+		maxQueryLength := a.config.GeneralOptions.MaxQueryLengthInBytes
+		if maxQueryLength > 0 && len(r.URL.RawQuery) > maxQueryLength {
+			a.helperSendJSONError(&w, newAPIError(http.StatusRequestURITooLong, "query too long"))
+			return
+		}
+
+		maxRequestBody := a.config.GeneralOptions.MaxRequestBodyInBytes
+		if maxRequestBody > 0 {
+			if r.ContentLength > maxRequestBody {
+				a.helperSendJSONError(&w, newAPIError(http.StatusRequestEntityTooLarge, "request body too large"))
+				return
+			}
+
+			// Design Note: Content-Length can be omitted, thus we need to cap the reads as well.
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(middle)
+}
+
+func (a *Application) initializeAPISpec() {
+	apiSpec, err := openapi.Load(embedding.GetOpenAPISpec())
+	if err != nil {
+		zap.L().Fatal("Unable to load OpenAPI document.", zap.String("err", err.Error()))
+	}
+	a.apiSpec = apiSpec
+}
+
+// Rejects requests which don't conform to the OpenAPI document. Paths which aren't documented, i.e. Web UI, are not
+// validated.
+func (a *Application) getRequestValidationHandler(next http.HandlerFunc) http.Handler {
+	middle := func(w http.ResponseWriter, r *http.Request) {
+		// CORS pre-flight requests are answered by the handlers
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err := a.apiSpec.ValidateRequest(r)
+		if err != nil {
+			var validationErr *openapi.ValidationError
+			if errors.Is(err, openapi.ErrMethodNotAllowed) {
+				a.helperSendJSONError(&w, errMethodNotAllowed)
+			} else if errors.As(err, &validationErr) {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid request: "+validationErr.Error()))
+			} else {
+				// i.e. request body exceeds the size limit
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "unable to read request"))
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(middle)
+}
+
 func (a *Application) getCommonWrapperHandler(next http.HandlerFunc) http.Handler {
-	logHandler := a.getLogHTTPRequestHandler(next)
-	crashOnPanicHandler := a.getHTTPCrashOnPanicHandler(logHandler.ServeHTTP)
+	requestValidationHandler := a.getRequestValidationHandler(next)
+	requestSizeLimitHandler := a.getRequestSizeLimitHandler(requestValidationHandler.ServeHTTP)
+	rateLimitHandler := a.getRateLimitHandler(requestSizeLimitHandler.ServeHTTP)
+	recoverHandler := a.getHTTPRecoverHandler(rateLimitHandler.ServeHTTP)
+	logHandler := a.getLogHTTPRequestHandler(recoverHandler.ServeHTTP)
 
-	return crashOnPanicHandler
+	return logHandler
 }
 
 // API Methods
-/*
-// TODO [HP]: Implement
+// Design Note: Unknown API paths would otherwise fall through to the Web UI, thus we reply with a JSON error instead.
+func (a *Application) apiNotFoundHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.helperSendJSONError(&w, errNotFound)
+	}
+}
+
+type ZoneStatusJSON struct {
+	ZoneName                 string                            `json:"zone_name"`
+	PreCrawlFilterTaskState  database.PreCrawlFilterTaskState  `json:"pre_crawl_filter_task_state"`
+	PostCrawlFilterTaskState database.PostCrawlFilterTaskState `json:"post_crawl_filter_task_state"`
+	IndexerTaskState         database.IndexerTaskState         `json:"indexer_task_state"`
+}
+
+type StatusRepJSON struct {
+	Success                        bool             `json:"success"`
+	Error                          string           `json:"error,omitempty"`
+	ApplicationTask                string           `json:"application_task"`
+	TotalWorkItems                 int              `json:"total_work_items"`
+	ProcessedWorkItems             int              `json:"processed_work_items"`
+	RemainingWorkItems             int              `json:"remaining_work_items"`
+	EstimateRemainingTimeInSeconds int              `json:"estimate_remaining_time_in_seconds"`
+	ErrorDetails                   string           `json:"error_details,omitempty"`
+	BIsPaused                      bool             `json:"b_is_paused"`
+	Zones                          []ZoneStatusJSON `json:"zones"`
+}
+
 func (a *Application) statusGetHandler() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		zap.L().Info("Received status request.")
@@ -179,30 +428,83 @@ func (a *Application) statusGetHandler() func(w http.ResponseWriter, r *http.Req
 			w.WriteHeader(http.StatusOK)
 			return
 		} else if r.Method == http.MethodGet {
-			w.WriteHeader(http.StatusOK)
+			currentApplicationState := a.applicationStateManager.GetApplicationState()
+
+			// Let's sort zone names, so that the output is stable between requests
+			zoneNames := make([]string, 0, len(a.zoneFileRegistry))
+			for zoneName := range a.zoneFileRegistry {
+				zoneNames = append(zoneNames, zoneName)
+			}
+			sort.Strings(zoneNames)
+
+			zones := make([]ZoneStatusJSON, 0, len(zoneNames))
+			for _, zoneName := range zoneNames {
+				zones = append(
+					zones, ZoneStatusJSON{
+						ZoneName:                 zoneName,
+						PreCrawlFilterTaskState:  a.db.GetPreCrawlFilterTaskState(zoneName),
+						PostCrawlFilterTaskState: a.db.GetPostCrawlFilterTaskState(zoneName),
+						IndexerTaskState:         a.db.GetIndexerTaskState(zoneName),
+					},
+				)
+			}
+
+			jsonRep := StatusRepJSON{
+				Success:                        true,
+				ApplicationTask:                currentApplicationState.task.String(),
+				TotalWorkItems:                 currentApplicationState.totalWorkItems,
+				ProcessedWorkItems:             currentApplicationState.processedWorkItems,
+				RemainingWorkItems:             currentApplicationState.remainingWorkItems,
+				EstimateRemainingTimeInSeconds: currentApplicationState.estimateRemainingTimeInSeconds,
+				ErrorDetails:                   currentApplicationState.errorDetails,
+				BIsPaused:                      currentApplicationState.bIsPaused,
+				Zones:                          zones,
+			}
+			a.helperSendJSONSuccess(&w, jsonRep)
 			return
 		} else {
-			w.WriteHeader(http.StatusBadRequest)
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+	}
+}
+
+// Serves the OpenAPI document of the HTTP API.
+func (a *Application) openAPIGetHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Headers", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
 			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write(embedding.GetOpenAPISpec())
+		if err != nil {
+			zap.L().Warn("Unable to write OpenAPI document.", zap.String("err", err.Error()))
+		}
 	}
 }
-*/
 
 type RankingQueryReqJSON struct {
 	UserQuery *string `json:"user_query"` // pointer so we can test for field absence
 }
 
 type RankingQueryRepJSON struct {
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
+	Success   bool               `json:"success"`
+	Error     string             `json:"error,omitempty"`
+	Query     string             `json:"query,omitempty"`
+	TotalHits uint64             `json:"total_hits"`
+	Results   []SearchResultJSON `json:"results"`
 }
 
 func (a *Application) rankerQueryHandler() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// TODO [HP]: Implement
-		zap.L().Info("Received status request.")
+		zap.L().Info("Received ranking query request.")
 
 		// Headers
 		w.Header().Set("Vary", "*")                 // Hint uncacheable
@@ -215,7 +517,7 @@ func (a *Application) rankerQueryHandler() func(w http.ResponseWriter, r *http.R
 		// Design Note: Can be set to '*' only when Access-Control-Allow-Credentials is not set. Otherwise requires
 		// manual specification.
 		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 
 		if r.Method == http.MethodOptions {
@@ -228,50 +530,319 @@ func (a *Application) rankerQueryHandler() func(w http.ResponseWriter, r *http.R
 
 			errJsonDecode := decoder.Decode(&jsonReq)
 			if errJsonDecode != nil {
-				jsonErr := RankingQueryRepJSON{
-					Success: false,
-					Error:   "json decode error",
-				}
-				a.helperSendJSONError(&w, jsonErr)
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "json decode error"))
 				return
 			}
 
 			// Check existence of mandatory fields on Json request
 			if jsonReq.UserQuery == nil {
-				jsonErr := RankingQueryRepJSON{
-					Success: false,
-					Error:   "json field missing",
-				}
-				a.helperSendJSONError(&w, jsonErr)
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "json field missing"))
 				return
 			}
 
 			// Let's check if server is ready to Rank
-			// TODO [HP]: We should lock the state mutex here, so that large amount of
-			// rank requests won't create a data race condition
-			currentApplicationState := a.applicationStateManager.GetApplicationState()
-			if currentApplicationState.task != enum.ReadyToSearch {
-				jsonErr := RankingQueryRepJSON{
-					Success: false,
-					Error:   "index not yet ready",
-				}
-				a.helperSendJSONError(&w, jsonErr)
+			// Design Note: Ranking doesn't alter the application state. Thus, there is no need
+			// to hold the state mutex until ranking is finished.
+			if !a.applicationStateManager.IsSearchable() {
+				a.helperSendJSONError(&w, errIndexNotReady)
+				return
+			}
+
+			query, err := query_parser.Parse(*jsonReq.UserQuery)
+			if err != nil {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid query: "+err.Error()))
 				return
 			}
 
 			// Let's start ranking
-			a.Rank(*jsonReq.UserQuery)
+			// Design Note: Queries are served from the Indexer & Rankers, they never write to the cache folder.
+			searchOutput, err := a.Search(query, 0, int(a.config.GeneralOptions.IndexerOutputLimit))
+			if err != nil {
+				zap.L().Error(
+					"Unable to search.", zap.String("user_query", *jsonReq.UserQuery), zap.String("err", err.Error()),
+				)
+				a.helperSendJSONError(&w, errSearchUnavailable)
+				return
+			}
 
-			// Everything seems fine. Let's reply back with success
+			results := make([]SearchResultJSON, 0, len(searchOutput.Results))
+			for i, domainProperties := range searchOutput.Results {
+				result := a.newSearchResultJSON(domainProperties)
+				result.ClickToken, err = a.getClickToken(*jsonReq.UserQuery, result.ID, i+1)
+				if err != nil {
+					zap.L().Error("Unable to create click token.", zap.String("err", err.Error()))
+					a.helperSendJSONError(&w, errInternalServerError)
+					return
+				}
+				results = append(results, result)
+			}
+
+			// Everything seems fine. Let's reply back with the ranked results
 			jsonRep := RankingQueryRepJSON{
-				Success: true,
+				Success:   true,
+				Query:     *jsonReq.UserQuery,
+				TotalHits: searchOutput.TotalHits,
+				Results:   results,
+			}
+			a.helperSendJSONSuccess(&w, jsonRep)
+			return
+		} else {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+	}
+}
+
+type SearchResultJSON struct {
+	ID               string             `json:"id"`
+	DomainName       string             `json:"domain_name"`
+	URL              string             `json:"url"`
+	Snippet          string             `json:"snippet"`
+	FinalScore       float64            `json:"final_score"`
+	IndexerScore     float64            `json:"indexer_score"`
+	StringProperties map[string]string  `json:"string_properties"`
+	IntProperties    map[string]int64   `json:"int_properties"`
+	FloatProperties  map[string]float64 `json:"float_properties"`
+	BoolProperties   map[string]bool    `json:"bool_properties"`
+	// Signs the position of the result for the click endpoint, see getClickToken
+	ClickToken string `json:"click_token"`
+}
+
+type SearchRepJSON struct {
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Query     string `json:"query,omitempty"`
+	From      int    `json:"from"`
+	Size      int    `json:"size"`
+	TotalHits uint64 `json:"total_hits"`
+	// Design Note: Only the hits up to indexer_output_limit can be paginated.
+	AvailableHits uint64                  `json:"available_hits"`
+	Results       []SearchResultJSON      `json:"results"`
+	Facets        map[string][]FacetCount `json:"facets"`
+}
+
+const (
+	defaultSearchPageSize = 10
+	maxSearchPageSize     = 100
+)
+
+// Parses an optional non-negative integer query parameter.
+func helperParseIntQueryParam(r *http.Request, key string, defaultValue int) (int, error) {
+	valueString := r.URL.Query().Get(key)
+	if valueString == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(valueString)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid %v parameter", key)
+	}
+
+	return value, nil
+}
+
+// Applies the optional "facet" query parameters as filters, i.e. "facet=ns:cloudflare.com".
+func helperApplyFacetQueryParams(r *http.Request, query *query_parser.Query) error {
+	for _, facet := range r.URL.Query()["facet"] {
+		field, value, found := strings.Cut(facet, ":")
+		if !found || strings.TrimSpace(field) == "" || strings.TrimSpace(value) == "" {
+			return fmt.Errorf("invalid facet parameter")
+		}
+		query.AddFilter(field, value)
+	}
+	return nil
+}
+
+func (a *Application) newSearchResultJSON(domainProperties common.DomainProperties) SearchResultJSON {
+	return SearchResultJSON{
+		ID:               domainProperties.StringProperties["index_id"],
+		DomainName:       domainProperties.DomainName,
+		URL:              a.getDomainURL(domainProperties.DomainName),
+		Snippet:          domainProperties.StringProperties["snippet"],
+		FinalScore:       domainProperties.FloatProperties["ranker_score"],
+		IndexerScore:     domainProperties.FloatProperties["indexer_score"],
+		StringProperties: domainProperties.StringProperties,
+		IntProperties:    domainProperties.IntProperties,
+		FloatProperties:  domainProperties.FloatProperties,
+		BoolProperties:   domainProperties.BoolProperties,
+	}
+}
+
+func (a *Application) searchGetHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zap.L().Info("Received search request.")
+
+		// Headers
+		w.Header().Set("Vary", "*")                 // Hint uncacheable
+		w.Header().Set("Cache-Control", "no-store") // No cache of any kind (private or shared)
+		w.Header().Set("Access-Control-Allow-Headers", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		} else if r.Method == http.MethodGet {
+			// Check existence of mandatory query parameters
+			userQuery := strings.TrimSpace(r.URL.Query().Get("q"))
+			if userQuery == "" {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "query parameter missing"))
+				return
+			}
+
+			// Pagination parameters
+			from, err := helperParseIntQueryParam(r, "from", 0)
+			if err != nil {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, err.Error()))
+				return
+			}
+
+			size, err := helperParseIntQueryParam(r, "size", defaultSearchPageSize)
+			if err != nil || size == 0 || size > maxSearchPageSize {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid size parameter"))
+				return
+			}
+
+			format, err := helperParseFormatQueryParam(r, replyFormatRSS, replyFormatAtom)
+			if err != nil {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, err.Error()))
+				return
+			}
+
+			// Let's check if server is ready to Rank
+			if !a.applicationStateManager.IsSearchable() {
+				a.helperSendJSONError(&w, errIndexNotReady)
+				return
+			}
+
+			query, err := query_parser.Parse(userQuery)
+			if err != nil {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid query: "+err.Error()))
+				return
+			}
+
+			err = helperApplyFacetQueryParams(r, query)
+			if err != nil {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, err.Error()))
+				return
+			}
+
+			startTime := time.Now()
+			searchOutput, err := a.Search(query, from, size)
+			if err != nil {
+				zap.L().Error("Unable to search.", zap.String("user_query", userQuery), zap.String("err", err.Error()))
+				a.helperSendJSONError(&w, errSearchUnavailable)
+				return
+			}
+
+			// Design Note: Only the first page is recorded, otherwise paginating would inflate the query counts.
+			if from == 0 {
+				a.recordQuery(r, query, startTime, searchOutput.TotalHits)
+			}
+
+			results := make([]SearchResultJSON, 0, len(searchOutput.Results))
+			for i, domainProperties := range searchOutput.Results {
+				result := a.newSearchResultJSON(domainProperties)
+				result.ClickToken, err = a.getClickToken(userQuery, result.ID, from+i+1)
+				if err != nil {
+					zap.L().Error("Unable to create click token.", zap.String("err", err.Error()))
+					a.helperSendJSONError(&w, errInternalServerError)
+					return
+				}
+				results = append(results, result)
+			}
+
+			if format != replyFormatJSON {
+				a.helperSendSearchFeed(&w, r, format, userQuery, results)
+				return
+			}
+
+			jsonRep := SearchRepJSON{
+				Success:       true,
+				Query:         userQuery,
+				From:          from,
+				Size:          size,
+				TotalHits:     searchOutput.TotalHits,
+				AvailableHits: uint64(searchOutput.AvailableHits),
+				Results:       results,
+				Facets:        searchOutput.Facets,
 			}
 			a.helperSendJSONSuccess(&w, jsonRep)
 			return
 		} else {
-			w.WriteHeader(http.StatusBadRequest)
+			a.helperSendJSONError(&w, errMethodNotAllowed)
 			return
 		}
+	}
+}
+
+type SuggestRepJSON struct {
+	Success     bool     `json:"success"`
+	Error       string   `json:"error,omitempty"`
+	Prefix      string   `json:"prefix,omitempty"`
+	Suggestions []string `json:"suggestions"`
+}
+
+const (
+	defaultSuggestionQty = 8
+	maxSuggestionQty     = 32
+)
+
+func (a *Application) suggestGetHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Headers
+		w.Header().Set("Vary", "*")                 // Hint uncacheable
+		w.Header().Set("Cache-Control", "no-store") // No cache of any kind (private or shared)
+		w.Header().Set("Access-Control-Allow-Headers", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
 
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		} else if r.Method == http.MethodGet {
+			// Check existence of mandatory query parameters
+			prefix := strings.TrimLeft(r.URL.Query().Get("prefix"), " ")
+			if prefix == "" {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "prefix parameter missing"))
+				return
+			}
+
+			size, err := helperParseIntQueryParam(r, "size", defaultSuggestionQty)
+			if err != nil || size == 0 || size > maxSuggestionQty {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "invalid size parameter"))
+				return
+			}
+
+			format, err := helperParseFormatQueryParam(r, replyFormatOpenSearch)
+			if err != nil {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, err.Error()))
+				return
+			}
+
+			// Let's check if server is ready to suggest
+			if !a.applicationStateManager.IsSearchable() {
+				a.helperSendJSONError(&w, errIndexNotReady)
+				return
+			}
+
+			suggestions := a.Suggest(prefix, size)
+			if format == replyFormatOpenSearch {
+				a.helperSendOpenSearchSuggestions(&w, prefix, suggestions)
+				return
+			}
+
+			jsonRep := SuggestRepJSON{
+				Success:     true,
+				Prefix:      prefix,
+				Suggestions: suggestions,
+			}
+			a.helperSendJSONSuccess(&w, jsonRep)
+			return
+		} else {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
 	}
 }
@@ -0,0 +1,411 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/openapi"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+	"github.com/anthony-ozdemir/zfse/internal/task_handlers/indexers"
+)
+
+func TestStatusGetHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	a.db.SaveIndexerTaskState("test", database.IndexerTaskState{BIsFinished: true, LineIndex: 2})
+
+	recorder := httptest.NewRecorder()
+	a.getRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathStatusGet, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	jsonRep := StatusRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.True(t, jsonRep.Success)
+	assert.Equal(t, "ReadyToSearch", jsonRep.ApplicationTask)
+	require.Len(t, jsonRep.Zones, 1)
+	assert.Equal(t, "test", jsonRep.Zones[0].ZoneName)
+	assert.False(t, jsonRep.Zones[0].PreCrawlFilterTaskState.BIsFinished)
+	assert.True(t, jsonRep.Zones[0].IndexerTaskState.BIsFinished)
+	assert.Equal(t, 2, jsonRep.Zones[0].IndexerTaskState.LineIndex)
+}
+
+func TestSearchGetHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+
+	recorder := httptest.NewRecorder()
+	a.getRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	jsonRep := SearchRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.True(t, jsonRep.Success)
+	require.Len(t, jsonRep.Results, 2)
+	assert.Equal(t, "https://"+jsonRep.Results[0].DomainName, jsonRep.Results[0].URL)
+	assert.NotEmpty(t, jsonRep.Results[0].ID)
+	// Random indexer is not a highlighter, generic snippets should be used instead
+	assert.Equal(t, "Description of "+jsonRep.Results[0].DomainName, jsonRep.Results[0].Snippet)
+
+	// Click tokens sign the 1-based positions of the results
+	clickToken, err := a.getClickToken("example", jsonRep.Results[1].ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, clickToken, jsonRep.Results[1].ClickToken)
+
+	assert.Equal(t, []FacetCount{{Value: "test", Count: 2}}, jsonRep.Facets["zone"])
+
+	// Missing query
+	recorder = httptest.NewRecorder()
+	a.getRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch, nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Facet selection
+	recorder = httptest.NewRecorder()
+	a.getRouter().ServeHTTP(
+		recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example&facet=zone:other", nil),
+	)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	jsonRep = SearchRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.Len(t, jsonRep.Results, 0)
+
+	// Invalid facet selection
+	recorder = httptest.NewRecorder()
+	a.getRouter().ServeHTTP(
+		recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example&facet=zone", nil),
+	)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestSearchGetHandlerRankedResults(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com", "c.com"})
+	router := a.getRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	// Results are returned in the reply, ordered by their final scores
+	jsonRep := SearchRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.True(t, jsonRep.Success)
+	assert.Equal(t, "example", jsonRep.Query)
+	assert.Equal(t, 0, jsonRep.From)
+	assert.Equal(t, defaultSearchPageSize, jsonRep.Size)
+	assert.Equal(t, uint64(3), jsonRep.TotalHits)
+	require.Len(t, jsonRep.Results, 3)
+
+	domainNames := make([]string, 0, len(jsonRep.Results))
+	for i, result := range jsonRep.Results {
+		domainNames = append(domainNames, result.DomainName)
+		assert.Equal(t, "https://"+result.DomainName, result.URL)
+		assert.Equal(t, result.FinalScore, result.FloatProperties["ranker_score"])
+		assert.Equal(t, result.IndexerScore, result.FloatProperties["indexer_score"])
+		if i > 0 {
+			assert.GreaterOrEqual(t, jsonRep.Results[i-1].FinalScore, result.FinalScore)
+		}
+	}
+	assert.ElementsMatch(t, []string{"a.com", "b.com", "c.com"}, domainNames)
+
+	// Scores are always present, even if they are zero
+	rawRep := struct {
+		Results []map[string]interface{} `json:"results"`
+	}{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rawRep))
+	for _, result := range rawRep.Results {
+		assert.Contains(t, result, "final_score")
+		assert.Contains(t, result, "indexer_score")
+		assert.Contains(t, result, "url")
+	}
+
+	// Pagination
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example&from=2&size=2", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	jsonRep = SearchRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.Equal(t, 2, jsonRep.From)
+	assert.Equal(t, 2, jsonRep.Size)
+	assert.Equal(t, uint64(3), jsonRep.TotalHits)
+	assert.Len(t, jsonRep.Results, 1)
+}
+
+func TestRankerQueryHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	router := a.getRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(
+		recorder,
+		httptest.NewRequest(http.MethodPost, apiPathRankerQuery, strings.NewReader(`{"user_query": "example"}`)),
+	)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "POST, OPTIONS", recorder.Header().Get("Access-Control-Allow-Methods"))
+
+	jsonRep := RankingQueryRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.True(t, jsonRep.Success)
+	assert.Equal(t, "example", jsonRep.Query)
+	assert.Equal(t, uint64(2), jsonRep.TotalHits)
+	require.Len(t, jsonRep.Results, 2)
+	assert.ElementsMatch(
+		t, []string{"a.com", "b.com"}, []string{jsonRep.Results[0].DomainName, jsonRep.Results[1].DomainName},
+	)
+	assert.GreaterOrEqual(t, jsonRep.Results[0].FinalScore, jsonRep.Results[1].FinalScore)
+
+	// Ranking queries are POST only
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathRankerQuery, nil))
+	requireJSONError(t, recorder, http.StatusMethodNotAllowed)
+}
+
+func TestGetRequestRemoteAddress(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.1"}
+	a.initializeHTTPLimits()
+
+	newRequest := func(remoteAddr string, forwardedFor string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, apiPathStatusGet, nil)
+		r.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return r
+	}
+
+	// X-Forwarded-For should be ignored for untrusted clients
+	assert.Equal(t, "203.0.113.5", a.getRequestRemoteAddress(newRequest("203.0.113.5:1234", "198.51.100.7")))
+	// Trusted proxy
+	assert.Equal(t, "198.51.100.7", a.getRequestRemoteAddress(newRequest("192.0.2.1:1234", "198.51.100.7")))
+	// Spoofed addresses before the right-most untrusted address should be ignored
+	assert.Equal(
+		t, "198.51.100.7",
+		a.getRequestRemoteAddress(newRequest("192.0.2.1:1234", "1.2.3.4, 198.51.100.7, 10.0.0.2")),
+	)
+	// Trusted proxy without X-Forwarded-For
+	assert.Equal(t, "10.1.1.1", a.getRequestRemoteAddress(newRequest("10.1.1.1:1234", "")))
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.RateLimitPerSecond = 0.001
+	a.config.GeneralOptions.RateLimitBurst = 2
+	a.initializeHTTPLimits()
+	router := a.getRouter()
+
+	newRequest := func(remoteAddr string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, apiPathStatusGet, nil)
+		r.RemoteAddr = remoteAddr
+		return r
+	}
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, newRequest("203.0.113.5:1234"))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newRequest("203.0.113.5:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// Other clients shouldn't be affected
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, newRequest("203.0.113.6:1234"))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestRequestSizeLimitHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.MaxQueryLengthInBytes = 16
+	a.config.GeneralOptions.MaxRequestBodyInBytes = 8
+	router := a.getRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(
+		recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q="+strings.Repeat("a", 16), nil),
+	)
+	assert.Equal(t, http.StatusRequestURITooLong, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(
+		recorder, httptest.NewRequest(http.MethodPost, apiPathRankerQuery, strings.NewReader(strings.Repeat("a", 16))),
+	)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+type testErrorIndexer struct {
+	indexers.RandomIndexer
+}
+
+func (i *testErrorIndexer) Query(query *query_parser.Query, from int, size int) (map[string]float64, uint64, error) {
+	return nil, 0, errors.New("indexer is unavailable")
+}
+
+func requireJSONError(t *testing.T, recorder *httptest.ResponseRecorder, statusCode int) {
+	require.Equal(t, statusCode, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	jsonRep := ErrorRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.False(t, jsonRep.Success)
+	assert.NotEmpty(t, jsonRep.Error)
+}
+
+func TestAPIErrors(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	router := a.getRouter()
+
+	// Unknown API path
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/unknown", nil))
+	requireJSONError(t, recorder, http.StatusNotFound)
+
+	// Unsupported method
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, apiPathSearch+"?q=example", nil))
+	requireJSONError(t, recorder, http.StatusMethodNotAllowed)
+
+	// Indexer errors should only fail the request
+	var indexer interfaces.Indexer = &testErrorIndexer{}
+	a.indexer = &indexer
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example", nil))
+	requireJSONError(t, recorder, http.StatusServiceUnavailable)
+	assert.Equal(t, enum.ReadyToSearch, a.applicationStateManager.GetApplicationState().task)
+
+	// Index not ready
+	a.applicationStateManager = NewApplicationStateManager()
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example", nil))
+	requireJSONError(t, recorder, http.StatusServiceUnavailable)
+}
+
+func TestHTTPRecoverHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.initializeAPISpec()
+
+	handler := a.getCommonWrapperHandler(
+		func(w http.ResponseWriter, r *http.Request) {
+			panic("bad request")
+		},
+	)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/test", nil))
+	requireJSONError(t, recorder, http.StatusInternalServerError)
+
+	// Replies which are already started can't be changed
+	handler = a.getCommonWrapperHandler(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			panic("bad request")
+		},
+	)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestOpenAPIGetHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+
+	recorder := httptest.NewRecorder()
+	a.getRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathOpenAPI, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	spec, err := openapi.Load(recorder.Body.Bytes())
+	require.NoError(t, err)
+
+	// Every API route should be documented
+	for _, apiPath := range []string{
+		apiPathStatusGet, apiPathEvents, apiPathOpenAPI, apiPathRankerQuery, apiPathSearch, apiPathSuggest,
+		apiPathAdminPipelinePause, apiPathAdminPipelineResume, apiPathAdminZoneRerun, apiPathAdminZonePurge,
+		apiPathAdminRankersReload, metricsPath,
+	} {
+		assert.Contains(t, spec.Paths, apiPath)
+	}
+}
+
+func TestRequestValidationHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	router := a.getRouter()
+
+	for _, target := range []string{
+		apiPathSearch + "?q=",
+		apiPathSearch + "?q=example&size=0",
+		apiPathSearch + "?q=example&from=a",
+		apiPathSuggest + "?prefix=ex&size=33",
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		requireJSONError(t, recorder, http.StatusBadRequest)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(
+		recorder,
+		httptest.NewRequest(http.MethodPost, apiPathRankerQuery, strings.NewReader(`{"user_query": 1}`)),
+	)
+	requireJSONError(t, recorder, http.StatusBadRequest)
+}
+
+// Replies of the handlers should conform to the OpenAPI document.
+func TestAPIContract(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	router := a.getRouter()
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, apiPathStatusGet, nil),
+		httptest.NewRequest(http.MethodGet, apiPathOpenAPI, nil),
+		httptest.NewRequest(http.MethodGet, metricsPath, nil),
+		httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example&facet=zone:test", nil),
+		httptest.NewRequest(http.MethodGet, apiPathSearch, nil),
+		httptest.NewRequest(http.MethodGet, apiPathSuggest+"?prefix=ex", nil),
+		httptest.NewRequest(http.MethodGet, apiPathSuggest+"?prefix=ex&format=opensearch", nil),
+		httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example&format=rss", nil),
+		httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example&format=atom", nil),
+		httptest.NewRequest(http.MethodGet, webPathOpenSearch, nil),
+		httptest.NewRequest(http.MethodGet, apiPathClick+"?q=example&id=test_0&pos=2", nil),
+		httptest.NewRequest(http.MethodGet, apiPathClick+"?q=example&id=unknown_0", nil),
+		httptest.NewRequest(http.MethodPost, apiPathRankerQuery, strings.NewReader(`{"user_query": "example"}`)),
+		httptest.NewRequest(http.MethodPost, apiPathAdminPipelinePause, nil),
+		newTestAdminRequest(http.MethodPost, apiPathAdminPipelinePause, ""),
+		newTestAdminRequest(http.MethodPost, apiPathAdminPipelineResume, ""),
+		newTestAdminRequest(http.MethodPost, apiPathAdminRankersReload, ""),
+		newTestAdminRequest(http.MethodGet, apiPathAdminAnalytics, ""),
+		newTestAdminRequest(http.MethodPost, apiPathAdminSavedSearches, `{"query": "example", "facets": ["tld:com"]}`),
+		newTestAdminRequest(http.MethodPost, apiPathAdminSavedSearches, `{"query": "example", "facets": ["com"]}`),
+		newTestAdminRequest(http.MethodGet, apiPathAdminSavedSearches, ""),
+		newTestAdminRequest(http.MethodDelete, apiPathAdminSavedSearches+"?id=unknown", ""),
+		newTestAdminRequest(http.MethodPost, apiPathAdminZonePurge, `{"zone_name": "other"}`),
+		newTestAdminRequest(http.MethodPost, apiPathAdminZonePurge, `{"zone_name": "test"}`),
+		newTestAdminRequest(http.MethodPost, apiPathAdminZoneRerun, `{"zone_name": "test"}`),
+		httptest.NewRequest(http.MethodGet, apiPathSearch+"?q=example", nil),
+	}
+
+	for _, r := range requests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+		err := a.apiSpec.ValidateResponse(
+			r.URL.Path, r.Method, recorder.Code, recorder.Header().Get("Content-Type"), recorder.Body.Bytes(),
+		)
+		assert.NoError(t, err, "%s %s: %s", r.Method, r.URL, recorder.Body.String())
+	}
+}
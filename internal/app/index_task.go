@@ -17,6 +17,7 @@ import (
 	"github.com/anthony-ozdemir/zfse/internal/enum"
 	"github.com/anthony-ozdemir/zfse/internal/helper"
 	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
 )
 
 const (
@@ -95,7 +96,8 @@ func (a *Application) getTotalPostCrawlFileLinesToRead() (map[string]int, error)
 	for zoneName, _ := range a.zoneFileRegistry {
 		postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath(zoneName)
 		lines, err := helper.CountLinesInFile(postCrawlCacheFile)
-		if err != nil {
+		// Zones whose post-crawl filters didn't produce any output don't have a cache file
+		if err != nil && !os.IsNotExist(err) {
 			return nil, err
 		}
 		totalLinesMap[zoneName] = lines
@@ -124,12 +126,22 @@ func (a *Application) runIndexer(wg *sync.WaitGroup) {
 		if indexerTaskState.BIsFinished {
 			processedWorkItems += totalLinesMap[zoneName]
 			a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+			// Saved searches may not be evaluated yet, if the application was shut down right after indexing
+			a.evaluateSavedSearches(zoneName, totalLinesMap[zoneName])
+			removeZoneDeltaFile(zoneName)
 			continue
 		}
 
 		postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath(zoneName)
 		// Let's read this file line by line and input to indexer
 		file, err := os.Open(postCrawlCacheFile)
+		if os.IsNotExist(err) {
+			// Nothing to index, i.e. a re-run zone whose domains are all dropped by the filters
+			indexerTaskState.BIsFinished = true
+			a.db.SaveIndexerTaskState(zoneName, indexerTaskState)
+			removeZoneDeltaFile(zoneName)
+			continue
+		}
 		if err != nil {
 			zap.L().Fatal("Error opening file.", zap.String("err", err.Error()))
 		}
@@ -142,6 +154,9 @@ func (a *Application) runIndexer(wg *sync.WaitGroup) {
 		lineIndex := 0
 		remainingLinesToNextDBSave := totalLinesUntilIndexerDBSave
 		for {
+			// Block while the pipeline is paused
+			a.applicationStateManager.WaitIfPaused()
+
 			// Check if we need to gracefully shut-down before finishing this task.
 			currentState := a.applicationStateManager.GetApplicationState()
 			if currentState.task == enum.Shutdown {
@@ -158,6 +173,7 @@ func (a *Application) runIndexer(wg *sync.WaitGroup) {
 			}
 
 			if lineIndex < startIndex {
+				lineIndex++
 				processedWorkItems++
 				a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
 				continue
@@ -170,11 +186,18 @@ func (a *Application) runIndexer(wg *sync.WaitGroup) {
 				zap.L().Fatal("Unable to parse JSON.", zap.String("err", err.Error()))
 			}
 
+			// Design Note: Zone name isn't a part of the post-crawl output, but indexers may use it for filtering.
+			if domainProperties.StringProperties == nil {
+				domainProperties.StringProperties = make(map[string]string)
+			}
+			domainProperties.StringProperties["zone_name"] = zoneName
+
 			indexID := createIndexID(zoneName, lineIndex)
 			err = (*a.indexer).Index(indexID, domainProperties)
 			if err != nil {
 				zap.L().Fatal("Unable to index.", zap.String("err", err.Error()))
 			}
+			a.metricsManager.IncCounter(metricIndexedDocuments, 1)
 
 			remainingLinesToNextDBSave--
 			lineIndex++
@@ -191,22 +214,74 @@ func (a *Application) runIndexer(wg *sync.WaitGroup) {
 
 		indexerTaskState.BIsFinished = true
 		a.db.SaveIndexerTaskState(zoneName, indexerTaskState)
+		removeZoneDeltaFile(zoneName)
+
+		a.evaluateSavedSearches(zoneName, totalLinesMap[zoneName])
 	}
 
+	// Cached rankings don't include the newly indexed documents
+	a.invalidateRankingCache()
+
 	a.applicationStateManager.OnReadyToSearch()
 
 	zap.L().Info("Indexer tasks are finished. Ready to search!")
 
 }
 
-func (a *Application) queryIndexer(userQuery string) []common.DomainProperties {
-	output, err := (*a.indexer).Query(userQuery)
+// Reads the domain properties of the index ID from the post-crawl cache file.
+func readIndexedDomainProperties(indexID string) (common.DomainProperties, error) {
+	tldName, lineIndex, err := parseIndexID(indexID)
 	if err != nil {
-		zap.L().Fatal(
-			"Unable to index.", zap.String("indexer_type", (*a.indexer).GetType()),
-			zap.String("err", err.Error()),
+		return common.DomainProperties{}, fmt.Errorf("invalid index ID %s: %w", indexID, err)
+	}
+
+	// TODO [HP]: This is super inefficient. It looks like we need to store lineOffsetBytes in another file then
+	// use the known offsets to read in one-shot. Though, this might create memory issues too. It will take some
+	// time to implement this efficiently.
+	postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath(tldName)
+	line, err := helper.ReadLine(postCrawlCacheFile, lineIndex)
+	if err != nil {
+		return common.DomainProperties{}, fmt.Errorf(
+			"unable to read line %d of %s: %w", lineIndex, postCrawlCacheFile, err,
+		)
+	}
+
+	domainProperties, err := parseIndexedDomainProperties(tldName, line)
+	if err != nil {
+		return common.DomainProperties{}, fmt.Errorf(
+			"unable to parse line %d of %s: %w", lineIndex, postCrawlCacheFile, err,
 		)
 	}
+	return domainProperties, nil
+}
+
+// Parses the post-crawl cache file line of the zone.
+func parseIndexedDomainProperties(zoneName string, line string) (common.DomainProperties, error) {
+	// All lines are supposed to be JSON objects at this stage
+	domainProperties := common.DomainProperties{}
+	err := json.Unmarshal([]byte(line), &domainProperties)
+	if err != nil {
+		return common.DomainProperties{}, err
+	}
+
+	// Zone name is encoded within the index ID
+	if domainProperties.StringProperties == nil {
+		domainProperties.StringProperties = make(map[string]string)
+	}
+	domainProperties.StringProperties["zone_name"] = zoneName
+
+	return domainProperties, nil
+}
+
+// Design Note: Query errors only affect a single search request, thus they are returned to the caller rather than
+// shutting down the application.
+func (a *Application) queryIndexer(query *query_parser.Query, from int, size int) (
+	[]common.DomainProperties, uint64, error,
+) {
+	output, totalHits, err := (*a.indexer).Query(query, from, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to query indexer %s: %w", (*a.indexer).GetType(), err)
+	}
 
 	// Let's normalize the output scores between 0.0 and 1.0
 	normalizedScores := normalizeIndexerScore(output)
@@ -232,28 +307,19 @@ func (a *Application) queryIndexer(userQuery string) []common.DomainProperties {
 
 	sortedDomainProperties := make([]common.DomainProperties, 0)
 	for _, idScore := range idScores {
-		tldName, lineIndex, err := parseIndexID(idScore.ID)
+		domainProperties, err := readIndexedDomainProperties(idScore.ID)
 		if err != nil {
-			zap.L().Fatal("Invalid indexID.", zap.String("err", err.Error()))
+			return nil, 0, err
 		}
 
-		// TODO [HP]: This is super inefficient. It looks like we need to store lineOffsetBytes in another file then
-		// use the known offsets to read in one-shot. Though, this might create memory issues too. It will take some
-		// time to implement this efficiently.
-		postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath(tldName)
-		line, err := helper.ReadLine(postCrawlCacheFile, lineIndex)
-		if err != nil {
-			zap.L().Fatal("Unable to read line.", zap.String("err", err.Error()))
-		}
-
-		// All lines are supposed to be JSON objects at this stage
-		domainProperties := common.DomainProperties{}
-		err = json.Unmarshal([]byte(line), &domainProperties)
-		if err != nil {
-			zap.L().Fatal("Unable to parse JSON.", zap.String("err", err.Error()))
+		// Design Note: Indexers are expected to translate filters, but not all of them are able to. Thus, we need to
+		// verify the filters here as well.
+		if !query.MatchFilters(domainProperties.DomainName, domainProperties.StringProperties) {
+			continue
 		}
 
-		// Let's also record the index score
+		// Let's also record the index ID & score
+		domainProperties.StringProperties["index_id"] = idScore.ID
 		domainProperties.FloatProperties["indexer_score"] = idScore.Score
 		domainProperties.FloatProperties["normalized_indexer_score"] = normalizedScores[idScore.ID]
 
@@ -261,5 +327,5 @@ func (a *Application) queryIndexer(userQuery string) []common.DomainProperties {
 
 	}
 
-	return sortedDomainProperties
+	return sortedDomainProperties, totalHits, nil
 }
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/crawler"
+	"github.com/anthony-ozdemir/zfse/internal/metrics_manager"
+)
+
+const (
+	metricZoneFileLinesRead     = "zfse_zone_file_lines_read_total"
+	metricFilterDropped         = "zfse_filter_dropped_total"
+	metricDNSFailures           = "zfse_dns_failures_total"
+	metricRobotsDisallowed      = "zfse_robots_disallowed_total"
+	metricCrawlErrors           = "zfse_crawl_errors_total"
+	metricFetchedBytes          = "zfse_fetched_bytes_total"
+	metricIndexedDocuments      = "zfse_indexed_documents_total"
+	metricQueryDuration         = "zfse_query_duration_seconds"
+	metricRankingCacheHits      = "zfse_ranking_cache_hits_total"
+	metricRankingCacheMisses    = "zfse_ranking_cache_misses_total"
+	metricTotalWorkItems        = "zfse_pipeline_total_work_items"
+	metricProcessedWorkItems    = "zfse_pipeline_processed_work_items"
+	metricEstimateRemainingTime = "zfse_pipeline_estimate_remaining_time_seconds"
+)
+
+const (
+	filterStagePreCrawl  = "pre_crawl"
+	filterStagePostCrawl = "post_crawl"
+)
+
+func getFilterDroppedMetricName(stage string, filterType string) string {
+	return metrics_manager.WithLabels(metricFilterDropped, "stage", stage, "filter", filterType)
+}
+
+func getCrawlErrorsMetricName(errorClass string) string {
+	return metrics_manager.WithLabels(metricCrawlErrors, "class", errorClass)
+}
+
+// Registers all pipeline & query metrics. Task handlers need to be initialized beforehand.
+func (a *Application) registerMetrics() {
+	m := a.metricsManager
+
+	m.NewCounter(metricZoneFileLinesRead)
+	m.Describe(metricZoneFileLinesRead, "Number of zone file lines read by pre-crawl filters.")
+
+	for _, preCrawlFilter := range a.preCrawlFilterArray {
+		m.NewCounter(getFilterDroppedMetricName(filterStagePreCrawl, (*preCrawlFilter).GetType()))
+	}
+	for _, postCrawlFilter := range a.postCrawlFilterArray {
+		m.NewCounter(getFilterDroppedMetricName(filterStagePostCrawl, (*postCrawlFilter).GetType()))
+	}
+	m.Describe(metricFilterDropped, "Number of domains dropped by each filter.")
+
+	m.NewCounter(metricDNSFailures)
+	m.Describe(metricDNSFailures, "Number of domains without a resolvable DNS record.")
+
+	m.NewCounter(metricRobotsDisallowed)
+	m.Describe(metricRobotsDisallowed, "Number of domains disallowing crawls via robots.txt.")
+
+	for _, errorClass := range crawler.ErrorClasses {
+		m.NewCounter(getCrawlErrorsMetricName(errorClass))
+	}
+	m.Describe(metricCrawlErrors, "Number of failed crawls by error class.")
+
+	m.NewCounter(metricFetchedBytes)
+	m.Describe(metricFetchedBytes, "Number of body bytes fetched by the crawler.")
+
+	m.NewCounter(metricIndexedDocuments)
+	m.Describe(metricIndexedDocuments, "Number of documents sent to the indexer.")
+
+	m.NewHistogram(metricQueryDuration, metrics_manager.DefaultDurationBuckets)
+	m.Describe(metricQueryDuration, "Search query latency in seconds.")
+
+	m.NewCounter(metricRankingCacheHits)
+	m.Describe(metricRankingCacheHits, "Number of searches served from the ranking cache.")
+
+	m.NewCounter(metricRankingCacheMisses)
+	m.Describe(metricRankingCacheMisses, "Number of searches which needed to be ranked.")
+
+	m.NewGauge(metricTotalWorkItems)
+	m.Describe(metricTotalWorkItems, "Total work items of the current pipeline task.")
+
+	m.NewGauge(metricProcessedWorkItems)
+	m.Describe(metricProcessedWorkItems, "Processed work items of the current pipeline task.")
+
+	m.NewGauge(metricEstimateRemainingTime)
+	m.Describe(metricEstimateRemainingTime, "Estimated remaining time of the current pipeline task.")
+}
+
+func (a *Application) observeQueryDuration(startTime time.Time) {
+	a.metricsManager.ObserveHistogram(metricQueryDuration, time.Since(startTime).Seconds())
+}
+
+func (a *Application) metricsGetHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		// Pipeline progress is tracked by the state manager, so let's update the gauges before the scrape.
+		currentApplicationState := a.applicationStateManager.GetApplicationState()
+		a.metricsManager.SetGauge(metricTotalWorkItems, int64(currentApplicationState.totalWorkItems))
+		a.metricsManager.SetGauge(metricProcessedWorkItems, int64(currentApplicationState.processedWorkItems))
+		a.metricsManager.SetGauge(
+			metricEstimateRemainingTime, int64(currentApplicationState.estimateRemainingTimeInSeconds),
+		)
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", metrics_manager.PrometheusContentType)
+		w.WriteHeader(http.StatusOK)
+
+		err := a.metricsManager.WritePrometheus(w)
+		if err != nil {
+			zap.L().Warn("Unable to write metrics.", zap.String("err", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/metrics_manager"
+)
+
+func TestMetricsGetHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	mustSearch(t, a, mustParseQuery(t, "example"), 0, 10)
+	a.metricsManager.IncCounter(getCrawlErrorsMetricName("timeout"), 2)
+
+	recorder := httptest.NewRecorder()
+	a.getRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, metricsPath, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, metrics_manager.PrometheusContentType, recorder.Header().Get("Content-Type"))
+
+	body := recorder.Body.String()
+	assert.Contains(t, body, "# TYPE zfse_query_duration_seconds histogram\n")
+	assert.Contains(t, body, "zfse_query_duration_seconds_count 1\n")
+	assert.Contains(t, body, "zfse_crawl_errors_total{class=\"timeout\"} 2\n")
+	assert.Contains(t, body, "zfse_indexed_documents_total 0\n")
+	assert.Contains(t, body, "# TYPE zfse_pipeline_processed_work_items gauge\n")
+}
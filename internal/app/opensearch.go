@@ -0,0 +1,271 @@
+package app
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reply formats of the search & suggest endpoints, JSON is the default.
+const (
+	replyFormatJSON       = "json"
+	replyFormatRSS        = "rss"
+	replyFormatAtom       = "atom"
+	replyFormatOpenSearch = "opensearch"
+)
+
+// Returns the reply format of the request, which is either JSON or one of the allowed formats.
+func helperParseFormatQueryParam(r *http.Request, allowedFormats ...string) (string, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" || format == replyFormatJSON {
+		return replyFormatJSON, nil
+	}
+
+	for _, allowedFormat := range allowedFormats {
+		if format == allowedFormat {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("invalid format parameter")
+}
+
+const (
+	openSearchDescriptionContentType = "application/opensearchdescription+xml"
+	openSearchSuggestionsContentType = "application/x-suggestions+json"
+	rssContentType                   = "application/rss+xml"
+	atomContentType                  = "application/atom+xml"
+)
+
+// Returns the base URL that clients use to reach ZFSE, i.e. "https://search.example.com". Unless "public_url" is
+// configured, it is derived from the request.
+func (a *Application) getPublicBaseURL(r *http.Request) string {
+	if a.config.GeneralOptions.PublicURL != "" {
+		return strings.TrimRight(a.config.GeneralOptions.PublicURL, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else {
+		// Design Note: Similar to X-Forwarded-For, X-Forwarded-Proto is only honored when sent by a trusted proxy.
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		remoteIP := net.ParseIP(host)
+		if remoteIP != nil && a.isTrustedProxy(remoteIP) && r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+	}
+
+	return scheme + "://" + r.Host
+}
+
+type openSearchURLXML struct {
+	Type     string `xml:"type,attr"`
+	Method   string `xml:"method,attr,omitempty"`
+	Rel      string `xml:"rel,attr,omitempty"`
+	Template string `xml:"template,attr"`
+}
+
+type openSearchDescriptionXML struct {
+	XMLName       xml.Name           `xml:"http://a9.com/-/spec/opensearch/1.1/ OpenSearchDescription"`
+	ShortName     string             `xml:"ShortName"`
+	Description   string             `xml:"Description"`
+	InputEncoding string             `xml:"InputEncoding"`
+	URLs          []openSearchURLXML `xml:"Url"`
+}
+
+// Serves the OpenSearch description, so that browsers can add ZFSE as a search engine.
+func (a *Application) openSearchDescriptionHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, errMethodNotAllowed.Message, errMethodNotAllowed.StatusCode)
+			return
+		}
+
+		baseURL := a.getPublicBaseURL(r)
+		description := openSearchDescriptionXML{
+			ShortName:     "ZFSE",
+			Description:   "ZFSE - Zone File Search Engine",
+			InputEncoding: "UTF-8",
+			URLs: []openSearchURLXML{
+				{Type: "text/html", Method: "get", Template: baseURL + webPathIndex + "?q={searchTerms}"},
+				{
+					Type:     openSearchSuggestionsContentType,
+					Template: baseURL + apiPathSuggest + "?prefix={searchTerms}&format=" + replyFormatOpenSearch,
+				},
+				{Type: rssContentType, Template: baseURL + apiPathSearch + "?q={searchTerms}&format=" + replyFormatRSS},
+				{Type: atomContentType, Template: baseURL + apiPathSearch + "?q={searchTerms}&format=" + replyFormatAtom},
+				{Type: openSearchDescriptionContentType, Rel: "self", Template: baseURL + webPathOpenSearch},
+			},
+		}
+
+		a.helperSendXML(&w, openSearchDescriptionContentType, description)
+	}
+}
+
+func (a *Application) helperSendXML(w *http.ResponseWriter, contentType string, xmlReply interface{}) {
+	xmlRes, err := xml.MarshalIndent(xmlReply, "", "  ")
+	if err != nil {
+		zap.L().Error("XML Marshal error.", zap.String("err", err.Error()))
+		http.Error(*w, errInternalServerError.Message, errInternalServerError.StatusCode)
+		return
+	}
+
+	(*w).Header().Set("Content-Type", contentType+"; charset=utf-8")
+	(*w).WriteHeader(http.StatusOK)
+	_, err = (*w).Write(append([]byte(xml.Header), xmlRes...))
+	if err != nil {
+		zap.L().Warn("Unable to write XML message.", zap.String("err", err.Error()))
+	}
+}
+
+// Sends the suggestions in OpenSearch suggestions format, i.e. ["gam", ["game", "games"]].
+func (a *Application) helperSendOpenSearchSuggestions(w *http.ResponseWriter, prefix string, suggestions []string) {
+	jsonRes, err := json.Marshal([]interface{}{prefix, suggestions})
+	if err != nil {
+		zap.L().Error("Json Marshal error.", zap.String("err", err.Error()))
+		http.Error(*w, errInternalServerError.Message, errInternalServerError.StatusCode)
+		return
+	}
+
+	(*w).Header().Set("Content-Type", openSearchSuggestionsContentType)
+	(*w).WriteHeader(http.StatusOK)
+	_, err = (*w).Write(jsonRes)
+	if err != nil {
+		zap.L().Warn("Unable to write JSON message.", zap.String("err", err.Error()))
+	}
+}
+
+type rssGUIDXML struct {
+	BIsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value        string `xml:",chardata"`
+}
+
+type rssItemXML struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description,omitempty"`
+	GUID        rssGUIDXML `xml:"guid"`
+}
+
+type rssChannelXML struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	Description   string       `xml:"description"`
+	LastBuildDate string       `xml:"lastBuildDate"`
+	Items         []rssItemXML `xml:"item"`
+}
+
+type rssFeedXML struct {
+	XMLName xml.Name      `xml:"rss"`
+	Version string        `xml:"version,attr"`
+	Channel rssChannelXML `xml:"channel"`
+}
+
+type atomLinkXML struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomTextXML struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntryXML struct {
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Link    atomLinkXML  `xml:"link"`
+	Summary *atomTextXML `xml:"summary,omitempty"`
+}
+
+type atomFeedXML struct {
+	XMLName xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string         `xml:"title"`
+	ID      string         `xml:"id"`
+	Updated string         `xml:"updated"`
+	Links   []atomLinkXML  `xml:"link"`
+	Entries []atomEntryXML `xml:"entry"`
+}
+
+// Returns the display title of the search result, as in the Web UI.
+func getSearchResultTitle(result SearchResultJSON) string {
+	if result.StringProperties["title"] != "" {
+		return result.StringProperties["title"]
+	}
+	return result.DomainName
+}
+
+// Sends the search results as an RSS or Atom feed, so that queries can be subscribed in feed readers.
+// Design Note: Domains don't have a publish date, thus entries are identified by their URL and feed readers are
+// expected to only notify about the URLs they haven't seen before.
+func (a *Application) helperSendSearchFeed(
+	w *http.ResponseWriter, r *http.Request, format string, userQuery string, results []SearchResultJSON,
+) {
+	baseURL := a.getPublicBaseURL(r)
+	webUIURL := baseURL + webPathIndex + "?q=" + url.QueryEscape(userQuery)
+	feedURL := baseURL + r.URL.RequestURI()
+	title := "ZFSE: " + userQuery
+	now := time.Now().UTC()
+
+	if format == replyFormatRSS {
+		feed := rssFeedXML{
+			Version: "2.0",
+			Channel: rssChannelXML{
+				Title:         title,
+				Link:          webUIURL,
+				Description:   "Search results of \"" + userQuery + "\"",
+				LastBuildDate: now.Format(time.RFC1123Z),
+				Items:         make([]rssItemXML, 0, len(results)),
+			},
+		}
+		for _, result := range results {
+			feed.Channel.Items = append(
+				feed.Channel.Items, rssItemXML{
+					Title:       getSearchResultTitle(result),
+					Link:        result.URL,
+					Description: result.Snippet,
+					GUID:        rssGUIDXML{BIsPermaLink: true, Value: result.URL},
+				},
+			)
+		}
+		a.helperSendXML(w, rssContentType, feed)
+		return
+	}
+
+	feed := atomFeedXML{
+		Title:   title,
+		ID:      feedURL,
+		Updated: now.Format(time.RFC3339),
+		Links: []atomLinkXML{
+			{Href: feedURL, Rel: "self", Type: atomContentType},
+			{Href: webUIURL, Rel: "alternate", Type: "text/html"},
+		},
+		Entries: make([]atomEntryXML, 0, len(results)),
+	}
+	for _, result := range results {
+		entry := atomEntryXML{
+			Title:   getSearchResultTitle(result),
+			ID:      result.URL,
+			Updated: now.Format(time.RFC3339),
+			Link:    atomLinkXML{Href: result.URL},
+		}
+		if result.Snippet != "" {
+			// Snippets carry highlighting markup
+			entry.Summary = &atomTextXML{Type: "html", Value: result.Snippet}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	a.helperSendXML(w, atomContentType, feed)
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+)
+
+func TestOpenSearchDescriptionHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	router := a.getRouter()
+
+	getDescription := func(r *http.Request) openSearchDescriptionXML {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, openSearchDescriptionContentType+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+		description := openSearchDescriptionXML{}
+		require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &description))
+		return description
+	}
+
+	// Base URL is derived from the request
+	r := httptest.NewRequest(http.MethodGet, webPathOpenSearch, nil)
+	r.Host = "search.example.com"
+	description := getDescription(r)
+	assert.Equal(t, "ZFSE", description.ShortName)
+	require.Len(t, description.URLs, 5)
+	assert.Equal(t, "text/html", description.URLs[0].Type)
+	assert.Equal(t, "http://search.example.com/?q={searchTerms}", description.URLs[0].Template)
+	assert.Equal(
+		t, "http://search.example.com/v1/suggest?prefix={searchTerms}&format=opensearch", description.URLs[1].Template,
+	)
+
+	// X-Forwarded-Proto is ignored unless sent by a trusted proxy
+	r = httptest.NewRequest(http.MethodGet, webPathOpenSearch, nil)
+	r.Host = "search.example.com"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	assert.Equal(t, "http://search.example.com/?q={searchTerms}", getDescription(r).URLs[0].Template)
+
+	a.config.GeneralOptions.TrustedProxies = []string{"192.0.2.0/24"}
+	a.initializeHTTPLimits()
+	assert.Equal(t, "https://search.example.com/?q={searchTerms}", getDescription(r).URLs[0].Template)
+
+	// Configured public URL has precedence
+	a.config.GeneralOptions.PublicURL = "https://zfse.example.org/"
+	assert.Equal(t, "https://zfse.example.org/?q={searchTerms}", getDescription(r).URLs[0].Template)
+
+	// Web UI should advertise the description
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, webPathIndex, nil))
+	assert.Contains(t, recorder.Body.String(), `href="/opensearch.xml"`)
+}
+
+func TestOpenSearchSuggestions(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	var indexer interfaces.Indexer = &testSuggesterIndexer{}
+	a.indexer = &indexer
+	router := a.getRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSuggest+"?prefix=gam&format=opensearch", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, openSearchSuggestionsContentType, recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `["gam", ["gaming", "gams"]]`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPathSuggest+"?prefix=gam&format=rss", nil))
+	requireJSONError(t, recorder, http.StatusBadRequest)
+}
+
+func TestSearchFeeds(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com"})
+	router := a.getRouter()
+
+	search := func(format string) *httptest.ResponseRecorder {
+		target := apiPathSearch + "?q=example+a"
+		if format != "" {
+			target += "&format=" + format
+		}
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Host = "search.example.com"
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	recorder := search(replyFormatRSS)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, rssContentType+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+	rssFeed := rssFeedXML{}
+	require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &rssFeed))
+	assert.Equal(t, "2.0", rssFeed.Version)
+	assert.Equal(t, "http://search.example.com/?q=example+a", rssFeed.Channel.Link)
+	require.Len(t, rssFeed.Channel.Items, 2)
+	for _, item := range rssFeed.Channel.Items {
+		assert.Contains(t, []string{"a.com", "b.com"}, item.Title)
+		assert.Equal(t, a.getDomainURL(item.Title), item.Link)
+		assert.Equal(t, item.Link, item.GUID.Value)
+	}
+
+	recorder = search(replyFormatAtom)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, atomContentType+"; charset=utf-8", recorder.Header().Get("Content-Type"))
+	atomFeed := atomFeedXML{}
+	require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &atomFeed))
+	assert.Equal(t, "http://search.example.com/v1/search?q=example+a&format=atom", atomFeed.ID)
+	require.Len(t, atomFeed.Entries, 2)
+	for _, entry := range atomFeed.Entries {
+		assert.Equal(t, a.getDomainURL(entry.Title), entry.Link.Href)
+		assert.Equal(t, entry.Link.Href, entry.ID)
+	}
+
+	// JSON is still the default
+	recorder = search("")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	jsonRep := SearchRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jsonRep))
+	assert.Len(t, jsonRep.Results, 2)
+
+	requireJSONError(t, search("csv"), http.StatusBadRequest)
+}
@@ -14,6 +14,7 @@ import (
 	"golang.org/x/net/html"
 
 	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/crawler"
 	"github.com/anthony-ozdemir/zfse/internal/database"
 	"github.com/anthony-ozdemir/zfse/internal/enum"
 	"github.com/anthony-ozdemir/zfse/internal/filebuf"
@@ -28,7 +29,8 @@ func (a *Application) getTotalPreCrawlFileLinesToRead() (map[string]int, error)
 	for zoneName, _ := range a.zoneFileRegistry {
 		preCrawlCacheFile := path_manager.GetPreCrawlFilterOutputFilePath(zoneName)
 		lines, err := helper.CountLinesInFile(preCrawlCacheFile)
-		if err != nil {
+		// Zones whose pre-crawl filters didn't produce any output don't have a cache file
+		if err != nil && !os.IsNotExist(err) {
 			return nil, err
 		}
 		totalLinesMap[zoneName] = lines
@@ -36,6 +38,36 @@ func (a *Application) getTotalPreCrawlFileLinesToRead() (map[string]int, error)
 	return totalLinesMap, nil
 }
 
+// Tracks the pre-crawl cache file lines processed by the concurrent connectors. Lines complete out of order, thus the
+// task state can only move up to the first line which isn't processed yet.
+type processedLineTracker struct {
+	mutex sync.Mutex
+	// All lines before this one are processed
+	nextLineIndex  int
+	processedLines map[int]bool
+}
+
+func newProcessedLineTracker(startLineIndex int) *processedLineTracker {
+	return &processedLineTracker{nextLineIndex: startLineIndex, processedLines: make(map[int]bool)}
+}
+
+func (t *processedLineTracker) MarkProcessed(lineIndex int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.processedLines[lineIndex] = true
+	for t.processedLines[t.nextLineIndex] {
+		delete(t.processedLines, t.nextLineIndex)
+		t.nextLineIndex++
+	}
+}
+
+func (t *processedLineTracker) GetNextLineIndex() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.nextLineIndex
+}
+
 func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 	defer wg.Done()
 
@@ -52,6 +84,13 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 	var processedWorkItemsMutex sync.Mutex
 	processedWorkItems := 0
 	a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+	// Design Note: Called from the connectors, thus the mutex is released right away rather than deferred.
+	addProcessedWorkItems := func(qty int) {
+		processedWorkItemsMutex.Lock()
+		processedWorkItems += qty
+		a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+		processedWorkItemsMutex.Unlock()
+	}
 
 	// Design Note:
 	// 1. Read the input pre-crawl cache file line by line.
@@ -63,13 +102,6 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Design Note: We will use a buffered channel to control the concurrent
-	// crawler usage
-	availableConnectors := make(chan struct{}, a.config.GeneralOptions.ConcurrentConnections)
-	for i := 0; i < a.config.GeneralOptions.ConcurrentConnections; i++ {
-		availableConnectors <- struct{}{}
-	}
-
 	var appendedToFileMutex sync.RWMutex
 	bHasAppendedToFileOnce := false
 	for zoneName, _ := range a.zoneFileRegistry {
@@ -80,17 +112,34 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 			bHasAppendedToFileOnce = true
 			appendedToFileMutex.Unlock()
 
-			processedWorkItemsMutex.Lock()
-			defer processedWorkItemsMutex.Unlock()
-			processedWorkItems += totalLinesMap[zoneName]
-			a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+			addProcessedWorkItems(totalLinesMap[zoneName])
 			continue
 		}
 
+		// Design Note: We will use a buffered channel to control the concurrent crawler usage. Connectors are
+		// drained once the zone is finished, thus each zone gets its own channel.
+		availableConnectors := make(chan struct{}, a.config.GeneralOptions.ConcurrentConnections)
+		for i := 0; i < a.config.GeneralOptions.ConcurrentConnections; i++ {
+			availableConnectors <- struct{}{}
+		}
+
+		// Outputs of a zone snapshot delta are appended to the output of the previous snapshots
+		postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath(zoneName)
+		if totalLines, _ := helper.CountLinesInFile(postCrawlCacheFile); totalLines > 0 {
+			appendedToFileMutex.Lock()
+			bHasAppendedToFileOnce = true
+			appendedToFileMutex.Unlock()
+		}
+
 		// Open the cache file from pre-connection filter
 		preCrawlCacheFile := path_manager.GetPreCrawlFilterOutputFilePath(zoneName)
 
 		file, err := os.Open(preCrawlCacheFile)
+		if os.IsNotExist(err) {
+			// Nothing to crawl, i.e. a re-run zone whose seeds are all dropped by the pre-crawl filters
+			a.db.SavePostCrawlFilterTaskState(zoneName, database.PostCrawlFilterTaskState{BIsFinished: true})
+			continue
+		}
 		if err != nil {
 			zap.L().Fatal("Error opening file.", zap.String("err", err.Error()))
 		}
@@ -98,28 +147,24 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 
 		startLineIndex := taskState.LineIndex
 		lineIndex := 0
+		processedLines := newProcessedLineTracker(startLineIndex)
 
 		// Prepare output file buffer
 		outputFileBufferOpts := filebuf.FileOutputBufferOptions{
 			BulkOutputLimit: a.config.GeneralOptions.FileBulkOutputQty,
-			FilePath:        path_manager.GetPostCrawlFilterOutputFilePath(zoneName),
+			FilePath:        postCrawlCacheFile,
 			OnAppendCB: func() {
-				processedWorkItemsMutex.Lock()
-				defer processedWorkItemsMutex.Unlock()
-				processedWorkItems++
-				a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+				addProcessedWorkItems(1)
 			},
-			OnFlushCB: func(appendQty int) {
-				// Design Note: This function will be called from a different thread.
-				// Thus, we need to rely on appendQty of fileOutputBuffer.
-				// TODO [LP]: Is this correct? Check via an unit test.
-				lastIndex := startLineIndex + appendQty
-				// Save Task State at this point
-				taskState := database.PreCrawlFilterTaskState{
+			OnFlushCB: func(int) {
+				// Design Note: Task state is the index of the pre-crawl cache file line to resume from, thus output
+				// line counts can't be used here. Lines are marked as processed after their outputs are appended, so
+				// the outputs of all processed lines are flushed at this point.
+				taskState := database.PostCrawlFilterTaskState{
 					BIsFinished: false,
-					LineIndex:   lastIndex,
+					LineIndex:   processedLines.GetNextLineIndex(),
 				}
-				a.db.SavePreCrawlFilterTaskState(zoneName, taskState)
+				a.db.SavePostCrawlFilterTaskState(zoneName, taskState)
 			},
 		}
 		fileOutputBuffer := filebuf.NewFileOutputBuffer(outputFileBufferOpts)
@@ -128,6 +173,9 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 		reader := bufio.NewReader(file)
 
 		for {
+			// Block while the pipeline is paused
+			a.applicationStateManager.WaitIfPaused()
+
 			// Check if we need to gracefully shut-down before finishing this task.
 			currentState := a.applicationStateManager.GetApplicationState()
 			if currentState.task == enum.Shutdown {
@@ -158,10 +206,8 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 				line = strings.TrimSpace(line)
 				if len(line) == 0 {
 					// Skip empty lines
-					processedWorkItemsMutex.Lock()
-					defer processedWorkItemsMutex.Unlock()
-					processedWorkItems++
-					a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+					processedLines.MarkProcessed(lineIndex)
+					addProcessedWorkItems(1)
 					continue
 				}
 
@@ -178,47 +224,44 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 				// Launch a new connector
 				// Design Note: Ensure to copy domainProperties otherwise it will cause
 				// data race issues.
-				go func(ctx context.Context, domainProperties common.DomainProperties) {
+				go func(ctx context.Context, domainProperties common.DomainProperties, lineIndex int) {
 					defer func() { availableConnectors <- struct{}{} }() // Release a connector
+					// Design Note: Lines interrupted by a shutdown are processed again on resume. Thus, a line which
+					// was finished right before the shutdown may be appended twice, yet no line is skipped.
+					defer func() {
+						if ctx.Err() == nil {
+							processedLines.MarkProcessed(lineIndex)
+						}
+					}()
 
 					bHasDNSRecord := a.crawler.HasDNSARecord(ctx, domainProperties.DomainName)
 					if !bHasDNSRecord {
-						processedWorkItemsMutex.Lock()
-						defer processedWorkItemsMutex.Unlock()
-						processedWorkItems++
-						a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+						a.metricsManager.IncCounter(metricDNSFailures, 1)
+						addProcessedWorkItems(1)
 						return
 					}
 
-					url := a.config.GeneralOptions.ConnectionProtocol + "://" + domainProperties.DomainName
+					url := a.getDomainURL(domainProperties.DomainName)
 
 					bCanCrawl := a.crawler.CanCrawl(ctx, url)
 					if !bCanCrawl {
-						processedWorkItemsMutex.Lock()
-						defer processedWorkItemsMutex.Unlock()
-						processedWorkItems++
-						a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+						a.metricsManager.IncCounter(metricRobotsDisallowed, 1)
+						addProcessedWorkItems(1)
 						return
 					}
 
 					// Let's read the body
 					header, baseNode, err := a.crawler.Crawl(ctx, url)
 					if err != nil {
-						// TODO [LP]: We should at least increment a metric here.
-						processedWorkItemsMutex.Lock()
-						defer processedWorkItemsMutex.Unlock()
-						processedWorkItems++
-						a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+						a.metricsManager.IncCounter(getCrawlErrorsMetricName(crawler.ClassifyError(err)), 1)
+						addProcessedWorkItems(1)
 						return
 					}
 
 					// Let's input this through the post connection filter chain
 					output := a.postCrawlProcessDomainProperties(&domainProperties, header, baseNode)
 					if output == nil {
-						processedWorkItemsMutex.Lock()
-						defer processedWorkItemsMutex.Unlock()
-						processedWorkItems++
-						a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+						addProcessedWorkItems(1)
 						return
 					}
 
@@ -234,7 +277,7 @@ func (a *Application) runPostCrawlFilters(wg *sync.WaitGroup) {
 					}
 					appendedToFileMutex.Unlock()
 
-				}(ctx, domainProperties)
+				}(ctx, domainProperties, lineIndex)
 
 			}
 
@@ -278,6 +321,9 @@ func (a *Application) postCrawlProcessDomainProperties(
 	for i, postCrawlFilter := range a.postCrawlFilterArray {
 		output := (*postCrawlFilter).Input(inProperties, header, baseNode)
 		if output == nil {
+			a.metricsManager.IncCounter(
+				getFilterDroppedMetricName(filterStagePostCrawl, (*postCrawlFilter).GetType()), 1,
+			)
 			return nil
 		}
 
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/crawler"
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+)
+
+// Writes the pre-crawl cache file of the zone. Domains are expected to be dropped by the crawler, i.e. localhost.
+func writeTestPreCrawlCacheFile(t *testing.T, zoneName string, domainNames []string) {
+	lines := make([]string, 0, len(domainNames))
+	for _, domainName := range domainNames {
+		domainProperties := common.NewDomainProperties()
+		domainProperties.DomainName = domainName
+		jsonString, err := domainProperties.ToJSONString()
+		require.NoError(t, err)
+		lines = append(lines, jsonString)
+	}
+
+	preCrawlCacheFile := path_manager.GetPreCrawlFilterOutputFilePath(zoneName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(preCrawlCacheFile), 0700))
+	require.NoError(t, os.WriteFile(preCrawlCacheFile, []byte(strings.Join(lines, "\n")+"\n"), 0600))
+}
+
+// Runs the pipeline task, failing the test if it doesn't finish in time.
+func runTestPipelineTask(t *testing.T, task func(wg *sync.WaitGroup)) {
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go task(&wg)
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "pipeline task didn't finish")
+	}
+}
+
+// Sets up the crawler of the test application, crawls are expected to fail quickly.
+func initializeTestCrawler(a *Application) {
+	a.config.GeneralOptions.ConcurrentConnections = 1
+	a.config.GeneralOptions.FileBulkOutputQty = 1024
+	a.config.GeneralOptions.ConnectionProtocol = "http"
+	a.crawler = crawler.NewCrawler(crawler.CrawlerOptions{TimeOutInSeconds: 1})
+}
+
+func TestPostCrawlFiltersPartiallyFinishedZones(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	initializeTestCrawler(a)
+	require.True(t, a.applicationStateManager.OnRestart())
+	a.applicationStateManager.OnPreCrawlFiltersStarted()
+	a.applicationStateManager.OnPreCrawlFiltersFinished()
+	a.applicationStateManager.OnPostCrawlFiltersStarted()
+
+	// "test" zone is already finished, while the others are snapshot deltas
+	writeTestPreCrawlCacheFile(t, "test", []string{"a.com"})
+	a.db.SavePostCrawlFilterTaskState("test", database.PostCrawlFilterTaskState{BIsFinished: true, LineIndex: 1})
+	for _, zoneName := range []string{"delta_a", "delta_b", "delta_c"} {
+		a.zoneFileRegistry[zoneName] = ""
+		writeTestPreCrawlCacheFile(t, zoneName, []string{"localhost", "localhost"})
+	}
+
+	runTestPipelineTask(t, a.runPostCrawlFilters)
+	assert.Equal(t, enum.FinishedPostCrawlFilters, a.applicationStateManager.GetApplicationState().task)
+	for zoneName := range a.zoneFileRegistry {
+		assert.True(t, a.db.GetPostCrawlFilterTaskState(zoneName).BIsFinished, zoneName)
+	}
+}
+
+func TestProcessedLineTracker(t *testing.T) {
+	// Post-crawl filters resume from the first line which isn't processed yet
+	processedLines := newProcessedLineTracker(5)
+	assert.Equal(t, 5, processedLines.GetNextLineIndex())
+
+	// Lines are processed out of order by the connectors
+	processedLines.MarkProcessed(6)
+	processedLines.MarkProcessed(8)
+	assert.Equal(t, 5, processedLines.GetNextLineIndex())
+
+	processedLines.MarkProcessed(5)
+	assert.Equal(t, 7, processedLines.GetNextLineIndex())
+
+	// Lines interrupted by a shutdown are never marked, thus the following lines are processed again on resume
+	processedLines.MarkProcessed(9)
+	assert.Equal(t, 7, processedLines.GetNextLineIndex())
+
+	processedLines.MarkProcessed(7)
+	assert.Equal(t, 10, processedLines.GetNextLineIndex())
+	assert.Empty(t, processedLines.processedLines)
+}
@@ -1,10 +1,8 @@
 package app
 
 import (
-	"bufio"
+	"errors"
 	"io"
-	"os"
-	"strings"
 	"sync"
 
 	"go.uber.org/zap"
@@ -14,7 +12,9 @@ import (
 	"github.com/anthony-ozdemir/zfse/internal/enum"
 	"github.com/anthony-ozdemir/zfse/internal/filebuf"
 	"github.com/anthony-ozdemir/zfse/internal/helper"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
 	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/zone_file"
 )
 
 func (a *Application) getTotalZoneFileLinesToRead() (map[string]int, error) {
@@ -22,7 +22,7 @@ func (a *Application) getTotalZoneFileLinesToRead() (map[string]int, error) {
 	// we need to estimate via read bytes. Though, this would increase code complexity.
 	totalLinesMap := make(map[string]int)
 	for zoneName, zoneFile := range a.zoneFileRegistry {
-		lines, err := helper.CountLinesInFile(zoneFile)
+		lines, err := zone_file.CountLines(getPreCrawlInputFilePath(zoneName, zoneFile))
 		if err != nil {
 			return nil, err
 		}
@@ -34,6 +34,9 @@ func (a *Application) getTotalZoneFileLinesToRead() (map[string]int, error) {
 func (a *Application) runPreCrawlFilters(wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	// Changed zone files are reduced to their deltas before estimating the work items
+	a.updateZoneSnapshots()
+
 	// Let's first setup work item estimates
 	totalLinesMap, err := a.getTotalZoneFileLinesToRead()
 	if err != nil {
@@ -65,15 +68,17 @@ func (a *Application) runPreCrawlFilters(wg *sync.WaitGroup) {
 			continue
 		}
 
-		// Open the zone file
-		file, err := os.Open(zoneFile)
-		if err != nil {
-			zap.L().Fatal("Error opening zone file.", zap.String("err", err.Error()))
+		// Outputs of a zone snapshot delta are appended to the output of the previous snapshots
+		if lines, _ := helper.CountLinesInFile(path_manager.GetPreCrawlFilterOutputFilePath(zoneName)); lines > 0 {
+			bHasAppendedToFileOnce = true
 		}
-		defer file.Close()
 
-		// Create a bufio.Scanner to read the file line by line
-		reader := bufio.NewReader(file)
+		// Open the seed file via its seed source, compressed seed files are decompressed while reading
+		seedReader, err := a.openSeedReader(zoneName, getPreCrawlInputFilePath(zoneName, zoneFile))
+		if err != nil {
+			zap.L().Fatal("Error opening seed file.", zap.String("err", err.Error()))
+		}
+		defer seedReader.Close()
 
 		startLineIndex := taskState.LineIndex
 		lineIndex := 0
@@ -84,7 +89,11 @@ func (a *Application) runPreCrawlFilters(wg *sync.WaitGroup) {
 			FilePath:        path_manager.GetPreCrawlFilterOutputFilePath(zoneName),
 			OnFlushCB: func(appendedLinesQty int) {
 				// Design Note: This function will be called from the same thread.
-				// Thus, we can just use the current lineIndex.
+				// Thus, we can just use the current lineIndex. Buffer is only flushed at line boundaries, i.e. once
+				// all seeds of the line are appended, as seeds starting on the saved line are skipped on resume.
+				if hasPendingSeeds(seedReader) {
+					panic("Programming error.")
+				}
 
 				// Save Task State at this point
 				taskState := database.PreCrawlFilterTaskState{
@@ -97,84 +106,64 @@ func (a *Application) runPreCrawlFilters(wg *sync.WaitGroup) {
 		outputFileBuffer := filebuf.NewFileOutputBuffer(outputBufferOpts)
 
 		for {
+			// Block while the pipeline is paused
+			a.applicationStateManager.WaitIfPaused()
+
 			// Check if we need to gracefully shut-down before finishing this task.
+			// Design Note: Remaining seeds of the current line are processed first, so that the task state is saved
+			// at a line boundary.
 			currentState := a.applicationStateManager.GetApplicationState()
-			if currentState.task == enum.Shutdown {
+			if currentState.task == enum.Shutdown && !hasPendingSeeds(seedReader) {
 				outputFileBuffer.Flush()
 				return
 			}
 
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				zap.L().Fatal("Error reading a line from zone file.", zap.String("err", err.Error()))
+			seed, err := seedReader.Next()
+			if err == io.EOF {
+				break
 			}
 
-			if lineIndex >= startLineIndex {
-				line = strings.TrimSpace(line)
-				if len(line) == 0 {
-					// Skip empty lines
-					processedWorkItems++
-					a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
-					continue
-				}
-
-				// TLD Zone Files are structured by whitespace separators. Thus,
-				// we can parse if via strings.Fields method.
-				fields := strings.Fields(line)
-
-				// Design Note: We are interested about records with at least five fields:
-				// domainName, TTL, dnsRecordClass, dnsRecordType, dnsRecordData
-				if len(fields) < 5 {
-					processedWorkItems++
-					a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
-					continue
-				}
-
-				// Prepare the initial DomainProperties
-				domainProperties := common.DomainProperties{
-					DomainName:       fields[0],
-					StringProperties: make(map[string]string),
-					IntProperties:    make(map[string]int64),
-					FloatProperties:  make(map[string]float64),
-					BoolProperties:   make(map[string]bool),
-				}
-
-				domainProperties.StringProperties["ttl"] = fields[1]
-				domainProperties.StringProperties["record_class"] = fields[2]
-				domainProperties.StringProperties["record_type"] = fields[3]
-				domainProperties.StringProperties["record_data"] = strings.Join(fields[4:], " ")
-
-				// Discard last . on DNS domain name
-				// For example, example.com. will be recorded as example.com
-				lastDotIndex := strings.LastIndex(domainProperties.DomainName, ".")
-				if lastDotIndex != -1 {
-					domainNameWithoutLastDot := domainProperties.DomainName[:lastDotIndex]
-					domainProperties.DomainName = domainNameWithoutLastDot
-				}
+			// Design Note: Seeds may span multiple lines, thus progress is tracked by the lines read so far. Seeds
+			// starting on the lines which were processed before resuming are skipped.
+			readLines := seedReader.GetLineCount() - lineIndex
+			lineIndex = seedReader.GetLineCount()
+			processedWorkItems += readLines
+			a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
 
-				// Let's input this through the post connection filter chain
-				output := a.preCrawlProcessDomainProperties(&domainProperties)
-				if output == nil {
-					processedWorkItems++
-					a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
-					continue
+			parseError := &common.SeedParseError{}
+			if errors.As(err, &parseError) {
+				if parseError.LineNumber > startLineIndex {
+					a.metricsManager.IncCounter(metricZoneFileLinesRead, int64(readLines))
+					zap.L().Warn(
+						"Skipping malformed seed.",
+						zap.String("zone_name", zoneName),
+						zap.String("err", parseError.Error()),
+					)
 				}
+				continue
+			}
+			if err != nil {
+				zap.L().Fatal("Error reading a seed from seed file.", zap.String("err", err.Error()))
+			}
 
-				jsonString, err := output.ToJSONString()
-				if err != nil {
-					zap.L().Fatal("Unable to unmarshall JSON", zap.String("err", err.Error()))
-				}
-				outputFileBuffer.AppendToFile(jsonString)
-				bHasAppendedToFileOnce = true
+			if seed.LineNumber <= startLineIndex {
+				continue
 			}
+			a.metricsManager.IncCounter(metricZoneFileLinesRead, int64(readLines))
 
-			processedWorkItems++
-			a.applicationStateManager.SetProcessedWorkItems(processedWorkItems)
+			// Let's input this through the pre-crawl filter chain
+			domainProperties := seed.DomainProperties
+			output := a.preCrawlProcessDomainProperties(&domainProperties)
+			if output == nil {
+				continue
+			}
 
-			lineIndex++
+			jsonString, err := output.ToJSONString()
+			if err != nil {
+				zap.L().Fatal("Unable to unmarshall JSON", zap.String("err", err.Error()))
+			}
+			outputFileBuffer.AppendToFile(jsonString)
+			bHasAppendedToFileOnce = true
 		}
 
 		// Force output at this stage, we don't have any more lines to read.
@@ -198,12 +187,21 @@ func (a *Application) runPreCrawlFilters(wg *sync.WaitGroup) {
 
 }
 
+// Returns true while seeds of the last read line are yet to be returned by the seed reader.
+func hasPendingSeeds(seedReader interfaces.SeedReader) bool {
+	multiSeedReader, ok := seedReader.(interfaces.MultiSeedReader)
+	return ok && multiSeedReader.HasPendingSeeds()
+}
+
 func (a *Application) preCrawlProcessDomainProperties(inProperties *common.DomainProperties) *common.DomainProperties {
 	// Send output of a filter to next one until finished.
 
 	for i, preCrawlFilter := range a.preCrawlFilterArray {
 		output := (*preCrawlFilter).Input(inProperties)
 		if output == nil {
+			a.metricsManager.IncCounter(
+				getFilterDroppedMetricName(filterStagePreCrawl, (*preCrawlFilter).GetType()), 1,
+			)
 			return nil
 		}
 
@@ -0,0 +1,251 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+	"github.com/anthony-ozdemir/zfse/internal/snippet"
+)
+
+const (
+	snippetMaxLength = 200
+
+	// Maximum number of values reported per facet
+	facetMaxValues = 10
+
+	facetFieldZone               = query_parser.FilterFieldZone
+	facetFieldNameserverProvider = query_parser.FilterFieldNameserver
+)
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type SearchOutput struct {
+	// Ranked results within the requested page
+	Results []common.DomainProperties
+	// Total number of hits reported by the Indexer
+	TotalHits uint64
+	// Number of ranked results that can be paginated, after filters & indexer output limit are applied
+	AvailableHits int
+	// Facet field to value counts over all available hits
+	Facets map[string][]FacetCount
+}
+
+// Design Note: The query engine is the read-only counterpart of the indexing pipeline. Queries never alter the
+// ApplicationStateManager, never write to the cache folder and only read from the Indexer & Rankers. Thus, any number
+// of queries can run at once, limited only by the available query slots.
+
+func (a *Application) initializeQuerySlots() {
+	maxConcurrentQueries := a.config.GeneralOptions.MaxConcurrentQueries
+	if maxConcurrentQueries <= 0 {
+		maxConcurrentQueries = a.config.GeneralOptions.NumThreadHint
+	}
+	if maxConcurrentQueries <= 0 {
+		maxConcurrentQueries = 1
+	}
+
+	// Design Note: We will use a buffered channel to control the concurrent
+	// query count
+	a.availableQuerySlots = make(chan struct{}, maxConcurrentQueries)
+	for i := 0; i < maxConcurrentQueries; i++ {
+		a.availableQuerySlots <- struct{}{}
+	}
+}
+
+// Search queries the Indexer and ranks the output via Rankers. Output is paginated by the [from, from+size) window,
+// along with the hit counts & facets. It is safe to call Search concurrently.
+func (a *Application) Search(query *query_parser.Query, from int, size int) (SearchOutput, error) {
+	<-a.availableQuerySlots                                // Acquire a query slot
+	defer func() { a.availableQuerySlots <- struct{}{} }() // Release the query slot
+	defer a.observeQueryDuration(time.Now())
+
+	// Design Note: Rankers are free to re-order the Indexer output. Thus, we cannot paginate via the Indexer
+	// directly, otherwise results would be inconsistent between pages. Instead, we rank all candidates up to the
+	// indexer output limit, then paginate the ranked output.
+	rankerOutput, totalHits, bIsCached := a.getCachedRanking(query)
+	if !bIsCached {
+		indexerOutput, indexerTotalHits, err := a.queryIndexer(
+			query, 0, int(a.config.GeneralOptions.IndexerOutputLimit),
+		)
+		if err != nil {
+			return SearchOutput{}, err
+		}
+
+		rankerOutput = a.runRankers(indexerOutput, query.Raw)
+		totalHits = indexerTotalHits
+		a.cacheRanking(query, rankerOutput, totalHits)
+	}
+
+	// Snippets are only needed for the requested page
+	output := paginate(rankerOutput, from, size)
+	a.addSnippets(output, query)
+
+	return SearchOutput{
+		Results:       output,
+		TotalHits:     totalHits,
+		AvailableHits: len(rankerOutput),
+		Facets:        a.countFacets(rankerOutput),
+	}, nil
+}
+
+// Returns the facet fields, i.e. zone, nameserver provider & configured string properties.
+func (a *Application) getFacetFields() []string {
+	facetFields := []string{facetFieldZone, facetFieldNameserverProvider}
+	for _, key := range a.config.GeneralOptions.FacetProperties {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" || key == facetFieldZone || key == facetFieldNameserverProvider {
+			continue
+		}
+		facetFields = append(facetFields, key)
+	}
+	return facetFields
+}
+
+// Returns the facet value of the domain. Facet values can be used as filter values for the same field, thus facet
+// selections are applied via Query.AddFilter.
+func getFacetValue(field string, domainProperties common.DomainProperties) string {
+	switch field {
+	case facetFieldZone:
+		return strings.ToLower(domainProperties.StringProperties["zone_name"])
+	case facetFieldNameserverProvider:
+		return query_parser.GetNameserverProvider(domainProperties.StringProperties)
+	default:
+		return strings.ToLower(strings.TrimSpace(domainProperties.StringProperties[field]))
+	}
+}
+
+// Design Note: Facets are counted over the ranked candidate set rather than within the Indexer. Thus, any string
+// property can be used as a facet regardless of what the Indexer records, and counts are always consistent with the
+// paginated results.
+func (a *Application) countFacets(input []common.DomainProperties) map[string][]FacetCount {
+	facets := make(map[string][]FacetCount)
+	for _, field := range a.getFacetFields() {
+		valueCounts := make(map[string]int)
+		for _, domainProperties := range input {
+			value := getFacetValue(field, domainProperties)
+			if value == "" {
+				continue
+			}
+			valueCounts[value]++
+		}
+
+		facetCounts := make([]FacetCount, 0, len(valueCounts))
+		for value, count := range valueCounts {
+			facetCounts = append(facetCounts, FacetCount{Value: value, Count: count})
+		}
+		sort.Slice(
+			facetCounts, func(i, j int) bool {
+				if facetCounts[i].Count != facetCounts[j].Count {
+					return facetCounts[i].Count > facetCounts[j].Count
+				}
+				return facetCounts[i].Value < facetCounts[j].Value
+			},
+		)
+		if len(facetCounts) > facetMaxValues {
+			facetCounts = facetCounts[:facetMaxValues]
+		}
+
+		facets[field] = facetCounts
+	}
+	return facets
+}
+
+// Records a highlighted "snippet" string property for each domain. Snippets are produced by the Indexer if it is a
+// Highlighter, otherwise a generic snippet is created from the description.
+func (a *Application) addSnippets(input []common.DomainProperties, query *query_parser.Query) {
+	idToSnippetMap := make(map[string]string)
+
+	highlighter, ok := (*a.indexer).(interfaces.Highlighter)
+	if ok {
+		ids := make([]string, 0, len(input))
+		for _, domainProperties := range input {
+			ids = append(ids, domainProperties.StringProperties["index_id"])
+		}
+
+		highlighterOutput, err := highlighter.Highlight(query, ids)
+		if err != nil {
+			// Design Note: We can still fall back to generic snippets.
+			zap.L().Warn(
+				"Unable to highlight.", zap.String("indexer_type", (*a.indexer).GetType()),
+				zap.String("err", err.Error()),
+			)
+		} else {
+			idToSnippetMap = highlighterOutput
+		}
+	}
+
+	highlightedTerms := strings.Join(query.GetTextClauses(), " ")
+	for _, domainProperties := range input {
+		snippetString, ok := idToSnippetMap[domainProperties.StringProperties["index_id"]]
+		if !ok {
+			snippetString = snippet.Generate(
+				domainProperties.StringProperties["description"], highlightedTerms, snippetMaxLength,
+			)
+		}
+		domainProperties.StringProperties["snippet"] = snippetString
+	}
+}
+
+func paginate(input []common.DomainProperties, from int, size int) []common.DomainProperties {
+	if from < 0 || size <= 0 || from >= len(input) {
+		return make([]common.DomainProperties, 0)
+	}
+
+	to := from + size
+	if to > len(input) {
+		to = len(input)
+	}
+
+	return input[from:to]
+}
+
+// Suggest returns up to limit query completions for the prefix. Only the last word of the prefix is completed.
+// It is safe to call Suggest concurrently.
+func (a *Application) Suggest(prefix string, limit int) []string {
+	suggestions := make([]string, 0)
+
+	suggester, ok := (*a.indexer).(interfaces.Suggester)
+	if !ok {
+		// Indexer doesn't support suggestions
+		return suggestions
+	}
+
+	<-a.availableQuerySlots                                // Acquire a query slot
+	defer func() { a.availableQuerySlots <- struct{}{} }() // Release the query slot
+
+	// Let's split the prefix into previous words & the last word to complete
+	words := strings.Fields(strings.ToLower(prefix))
+	if len(words) == 0 || unicode.IsSpace(rune(prefix[len(prefix)-1])) {
+		// There's no word to complete
+		return suggestions
+	}
+	lastWord := words[len(words)-1]
+	previousWords := strings.Join(words[:len(words)-1], " ")
+
+	completions, err := suggester.Suggest(lastWord, limit)
+	if err != nil {
+		zap.L().Warn(
+			"Unable to suggest.", zap.String("indexer_type", (*a.indexer).GetType()),
+			zap.String("err", err.Error()),
+		)
+		return suggestions
+	}
+
+	for _, completion := range completions {
+		if previousWords != "" {
+			completion = previousWords + " " + completion
+		}
+		suggestions = append(suggestions, completion)
+	}
+
+	return suggestions
+}
@@ -0,0 +1,267 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/metrics_manager"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+	"github.com/anthony-ozdemir/zfse/internal/task_handlers/indexers"
+	"github.com/anthony-ozdemir/zfse/internal/task_handlers/rankers"
+)
+
+func newTestTaskHandlerOptions(taskHandlerType string) config.TaskHandlerOptions {
+	return config.TaskHandlerOptions{
+		Type:          taskHandlerType,
+		StringOptions: make(map[string]string),
+		IntOptions:    make(map[string]int64),
+		FloatOptions:  make(map[string]float64),
+		BoolOptions:   make(map[string]bool),
+	}
+}
+
+// Creates an application which is ready to search, with a single "test" zone.
+func newTestApplication(t *testing.T, domainNames []string) *Application {
+	domainPropertiesArray := make([]common.DomainProperties, 0, len(domainNames))
+	for _, domainName := range domainNames {
+		domainProperties := common.NewDomainProperties()
+		domainProperties.DomainName = domainName
+		domainProperties.StringProperties["description"] = "Description of " + domainName
+		domainPropertiesArray = append(domainPropertiesArray, domainProperties)
+	}
+	return newTestApplicationWithDomainProperties(t, domainPropertiesArray)
+}
+
+// Creates an application which is ready to search, with the given domains in a single "test" zone.
+func newTestApplicationWithDomainProperties(
+	t *testing.T, domainPropertiesArray []common.DomainProperties,
+) *Application {
+	cacheFolderPath := t.TempDir()
+	path_manager.SetCacheFolderPath(cacheFolderPath)
+
+	a := &Application{
+		config:                  config.ApplicationConfig{},
+		applicationStateManager: NewApplicationStateManager(),
+		zoneFileRegistry:        map[string]string{"test": ""},
+		rankerWeightMap:         make(map[string]float64),
+	}
+	db, err := database.NewDatabase(filepath.Join(cacheFolderPath, "db"))
+	require.NoError(t, err)
+	a.db = db
+	t.Cleanup(
+		func() {
+			_ = db.Close()
+		},
+	)
+
+	userDataFolderPath := t.TempDir()
+	path_manager.SetUserDataFolderPath(userDataFolderPath)
+	userDB, err := database.NewDatabase(filepath.Join(userDataFolderPath, "db"))
+	require.NoError(t, err)
+	a.userDB = userDB
+	t.Cleanup(
+		func() {
+			_ = userDB.Close()
+		},
+	)
+
+	a.metricsManager = metrics_manager.New()
+	a.registerMetrics()
+
+	a.config.GeneralOptions.ConnectionProtocol = "https"
+	a.config.GeneralOptions.IndexerOutputLimit = 100
+	a.config.GeneralOptions.MaxConcurrentQueries = 4
+	a.initializeQuerySlots()
+
+	// Indexer
+	var indexer interfaces.Indexer = &indexers.RandomIndexer{}
+	err = indexer.Initialize(
+		newTestTaskHandlerOptions("builtin.random_indexer"), "", a.config.GeneralOptions.IndexerOutputLimit,
+	)
+	require.NoError(t, err)
+	a.indexer = &indexer
+
+	// Ranker
+	var ranker interfaces.Ranker = &rankers.IndexerRanker{}
+	err = ranker.Initialize(newTestTaskHandlerOptions("builtin.indexer_ranker"))
+	require.NoError(t, err)
+	a.rankerArray = append(a.rankerArray, &ranker)
+	a.rankerWeightMap[ranker.GetType()] = 1.0
+
+	// Post-crawl cache file & index
+	postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath("test")
+	require.NoError(t, os.MkdirAll(filepath.Dir(postCrawlCacheFile), 0700))
+
+	content := ""
+	for i, domainProperties := range domainPropertiesArray {
+		jsonString, err := domainProperties.ToJSONString()
+		require.NoError(t, err)
+		content += jsonString + "\n"
+
+		require.NoError(t, indexer.Index(createIndexID("test", i), domainProperties))
+	}
+	require.NoError(t, os.WriteFile(postCrawlCacheFile, []byte(content), 0600))
+
+	a.applicationStateManager.OnPreCrawlFiltersStarted()
+	a.applicationStateManager.OnPreCrawlFiltersFinished()
+	a.applicationStateManager.OnPostCrawlFiltersStarted()
+	a.applicationStateManager.OnPostCrawlFiltersFinished()
+	a.applicationStateManager.OnIndexingStarted()
+	a.applicationStateManager.OnReadyToSearch()
+
+	return a
+}
+
+func mustParseQuery(t *testing.T, userQuery string) *query_parser.Query {
+	query, err := query_parser.Parse(userQuery)
+	require.NoError(t, err)
+	return query
+}
+
+func mustSearch(t *testing.T, a *Application, query *query_parser.Query, from int, size int) SearchOutput {
+	output, err := a.Search(query, from, size)
+	require.NoError(t, err)
+	return output
+}
+
+func TestConcurrentSearch(t *testing.T) {
+	domainNames := []string{"a.com", "b.com", "c.com"}
+	a := newTestApplication(t, domainNames)
+
+	query := mustParseQuery(t, "example")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := a.Search(query, 0, 10)
+			assert.NoError(t, err)
+			assert.Len(t, output.Results, len(domainNames))
+			assert.Equal(t, uint64(len(domainNames)), output.TotalHits)
+		}()
+	}
+	wg.Wait()
+
+	// Queries should not alter the application state
+	assert.Equal(t, enum.ReadyToSearch, a.applicationStateManager.GetApplicationState().task)
+
+	// Queries should not write to the cache folder
+	_, err := os.Stat(filepath.Join(path_manager.GetCacheFolderPath(), "ranking"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSearchPagination(t *testing.T) {
+	domainNames := []string{"a.com", "b.com", "c.com", "d.com", "e.com"}
+	a := newTestApplication(t, domainNames)
+
+	firstPage := mustSearch(t, a, mustParseQuery(t, "example"), 0, 2)
+	assert.Len(t, firstPage.Results, 2)
+	assert.Equal(t, uint64(len(domainNames)), firstPage.TotalHits)
+	assert.Equal(t, len(domainNames), firstPage.AvailableHits)
+
+	lastPage := mustSearch(t, a, mustParseQuery(t, "example"), 4, 2)
+	assert.Len(t, lastPage.Results, 1)
+
+	emptyPage := mustSearch(t, a, mustParseQuery(t, "example"), 10, 2)
+	assert.Len(t, emptyPage.Results, 0)
+
+	// Pages should not overlap
+	secondPage := mustSearch(t, a, mustParseQuery(t, "example"), 2, 2)
+	for _, firstPageProperties := range firstPage.Results {
+		for _, secondPageProperties := range secondPage.Results {
+			assert.NotEqual(t, firstPageProperties.DomainName, secondPageProperties.DomainName)
+		}
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.dev", "c.dev"})
+
+	// Random indexer doesn't translate filters, application should verify them instead.
+	output := mustSearch(t, a, mustParseQuery(t, "example tld:dev"), 0, 10)
+	assert.Len(t, output.Results, 2)
+	assert.Equal(t, 2, output.AvailableHits)
+
+	output = mustSearch(t, a, mustParseQuery(t, "example -tld:dev"), 0, 10)
+	require.Len(t, output.Results, 1)
+	assert.Equal(t, "a.com", output.Results[0].DomainName)
+
+	// Zone name is encoded within the index ID
+	assert.Len(t, mustSearch(t, a, mustParseQuery(t, "example zone:test"), 0, 10).Results, 3)
+	assert.Len(t, mustSearch(t, a, mustParseQuery(t, "example zone:com"), 0, 10).Results, 0)
+}
+
+func newTestFacetDomainProperties(domainName string, nameserver string, language string) common.DomainProperties {
+	domainProperties := common.NewDomainProperties()
+	domainProperties.DomainName = domainName
+	domainProperties.StringProperties["description"] = "Description of " + domainName
+	domainProperties.StringProperties["record_type"] = "ns"
+	domainProperties.StringProperties["record_data"] = nameserver
+	domainProperties.StringProperties["lang"] = language
+	return domainProperties
+}
+
+func TestSearchFacets(t *testing.T) {
+	a := newTestApplicationWithDomainProperties(
+		t, []common.DomainProperties{
+			newTestFacetDomainProperties("a.dev", "jason.ns.cloudflare.com.", "en"),
+			newTestFacetDomainProperties("b.dev", "ns1.cloudflare.com.", "de"),
+			newTestFacetDomainProperties("c.dev", "ns-1.awsdns-00.org.", "en"),
+		},
+	)
+	a.config.GeneralOptions.FacetProperties = []string{"lang"}
+
+	output := mustSearch(t, a, mustParseQuery(t, "example"), 0, 1)
+	assert.Len(t, output.Results, 1)
+	// Facets should be counted over all available hits, not only the requested page
+	assert.Equal(t, []FacetCount{{Value: "test", Count: 3}}, output.Facets["zone"])
+	assert.Equal(
+		t, []FacetCount{{Value: "cloudflare.com", Count: 2}, {Value: "awsdns-00.org", Count: 1}}, output.Facets["ns"],
+	)
+	assert.Equal(t, []FacetCount{{Value: "en", Count: 2}, {Value: "de", Count: 1}}, output.Facets["lang"])
+
+	// Facet selections are applied as filters
+	query := mustParseQuery(t, "example")
+	query.AddFilter("ns", "cloudflare.com")
+	query.AddFilter("lang", "en")
+	output = mustSearch(t, a, query, 0, 10)
+	require.Len(t, output.Results, 1)
+	assert.Equal(t, "a.dev", output.Results[0].DomainName)
+	assert.Equal(t, []FacetCount{{Value: "en", Count: 1}}, output.Facets["lang"])
+}
+
+type testSuggesterIndexer struct {
+	indexers.RandomIndexer
+}
+
+func (i *testSuggesterIndexer) Suggest(prefix string, limit int) ([]string, error) {
+	return []string{prefix + "ing", prefix + "s"}, nil
+}
+
+func TestSuggest(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+
+	// Random indexer is not a suggester
+	assert.Len(t, a.Suggest("gam", 10), 0)
+
+	var indexer interfaces.Indexer = &testSuggesterIndexer{}
+	a.indexer = &indexer
+
+	assert.Equal(t, []string{"gaming", "gams"}, a.Suggest("Gam", 10))
+	// Only the last word should be completed
+	assert.Equal(t, []string{"mmorpg gaming", "mmorpg gams"}, a.Suggest("MMORPG gam", 10))
+	// There's no word to complete
+	assert.Len(t, a.Suggest("gam ", 10), 0)
+}
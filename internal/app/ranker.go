@@ -16,17 +16,24 @@ func (a *Application) runRankers(input []common.DomainProperties, userQuery stri
 
 	scoreList := make([]scoreItem, 0)
 
+	a.rankerWeightMutex.RLock()
+	rankerWeightMap := a.rankerWeightMap
+	a.rankerWeightMutex.RUnlock()
+
 	for _, domainProperties := range input {
 		outputScore := 0.0
 		for _, ranker := range a.rankerArray {
 			copyProperties := domainProperties
-			rankerNormalizedWeight := a.rankerWeightMap[(*ranker).GetType()]
+			rankerNormalizedWeight := rankerWeightMap[(*ranker).GetType()]
 			// rankerOutputScore := (*ranker).Input(&copyProperties, userQuery)
 			// Let's clamp the output score between 0.0 and 1.0
 
 			outputScore += (*ranker).Input(&copyProperties, userQuery) * rankerNormalizedWeight
 		}
 
+		// Let's also record the final ranker score
+		domainProperties.FloatProperties["ranker_score"] = outputScore
+
 		item := scoreItem{
 			DomainProperties: domainProperties,
 			Score:            outputScore,
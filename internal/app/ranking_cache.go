@@ -0,0 +1,137 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+)
+
+// Ranking cache is disabled unless a TTL is configured.
+func (a *Application) isRankingCacheEnabled() bool {
+	return a.config.GeneralOptions.RankingCacheTTLInSeconds > 0
+}
+
+// Returns a hash of the options affecting the ranked output, so that ranker changes (i.e. reloaded weights) never
+// serve rankings produced by the previous configuration.
+func (a *Application) getRankerConfigHash() (string, error) {
+	a.rankerWeightMutex.RLock()
+	rankerWeightMap := a.rankerWeightMap
+	a.rankerWeightMutex.RUnlock()
+
+	rankerConfig := struct {
+		IndexerOutputLimit int64                       `json:"indexer_output_limit"`
+		RankerOptions      []config.TaskHandlerOptions `json:"ranker_options"`
+		RankerWeights      map[string]float64          `json:"ranker_weights"`
+	}{
+		IndexerOutputLimit: a.config.GeneralOptions.IndexerOutputLimit,
+		RankerOptions:      a.config.RankerOptions,
+		RankerWeights:      rankerWeightMap,
+	}
+
+	// Design Note: JSON encoder sorts map keys, thus the output is deterministic.
+	jsonBytes, err := json.Marshal(rankerConfig)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(jsonBytes)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Returns the ranking cache key of the query, which is derived from the normalized query, index generation & ranker
+// config hash.
+// Design Note: Rankers receive the raw user query. Built-in rankers don't depend on the letter case or the clause
+// order of the query, thus normalized queries can share the same ranking.
+func (a *Application) getRankingCacheKey(query *query_parser.Query) (string, error) {
+	indexGeneration, err := a.db.GetIndexGeneration()
+	if err != nil {
+		return "", err
+	}
+
+	rankerConfigHash, err := a.getRankerConfigHash()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(
+		[]byte(query.Normalize() + "\x00" + strconv.FormatInt(indexGeneration, 10) + "\x00" + rankerConfigHash),
+	)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Returns the cached ranked output & total hits of the query, if any.
+func (a *Application) getCachedRanking(query *query_parser.Query) ([]common.DomainProperties, uint64, bool) {
+	if !a.isRankingCacheEnabled() {
+		return nil, 0, false
+	}
+
+	cacheKey, err := a.getRankingCacheKey(query)
+	if err != nil {
+		zap.L().Warn("Unable to create ranking cache key.", zap.String("err", err.Error()))
+		return nil, 0, false
+	}
+
+	entry, bIsFound, err := a.db.GetRankingCacheEntry(cacheKey)
+	if err != nil {
+		zap.L().Warn("Unable to read ranking cache.", zap.String("err", err.Error()))
+		return nil, 0, false
+	}
+
+	if !bIsFound {
+		a.metricsManager.IncCounter(metricRankingCacheMisses, 1)
+		return nil, 0, false
+	}
+
+	a.metricsManager.IncCounter(metricRankingCacheHits, 1)
+	return entry.Results, entry.TotalHits, true
+}
+
+// Caches the ranked output & total hits of the query. Cache errors only affect performance, thus they are logged
+// rather than returned.
+func (a *Application) cacheRanking(
+	query *query_parser.Query, rankerOutput []common.DomainProperties, totalHits uint64,
+) {
+	if !a.isRankingCacheEnabled() {
+		return
+	}
+
+	cacheKey, err := a.getRankingCacheKey(query)
+	if err != nil {
+		zap.L().Warn("Unable to create ranking cache key.", zap.String("err", err.Error()))
+		return
+	}
+
+	err = a.db.SaveRankingCacheEntry(
+		cacheKey,
+		database.RankingCacheEntry{TotalHits: totalHits, Results: rankerOutput},
+		time.Duration(a.config.GeneralOptions.RankingCacheTTLInSeconds)*time.Second,
+		a.config.GeneralOptions.RankingCacheMaxEntries,
+	)
+	if err != nil {
+		zap.L().Warn("Unable to save ranking cache.", zap.String("err", err.Error()))
+	}
+}
+
+// Invalidates all cached rankings. Needs to be called whenever the index changes.
+func (a *Application) invalidateRankingCache() {
+	// Design Note: Index generation is a part of the cache keys, thus incrementing it is enough to invalidate the
+	// cache. Purging only reclaims the disk space.
+	_, err := a.db.IncrementIndexGeneration()
+	if err != nil {
+		zap.L().Fatal("Unable to increment index generation.", zap.String("err", err.Error()))
+	}
+
+	err = a.db.PurgeRankingCache()
+	if err != nil {
+		zap.L().Warn("Unable to purge ranking cache.", zap.String("err", err.Error()))
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+)
+
+func TestSearchRankingCache(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com", "b.com", "c.com"})
+	a.config.GeneralOptions.RankingCacheTTLInSeconds = 60
+	a.config.GeneralOptions.RankingCacheMaxEntries = 10
+
+	firstOutput := mustSearch(t, a, mustParseQuery(t, "example"), 0, 10)
+	assert.Equal(t, int64(0), a.metricsManager.GetCounterCount(metricRankingCacheHits))
+	assert.Equal(t, int64(1), a.metricsManager.GetCounterCount(metricRankingCacheMisses))
+
+	// Cached rankings should be served without querying the indexer
+	var indexer interfaces.Indexer = &testErrorIndexer{}
+	a.indexer = &indexer
+
+	// Normalized queries should share the same ranking
+	cachedOutput := mustSearch(t, a, mustParseQuery(t, "EXAMPLE"), 0, 10)
+	assert.Equal(t, int64(1), a.metricsManager.GetCounterCount(metricRankingCacheHits))
+	assert.Equal(t, firstOutput.TotalHits, cachedOutput.TotalHits)
+	require.Len(t, cachedOutput.Results, len(firstOutput.Results))
+	for i := range firstOutput.Results {
+		assert.Equal(t, firstOutput.Results[i].DomainName, cachedOutput.Results[i].DomainName)
+		// Property types should be kept intact
+		assert.Equal(t, firstOutput.Results[i].FloatProperties, cachedOutput.Results[i].FloatProperties)
+	}
+
+	// Pagination & facets should work on cached rankings
+	pagedOutput := mustSearch(t, a, mustParseQuery(t, "example"), 1, 1)
+	require.Len(t, pagedOutput.Results, 1)
+	assert.Equal(t, firstOutput.Results[1].DomainName, pagedOutput.Results[0].DomainName)
+	assert.Equal(t, []FacetCount{{Value: "test", Count: 3}}, pagedOutput.Facets["zone"])
+
+	// Ranker changes shouldn't be served from the cache
+	a.rankerWeightMutex.Lock()
+	a.rankerWeightMap = map[string]float64{"builtin.indexer_ranker": 0.5}
+	a.rankerWeightMutex.Unlock()
+	_, err := a.Search(mustParseQuery(t, "example"), 0, 10)
+	assert.Error(t, err)
+
+	// Finished indexing should invalidate the cache
+	a.rankerWeightMutex.Lock()
+	a.rankerWeightMap = map[string]float64{"builtin.indexer_ranker": 1.0}
+	a.rankerWeightMutex.Unlock()
+	a.invalidateRankingCache()
+	_, err = a.Search(mustParseQuery(t, "example"), 0, 10)
+	assert.Error(t, err)
+}
+
+func TestRankingCacheEviction(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.RankingCacheTTLInSeconds = 60
+	a.config.GeneralOptions.RankingCacheMaxEntries = 2
+
+	for _, userQuery := range []string{"first", "second", "third"} {
+		mustSearch(t, a, mustParseQuery(t, userQuery), 0, 10)
+	}
+
+	_, _, bIsCached := a.getCachedRanking(mustParseQuery(t, "first"))
+	assert.False(t, bIsCached)
+	_, _, bIsCached = a.getCachedRanking(mustParseQuery(t, "third"))
+	assert.True(t, bIsCached)
+}
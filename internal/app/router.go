@@ -5,19 +5,107 @@ import (
 )
 
 const (
+	apiPathPrefix      = "/v1/"
+	apiPathStatusGet   = "/v1/status"
+	apiPathEvents      = "/v1/events"
+	apiPathOpenAPI     = "/v1/openapi.json"
 	apiPathRankerQuery = "/v1/ranker/query"
+	apiPathSearch      = "/v1/search"
+	apiPathSuggest     = "/v1/suggest"
+	apiPathClick       = "/v1/click"
+)
+
+const (
+	apiPathAdminPipelinePause  = "/v1/admin/pipeline/pause"
+	apiPathAdminPipelineResume = "/v1/admin/pipeline/resume"
+	apiPathAdminZoneRerun      = "/v1/admin/zones/rerun"
+	apiPathAdminZonePurge      = "/v1/admin/zones/purge"
+	apiPathAdminRankersReload  = "/v1/admin/rankers/reload"
+	apiPathAdminAnalytics      = "/v1/admin/analytics"
+	apiPathAdminSavedSearches  = "/v1/admin/saved_searches"
+)
+
+const (
+	metricsPath = "/metrics"
+)
+
+const (
+	webPathIndex      = "/"
+	webPathStatic     = "/static/"
+	webPathOpenSearch = "/opensearch.xml"
 )
 
 func (a *Application) getRouter() *http.ServeMux {
 
+	// Requests are validated against the OpenAPI document
+	a.initializeAPISpec()
+
 	// Create and configure routes
 	serveMux := http.NewServeMux()
 
+	// Unknown API paths
+	{
+		serveMux.Handle(apiPathPrefix, a.getCommonWrapperHandler(a.apiNotFoundHandler()))
+	}
+
 	// Status API
 	{
-		// serveMux.Handle(apiPathStatusGet, a.getCommonWrapperHandler(a.statusGetHandler()))
+		serveMux.Handle(apiPathStatusGet, a.getCommonWrapperHandler(a.statusGetHandler()))
+		serveMux.Handle(apiPathEvents, a.getCommonWrapperHandler(a.eventsGetHandler()))
+		serveMux.Handle(apiPathOpenAPI, a.getCommonWrapperHandler(a.openAPIGetHandler()))
 		serveMux.Handle(apiPathRankerQuery, a.getCommonWrapperHandler(a.rankerQueryHandler()))
 	}
 
+	// Search API
+	{
+		serveMux.Handle(apiPathSearch, a.getCommonWrapperHandler(a.searchGetHandler()))
+		serveMux.Handle(apiPathSuggest, a.getCommonWrapperHandler(a.suggestGetHandler()))
+		serveMux.Handle(apiPathClick, a.getCommonWrapperHandler(a.clickGetHandler()))
+	}
+
+	// Admin API
+	{
+		serveMux.Handle(
+			apiPathAdminPipelinePause,
+			a.getCommonWrapperHandler(a.getAdminAuthHandler(a.adminPipelineHandler(true)).ServeHTTP),
+		)
+		serveMux.Handle(
+			apiPathAdminPipelineResume,
+			a.getCommonWrapperHandler(a.getAdminAuthHandler(a.adminPipelineHandler(false)).ServeHTTP),
+		)
+		serveMux.Handle(
+			apiPathAdminZoneRerun,
+			a.getCommonWrapperHandler(a.getAdminAuthHandler(a.adminZoneHandler(a.rerunZone)).ServeHTTP),
+		)
+		serveMux.Handle(
+			apiPathAdminZonePurge,
+			a.getCommonWrapperHandler(a.getAdminAuthHandler(a.adminZoneHandler(a.purgeZone)).ServeHTTP),
+		)
+		serveMux.Handle(
+			apiPathAdminRankersReload,
+			a.getCommonWrapperHandler(a.getAdminAuthHandler(a.adminRankersReloadHandler()).ServeHTTP),
+		)
+		serveMux.Handle(
+			apiPathAdminAnalytics,
+			a.getCommonWrapperHandler(a.getAdminAuthHandler(a.adminAnalyticsHandler()).ServeHTTP),
+		)
+		serveMux.Handle(
+			apiPathAdminSavedSearches,
+			a.getCommonWrapperHandler(a.getAdminAuthHandler(a.adminSavedSearchesHandler()).ServeHTTP),
+		)
+	}
+
+	// Metrics
+	{
+		serveMux.Handle(metricsPath, a.getCommonWrapperHandler(a.metricsGetHandler()))
+	}
+
+	// Web UI
+	{
+		serveMux.Handle(webPathIndex, a.getCommonWrapperHandler(a.webUIIndexHandler()))
+		serveMux.Handle(webPathStatic, a.getCommonWrapperHandler(a.webUIStaticHandler().ServeHTTP))
+		serveMux.Handle(webPathOpenSearch, a.getCommonWrapperHandler(a.openSearchDescriptionHandler()))
+	}
+
 	return serveMux
 }
@@ -0,0 +1,593 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
+)
+
+const (
+	maxSavedSearches = 1000
+	// Number of newly indexed IDs to match at once
+	savedSearchMatchBatchSize = 1000
+	// Maximum number of domains within a single alert
+	savedSearchAlertBatchSize = 100
+	savedSearchWebhookTimeout = 10 * time.Second
+	// Maximum number of alerts waiting for webhook delivery
+	savedSearchAlertQueueSize = 1024
+)
+
+type savedSearchAlertDelivery struct {
+	savedSearch database.SavedSearch
+	jsonBytes   []byte
+}
+
+// Parses the saved search query along with its facet selections.
+func parseSavedSearchQuery(savedSearch database.SavedSearch) (*query_parser.Query, error) {
+	query, err := query_parser.Parse(savedSearch.Query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	for _, facet := range savedSearch.Facets {
+		field, value, found := strings.Cut(facet, ":")
+		if !found || strings.TrimSpace(field) == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("invalid facet %q", facet)
+		}
+		query.AddFilter(field, value)
+	}
+
+	return query, nil
+}
+
+func validateWebhookURL(webhookURL string) error {
+	if webhookURL == "" {
+		return nil
+	}
+
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	return nil
+}
+
+func newSavedSearchID() (string, error) {
+	idBytes := make([]byte, 16)
+	_, err := rand.Read(idBytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+// Returns the IDs among the given ones, which match the query of the saved search.
+// Design Note: Indexers which can't match within the given IDs are queried up to the output limit instead, thus
+// matches beyond the limit are missed.
+func (a *Application) matchSavedSearch(query *query_parser.Query, ids []string) ([]string, error) {
+	if matcher, ok := (*a.indexer).(interfaces.Matcher); ok {
+		return matcher.Match(query, ids)
+	}
+
+	output, _, err := (*a.indexer).Query(query, 0, int(a.config.GeneralOptions.IndexerOutputLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	matchingIDs := make([]string, 0)
+	for _, id := range ids {
+		if _, ok := output[id]; ok {
+			matchingIDs = append(matchingIDs, id)
+		}
+	}
+	return matchingIDs, nil
+}
+
+// Matching domains of a saved search, collected while the newly indexed lines are evaluated.
+type savedSearchEvaluation struct {
+	savedSearch     database.SavedSearch
+	query           *query_parser.Query
+	matchingDomains []SavedSearchAlertDomainJSON
+	err             error
+}
+
+// Evaluates the saved searches against the lines of the zone, which are indexed since the last evaluation. Saved
+// search errors don't affect the pipeline, thus they are logged rather than returned.
+func (a *Application) evaluateSavedSearches(zoneName string, totalLines int) {
+	startLineIndex, err := a.db.GetSavedSearchZoneLineIndex(zoneName)
+	if err != nil {
+		zap.L().Error("Unable to read saved search state.", zap.String("err", err.Error()))
+		return
+	}
+	if startLineIndex >= totalLines {
+		return
+	}
+
+	savedSearches, err := a.userDB.GetSavedSearches()
+	if err != nil {
+		zap.L().Error("Unable to read saved searches.", zap.String("err", err.Error()))
+		return
+	}
+
+	evaluations := make([]*savedSearchEvaluation, 0, len(savedSearches))
+	for _, savedSearch := range savedSearches {
+		query, err := parseSavedSearchQuery(savedSearch)
+		evaluations = append(
+			evaluations, &savedSearchEvaluation{
+				savedSearch:     savedSearch,
+				query:           query,
+				matchingDomains: make([]SavedSearchAlertDomainJSON, 0),
+				err:             err,
+			},
+		)
+	}
+
+	// Nothing is alerted unless all lines could be read, thus the lines will be evaluated again on the next run
+	err = a.matchSavedSearches(zoneName, startLineIndex, totalLines, evaluations)
+	if err != nil {
+		zap.L().Error(
+			"Unable to evaluate saved searches.", zap.String("zone_name", zoneName), zap.String("err", err.Error()),
+		)
+		return
+	}
+
+	for _, evaluation := range evaluations {
+		err = evaluation.err
+		if err == nil {
+			err = a.alertSavedSearch(evaluation.savedSearch, zoneName, evaluation.matchingDomains)
+		}
+		if err != nil {
+			zap.L().Error(
+				"Unable to evaluate saved search.", zap.String("saved_search_id", evaluation.savedSearch.ID),
+				zap.String("zone_name", zoneName), zap.String("err", err.Error()),
+			)
+		}
+	}
+
+	// Design Note: Saved searches are evaluated once per newly indexed line, even if some of them failed. Otherwise,
+	// the other saved searches would alert the same domains again.
+	err = a.db.SaveSavedSearchZoneLineIndex(zoneName, totalLines)
+	if err != nil {
+		zap.L().Error("Unable to save saved search state.", zap.String("err", err.Error()))
+	}
+}
+
+// Matches the saved searches against the lines of the zone in batches. Post-crawl cache file is read once, each batch
+// of lines is shared by all saved searches. Match errors only fail the saved search, file errors are returned.
+func (a *Application) matchSavedSearches(
+	zoneName string, startLineIndex int, endLineIndex int, evaluations []*savedSearchEvaluation,
+) error {
+	postCrawlCacheFile := path_manager.GetPostCrawlFilterOutputFilePath(zoneName)
+	file, err := os.Open(postCrawlCacheFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	readLine := func(lineIndex int) (string, error) {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to read line %d of %s: %w", lineIndex, postCrawlCacheFile, err)
+		}
+		return line, nil
+	}
+
+	for lineIndex := 0; lineIndex < startLineIndex; lineIndex++ {
+		_, err = readLine(lineIndex)
+		if err != nil {
+			return err
+		}
+	}
+
+	lines := make([]string, 0, savedSearchMatchBatchSize)
+	for batchStart := startLineIndex; batchStart < endLineIndex; batchStart += savedSearchMatchBatchSize {
+		batchEnd := batchStart + savedSearchMatchBatchSize
+		if batchEnd > endLineIndex {
+			batchEnd = endLineIndex
+		}
+
+		lines = lines[:0]
+		ids := make([]string, 0, batchEnd-batchStart)
+		for lineIndex := batchStart; lineIndex < batchEnd; lineIndex++ {
+			line, err := readLine(lineIndex)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, line)
+			ids = append(ids, createIndexID(zoneName, lineIndex))
+		}
+
+		for _, evaluation := range evaluations {
+			if evaluation.err != nil {
+				continue
+			}
+			evaluation.err = a.matchSavedSearchBatch(evaluation, zoneName, batchStart, lines, ids)
+		}
+	}
+	return nil
+}
+
+func (a *Application) matchSavedSearchBatch(
+	evaluation *savedSearchEvaluation, zoneName string, batchStart int, lines []string, ids []string,
+) error {
+	matchingIDs, err := a.matchSavedSearch(evaluation.query, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range matchingIDs {
+		_, lineIndex, err := parseIndexID(id)
+		if err != nil || lineIndex < batchStart || lineIndex >= batchStart+len(lines) {
+			return fmt.Errorf("unexpected index ID %s", id)
+		}
+
+		domainProperties, err := parseIndexedDomainProperties(zoneName, lines[lineIndex-batchStart])
+		if err != nil {
+			return fmt.Errorf("unable to parse line %d of zone %s: %w", lineIndex, zoneName, err)
+		}
+
+		// Indexers are not required to translate all filters, see queryIndexer
+		if !evaluation.query.MatchFilters(domainProperties.DomainName, domainProperties.StringProperties) {
+			continue
+		}
+
+		evaluation.matchingDomains = append(
+			evaluation.matchingDomains, SavedSearchAlertDomainJSON{
+				IndexID:    id,
+				DomainName: domainProperties.DomainName,
+				URL:        a.getDomainURL(domainProperties.DomainName),
+			},
+		)
+	}
+	return nil
+}
+
+// Alerts the matching domains which weren't alerted for the saved search before.
+func (a *Application) alertSavedSearch(
+	savedSearch database.SavedSearch, zoneName string, matchingDomains []SavedSearchAlertDomainJSON,
+) error {
+	if len(matchingDomains) == 0 {
+		return nil
+	}
+
+	domainNames := make([]string, 0, len(matchingDomains))
+	for _, domain := range matchingDomains {
+		domainNames = append(domainNames, domain.DomainName)
+	}
+	newDomainNames, err := a.userDB.MarkSavedSearchAlerted(savedSearch.ID, domainNames)
+	if err != nil {
+		return err
+	}
+	bIsNewDomain := make(map[string]bool)
+	for _, domainName := range newDomainNames {
+		bIsNewDomain[domainName] = true
+	}
+
+	newDomains := make([]SavedSearchAlertDomainJSON, 0, len(newDomainNames))
+	for _, domain := range matchingDomains {
+		if bIsNewDomain[domain.DomainName] {
+			newDomains = append(newDomains, domain)
+			// Duplicate lines of the same domain are alerted once
+			bIsNewDomain[domain.DomainName] = false
+		}
+	}
+
+	for batchStart := 0; batchStart < len(newDomains); batchStart += savedSearchAlertBatchSize {
+		batchEnd := batchStart + savedSearchAlertBatchSize
+		if batchEnd > len(newDomains) {
+			batchEnd = len(newDomains)
+		}
+
+		a.queueSavedSearchAlert(
+			savedSearch, SavedSearchAlertJSON{
+				SavedSearchID: savedSearch.ID,
+				Name:          savedSearch.Name,
+				Query:         savedSearch.Query,
+				Facets:        savedSearch.Facets,
+				ZoneName:      zoneName,
+				CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+				Domains:       newDomains[batchStart:batchEnd],
+			},
+		)
+	}
+
+	zap.L().Info(
+		"Saved search matched new domains.", zap.String("saved_search_id", savedSearch.ID),
+		zap.String("zone_name", zoneName), zap.Int("domains", len(newDomains)),
+	)
+	return nil
+}
+
+// Queues the alert for delivery. Alerts without a webhook are appended to the alerts file right away.
+// Design Note: Webhooks are posted by a single worker, so that slow webhooks don't delay the pipeline (i.e. indexer
+// waits for saved searches before it is ready to search). Alerts which don't fit into the queue are appended to the
+// alerts file instead, so that they are never lost.
+func (a *Application) queueSavedSearchAlert(savedSearch database.SavedSearch, alert SavedSearchAlertJSON) {
+	jsonBytes, err := json.Marshal(alert)
+	if err != nil {
+		zap.L().Error("Json Marshal error.", zap.String("err", err.Error()))
+		return
+	}
+
+	if savedSearch.WebhookURL == "" {
+		a.appendSavedSearchAlert(jsonBytes)
+		return
+	}
+
+	a.savedSearchAlertOnce.Do(
+		func() {
+			a.savedSearchAlertQueue = make(chan savedSearchAlertDelivery, savedSearchAlertQueueSize)
+			go a.runSavedSearchAlertWorker()
+		},
+	)
+
+	a.pendingSavedSearchAlerts.Add(1)
+	select {
+	case a.savedSearchAlertQueue <- savedSearchAlertDelivery{savedSearch: savedSearch, jsonBytes: jsonBytes}:
+	default:
+		a.pendingSavedSearchAlerts.Done()
+		zap.L().Warn(
+			"Saved search alert queue is full, appending to the alerts file.",
+			zap.String("saved_search_id", savedSearch.ID),
+		)
+		a.appendSavedSearchAlert(jsonBytes)
+	}
+}
+
+func (a *Application) runSavedSearchAlertWorker() {
+	for delivery := range a.savedSearchAlertQueue {
+		a.deliverSavedSearchAlert(delivery.savedSearch, delivery.jsonBytes)
+		a.pendingSavedSearchAlerts.Done()
+	}
+}
+
+// Delivers the alert to the webhook of the saved search, or appends it to the alerts file.
+// Design Note: Alerts failed to be delivered to the webhook are appended to the alerts file, so that they are never
+// lost. Webhooks aren't posted once the application is shutting down, so that shutdown isn't delayed by them.
+func (a *Application) deliverSavedSearchAlert(savedSearch database.SavedSearch, jsonBytes []byte) {
+	if a.applicationStateManager.GetApplicationState().task != enum.Shutdown {
+		err := postSavedSearchWebhook(savedSearch.WebhookURL, jsonBytes)
+		if err == nil {
+			return
+		}
+		zap.L().Warn(
+			"Unable to deliver saved search alert to webhook, appending to the alerts file.",
+			zap.String("saved_search_id", savedSearch.ID), zap.String("err", err.Error()),
+		)
+	}
+
+	a.appendSavedSearchAlert(jsonBytes)
+}
+
+func postSavedSearchWebhook(webhookURL string, jsonBytes []byte) error {
+	client := http.Client{Timeout: savedSearchWebhookTimeout}
+	response, err := client.Post(webhookURL, "application/json", bytes.NewReader(jsonBytes))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook replied with status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+// Appends the alert to the alerts file. Errors are logged, as alerts are delivered outside of the pipeline.
+func (a *Application) appendSavedSearchAlert(jsonBytes []byte) {
+	// Alerts are appended by both the pipeline & the delivery worker
+	a.savedSearchAlertFileMutex.Lock()
+	defer a.savedSearchAlertFileMutex.Unlock()
+
+	err := appendSavedSearchAlert(jsonBytes)
+	if err != nil {
+		zap.L().Error("Unable to append saved search alert.", zap.String("err", err.Error()))
+	}
+}
+
+func appendSavedSearchAlert(jsonBytes []byte) error {
+	alertsFilePath := path_manager.GetSavedSearchAlertsFilePath()
+	err := os.MkdirAll(filepath.Dir(alertsFilePath), 0700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(alertsFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(jsonBytes, '\n'))
+	return err
+}
+
+// API Methods
+type SavedSearchAlertDomainJSON struct {
+	IndexID    string `json:"index_id"`
+	DomainName string `json:"domain_name"`
+	URL        string `json:"url"`
+}
+
+type SavedSearchAlertJSON struct {
+	SavedSearchID string                       `json:"saved_search_id"`
+	Name          string                       `json:"name"`
+	Query         string                       `json:"query"`
+	Facets        []string                     `json:"facets"`
+	ZoneName      string                       `json:"zone_name"`
+	CreatedAt     string                       `json:"created_at"`
+	Domains       []SavedSearchAlertDomainJSON `json:"domains"`
+}
+
+type SavedSearchJSON struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Query      string   `json:"query"`
+	Facets     []string `json:"facets"`
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type AdminSavedSearchReqJSON struct {
+	Name       string   `json:"name"`
+	Query      *string  `json:"query"` // pointer so we can test for field absence
+	Facets     []string `json:"facets"`
+	WebhookURL string   `json:"webhook_url"`
+}
+
+type AdminSavedSearchRepJSON struct {
+	Success     bool             `json:"success"`
+	Error       string           `json:"error,omitempty"`
+	SavedSearch *SavedSearchJSON `json:"saved_search,omitempty"`
+}
+
+type AdminSavedSearchesRepJSON struct {
+	Success       bool              `json:"success"`
+	Error         string            `json:"error,omitempty"`
+	SavedSearches []SavedSearchJSON `json:"saved_searches"`
+}
+
+func newSavedSearchJSON(savedSearch database.SavedSearch) SavedSearchJSON {
+	facets := savedSearch.Facets
+	if facets == nil {
+		facets = make([]string, 0)
+	}
+
+	return SavedSearchJSON{
+		ID:         savedSearch.ID,
+		Name:       savedSearch.Name,
+		Query:      savedSearch.Query,
+		Facets:     facets,
+		WebhookURL: savedSearch.WebhookURL,
+		CreatedAt:  savedSearch.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// Lists (GET), creates (POST) & deletes (DELETE) saved searches.
+// Design Note: Saved searches are managed by the operator on behalf of the users, as there are no user accounts to
+// scope them to. Webhooks are posted by the server, thus letting anonymous clients create saved searches would allow
+// them to make the server send requests to arbitrary URLs & to exhaust the saved search limit of everyone else.
+func (a *Application) adminSavedSearchesHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			savedSearches, err := a.userDB.GetSavedSearches()
+			if err != nil {
+				zap.L().Error("Unable to read saved searches.", zap.String("err", err.Error()))
+				a.helperSendJSONError(&w, errInternalServerError)
+				return
+			}
+
+			jsonRep := AdminSavedSearchesRepJSON{
+				Success:       true,
+				SavedSearches: make([]SavedSearchJSON, 0, len(savedSearches)),
+			}
+			for _, savedSearch := range savedSearches {
+				jsonRep.SavedSearches = append(jsonRep.SavedSearches, newSavedSearchJSON(savedSearch))
+			}
+			a.helperSendJSONSuccess(&w, jsonRep)
+		case http.MethodPost:
+			a.createSavedSearch(w, r)
+		case http.MethodDelete:
+			id := r.URL.Query().Get("id")
+			if id == "" {
+				a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "id parameter missing"))
+				return
+			}
+
+			bIsFound, err := a.userDB.DeleteSavedSearch(id)
+			if err != nil {
+				zap.L().Error("Unable to delete saved search.", zap.String("err", err.Error()))
+				a.helperSendJSONError(&w, errInternalServerError)
+				return
+			}
+			if !bIsFound {
+				a.helperSendJSONError(&w, newAPIError(http.StatusNotFound, "unknown saved search"))
+				return
+			}
+
+			a.helperSendJSONSuccess(&w, AdminSavedSearchRepJSON{Success: true})
+		default:
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+		}
+	}
+}
+
+func (a *Application) createSavedSearch(w http.ResponseWriter, r *http.Request) {
+	// Try decode Json request
+	decoder := json.NewDecoder(r.Body)
+	jsonReq := AdminSavedSearchReqJSON{}
+
+	errJsonDecode := decoder.Decode(&jsonReq)
+	if errJsonDecode != nil {
+		a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "json decode error"))
+		return
+	}
+
+	// Check existence of mandatory fields on Json request
+	if jsonReq.Query == nil {
+		a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, "json field missing"))
+		return
+	}
+
+	savedSearch := database.SavedSearch{
+		Name:       jsonReq.Name,
+		Query:      strings.TrimSpace(*jsonReq.Query),
+		Facets:     jsonReq.Facets,
+		WebhookURL: jsonReq.WebhookURL,
+		CreatedAt:  time.Now(),
+	}
+
+	_, err := parseSavedSearchQuery(savedSearch)
+	if err == nil {
+		err = validateWebhookURL(savedSearch.WebhookURL)
+	}
+	if err != nil {
+		a.helperSendJSONError(&w, newAPIError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	savedSearches, err := a.userDB.GetSavedSearches()
+	if err == nil && len(savedSearches) >= maxSavedSearches {
+		a.helperSendJSONError(&w, newAPIError(http.StatusConflict, "too many saved searches"))
+		return
+	}
+	if err == nil {
+		savedSearch.ID, err = newSavedSearchID()
+	}
+	if err == nil {
+		err = a.userDB.SaveSavedSearch(savedSearch)
+	}
+	if err != nil {
+		zap.L().Error("Unable to save saved search.", zap.String("err", err.Error()))
+		a.helperSendJSONError(&w, errInternalServerError)
+		return
+	}
+
+	zap.L().Info("Created saved search.", zap.String("saved_search_id", savedSearch.ID))
+
+	savedSearchJSON := newSavedSearchJSON(savedSearch)
+	a.helperSendJSONSuccess(&w, AdminSavedSearchRepJSON{Success: true, SavedSearch: &savedSearchJSON})
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+)
+
+func readSavedSearchAlerts(t *testing.T) []SavedSearchAlertJSON {
+	alerts := make([]SavedSearchAlertJSON, 0)
+
+	file, err := os.Open(path_manager.GetSavedSearchAlertsFilePath())
+	if os.IsNotExist(err) {
+		return alerts
+	}
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		alert := SavedSearchAlertJSON{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &alert))
+		alerts = append(alerts, alert)
+	}
+	require.NoError(t, scanner.Err())
+	return alerts
+}
+
+func getAlertedDomainNames(alert SavedSearchAlertJSON) []string {
+	domainNames := make([]string, 0, len(alert.Domains))
+	for _, domain := range alert.Domains {
+		domainNames = append(domainNames, domain.DomainName)
+	}
+	return domainNames
+}
+
+func TestAdminSavedSearchesHandler(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	a.config.GeneralOptions.AdminAPIKeys = []string{testAdminAPIKey}
+	router := a.getRouter()
+
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	recorder := serve(
+		newTestAdminRequest(
+			http.MethodPost, apiPathAdminSavedSearches,
+			`{"name": "Game studios", "query": "game studio", "facets": ["tld:dev"]}`,
+		),
+	)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	createRep := AdminSavedSearchRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &createRep))
+	require.NotNil(t, createRep.SavedSearch)
+	assert.NotEmpty(t, createRep.SavedSearch.ID)
+	assert.Equal(t, "game studio", createRep.SavedSearch.Query)
+	assert.Equal(t, []string{"tld:dev"}, createRep.SavedSearch.Facets)
+
+	recorder = serve(newTestAdminRequest(http.MethodGet, apiPathAdminSavedSearches, ""))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	listRep := AdminSavedSearchesRepJSON{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &listRep))
+	require.Len(t, listRep.SavedSearches, 1)
+	assert.Equal(t, *createRep.SavedSearch, listRep.SavedSearches[0])
+
+	// Invalid saved searches
+	for _, body := range []string{
+		`{"name": "No query"}`,
+		`{"query": "game", "facets": ["dev"]}`,
+		`{"query": "game", "webhook_url": "ftp://example.com"}`,
+		`{"query": "game", "webhook_url": "example.com"}`,
+	} {
+		recorder = serve(newTestAdminRequest(http.MethodPost, apiPathAdminSavedSearches, body))
+		requireJSONError(t, recorder, http.StatusBadRequest)
+	}
+
+	recorder = serve(
+		newTestAdminRequest(http.MethodDelete, apiPathAdminSavedSearches+"?id="+createRep.SavedSearch.ID, ""),
+	)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireJSONError(
+		t, serve(newTestAdminRequest(http.MethodDelete, apiPathAdminSavedSearches+"?id="+createRep.SavedSearch.ID, "")),
+		http.StatusNotFound,
+	)
+
+	savedSearches, err := a.userDB.GetSavedSearches()
+	require.NoError(t, err)
+	assert.Len(t, savedSearches, 0)
+
+	requireJSONError(
+		t, serve(newTestAdminRequest(http.MethodPut, apiPathAdminSavedSearches, "")), http.StatusMethodNotAllowed,
+	)
+}
+
+func TestEvaluateSavedSearches(t *testing.T) {
+	a := newTestApplication(t, []string{"a.dev", "b.com", "c.dev", "a.dev"})
+	require.NoError(
+		t, a.userDB.SaveSavedSearch(
+			database.SavedSearch{ID: "dev", Name: "Dev", Query: "game", Facets: []string{"tld:dev"}},
+		),
+	)
+	require.NoError(t, a.userDB.SaveSavedSearch(database.SavedSearch{ID: "com", Query: "game tld:com"}))
+
+	a.evaluateSavedSearches("test", 3)
+
+	alerts := readSavedSearchAlerts(t)
+	require.Len(t, alerts, 2)
+	for _, alert := range alerts {
+		assert.Equal(t, "test", alert.ZoneName)
+		switch alert.SavedSearchID {
+		case "dev":
+			assert.Equal(t, []string{"a.dev", "c.dev"}, getAlertedDomainNames(alert))
+			assert.Equal(t, "https://a.dev", alert.Domains[0].URL)
+			assert.Equal(t, "test_0", alert.Domains[0].IndexID)
+		case "com":
+			assert.Equal(t, []string{"b.com"}, getAlertedDomainNames(alert))
+		default:
+			assert.Fail(t, "unexpected saved search", alert.SavedSearchID)
+		}
+	}
+
+	// Lines are evaluated once, and domains are alerted once per saved search
+	a.evaluateSavedSearches("test", 3)
+	a.evaluateSavedSearches("test", 4)
+	assert.Len(t, readSavedSearchAlerts(t), 2)
+
+	lineIndex, err := a.db.GetSavedSearchZoneLineIndex("test")
+	require.NoError(t, err)
+	assert.Equal(t, 4, lineIndex)
+
+	// Purging the zone resets the evaluated lines, yet the same domains aren't alerted again
+	require.NoError(t, a.db.DeleteZoneTaskStates("test"))
+	a.evaluateSavedSearches("test", 4)
+	assert.Len(t, readSavedSearchAlerts(t), 2)
+}
+
+func TestSavedSearchWebhook(t *testing.T) {
+	a := newTestApplication(t, []string{"a.dev", "b.dev"})
+
+	webhookAlerts := make(chan SavedSearchAlertJSON, 1)
+	releaseWebhook := make(chan struct{})
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				alert := SavedSearchAlertJSON{}
+				err := json.NewDecoder(r.Body).Decode(&alert)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				webhookAlerts <- alert
+				<-releaseWebhook
+			},
+		),
+	)
+	defer server.Close()
+
+	failingServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		),
+	)
+	defer failingServer.Close()
+
+	require.NoError(t, a.userDB.SaveSavedSearch(database.SavedSearch{ID: "ok", Query: "game", WebhookURL: server.URL}))
+	require.NoError(
+		t, a.userDB.SaveSavedSearch(database.SavedSearch{ID: "failing", Query: "game", WebhookURL: failingServer.URL}),
+	)
+
+	// Webhooks are posted in the background, thus a slow webhook doesn't block the evaluation
+	a.evaluateSavedSearches("test", 2)
+
+	alert := <-webhookAlerts
+	assert.Equal(t, "ok", alert.SavedSearchID)
+	assert.Equal(t, []string{"a.dev", "b.dev"}, getAlertedDomainNames(alert))
+	close(releaseWebhook)
+	a.pendingSavedSearchAlerts.Wait()
+
+	// Alerts failed to be delivered to the webhook are appended to the alerts file
+	alerts := readSavedSearchAlerts(t)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "failing", alerts[0].SavedSearchID)
+}
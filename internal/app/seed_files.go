@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/zone_file"
+)
+
+const zoneFileSeedSourceType = "builtin.zone_file"
+
+// Returns the absolute paths of the files of the directory, or the path itself if it is a file.
+func getSeedFilePaths(path string) ([]string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fileInfo.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	filePaths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			filePaths = append(filePaths, filepath.Join(path, entry.Name()))
+		}
+	}
+	return filePaths, nil
+}
+
+// Registers the files of the declared seed sources, then the remaining files under the zone files folder as zone
+// files. Each file is registered as a zone named after the file.
+func (a *Application) registerSeedFiles() {
+	declaredFilePaths := make(map[string]bool)
+	for _, seedSourceOptions := range a.config.SeedSourceOptions {
+		if _, ok := a.seedSourceRegistry[seedSourceOptions.Type]; !ok {
+			zap.L().Fatal(
+				"Seed Source not found in registry.",
+				zap.String("seed_source_type", seedSourceOptions.Type),
+			)
+		}
+
+		seedPath := seedSourceOptions.StringOptions["path"]
+		filePaths, err := getSeedFilePaths(seedPath)
+		if err != nil {
+			zap.L().Fatal(
+				"Unable to read seed source path.",
+				zap.String("path", seedPath),
+				zap.String("err", err.Error()),
+			)
+		}
+
+		for _, filePath := range filePaths {
+			declaredFilePaths[filePath] = true
+			a.registerSeedFile(filePath, seedSourceOptions)
+		}
+	}
+
+	zoneFilesFolderPath := path_manager.GetZoneFilesFolderPath()
+	filePaths, err := getSeedFilePaths(zoneFilesFolderPath)
+	// Zone files folder is optional once seed sources are declared
+	if err != nil && !(os.IsNotExist(err) && len(a.config.SeedSourceOptions) > 0) {
+		zap.L().Fatal(
+			"Unable to read zone zoneFiles directory.",
+			zap.String("path", zoneFilesFolderPath),
+			zap.String("err", err.Error()),
+		)
+	}
+	for _, filePath := range filePaths {
+		if declaredFilePaths[filePath] {
+			continue
+		}
+		a.registerSeedFile(filePath, config.TaskHandlerOptions{Type: zoneFileSeedSourceType})
+	}
+
+	if len(a.zoneFileRegistry) <= 0 {
+		zap.L().Fatal(
+			fmt.Sprintf(
+				"No zone files found. Please ensure that zone files are correctly "+
+					"located under %v, or declare seed sources in config.toml.", path_manager.GetZoneFilesFolderPath(),
+			),
+		)
+	}
+}
+
+func (a *Application) registerSeedFile(filePath string, seedSourceOptions config.TaskHandlerOptions) {
+	// Design Note: Remove extensions (including compression extensions) from file name
+	zoneName := zone_file.GetZoneName(filePath)
+
+	if existingFilePath, ok := a.zoneFileRegistry[zoneName]; ok {
+		zap.L().Warn(
+			"Multiple seed files found for the same zone, skipping.",
+			zap.String("zone_name", zoneName),
+			zap.String("path", filePath),
+			zap.String("registered_path", existingFilePath),
+		)
+		return
+	}
+	a.zoneFileRegistry[zoneName] = filePath
+	a.zoneSeedSourceOptions[zoneName] = seedSourceOptions
+}
+
+func (a *Application) getZoneSeedSourceType(zoneName string) string {
+	seedSourceOptions, ok := a.zoneSeedSourceOptions[zoneName]
+	if !ok {
+		return zoneFileSeedSourceType
+	}
+	return seedSourceOptions.Type
+}
+
+// Opens the seed file of the zone via its seed source.
+func (a *Application) openSeedReader(zoneName string, filePath string) (interfaces.SeedReader, error) {
+	seedSourceOptions, ok := a.zoneSeedSourceOptions[zoneName]
+	if !ok {
+		seedSourceOptions = config.TaskHandlerOptions{Type: zoneFileSeedSourceType}
+	}
+
+	seedSource, ok := a.seedSourceRegistry[seedSourceOptions.Type]
+	if !ok {
+		return nil, fmt.Errorf("seed source %v not found in registry", seedSourceOptions.Type)
+	}
+	return seedSource.Open(filePath, zoneName, seedSourceOptions)
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+)
+
+func TestRegisterSeedFiles(t *testing.T) {
+	zoneFilesFolderPath := t.TempDir()
+	curatedFolderPath := t.TempDir()
+	for _, filePath := range []string{
+		filepath.Join(zoneFilesFolderPath, "dev.txt.gz"),
+		filepath.Join(zoneFilesFolderPath, "tranco.csv"),
+		filepath.Join(curatedFolderPath, "studios.txt"),
+		filepath.Join(curatedFolderPath, "dev.txt"),
+	} {
+		require.NoError(t, os.WriteFile(filePath, []byte{}, 0600))
+	}
+	previousZoneFilesFolderPath := path_manager.GetZoneFilesFolderPath()
+	path_manager.SetZoneFilesFolderPath(zoneFilesFolderPath)
+	t.Cleanup(
+		func() {
+			path_manager.SetZoneFilesFolderPath(previousZoneFilesFolderPath)
+		},
+	)
+
+	a := &Application{
+		zoneFileRegistry:      make(map[string]string),
+		zoneSeedSourceOptions: make(map[string]config.TaskHandlerOptions),
+		seedSourceRegistry:    make(map[string]interfaces.SeedSource),
+	}
+	a.config.SeedSourceOptions = []config.TaskHandlerOptions{
+		{
+			Type:          "builtin.ranked_csv",
+			StringOptions: map[string]string{"path": filepath.Join(zoneFilesFolderPath, "tranco.csv")},
+		},
+		{Type: "builtin.domain_list", StringOptions: map[string]string{"path": curatedFolderPath}},
+	}
+	a.registerSeedSources()
+	a.registerSeedFiles()
+
+	// Declared seed files take precedence over the zone files with the same zone name
+	assert.Equal(
+		t, map[string]string{
+			"tranco":  filepath.Join(zoneFilesFolderPath, "tranco.csv"),
+			"studios": filepath.Join(curatedFolderPath, "studios.txt"),
+			"dev":     filepath.Join(curatedFolderPath, "dev.txt"),
+		}, a.zoneFileRegistry,
+	)
+	assert.Equal(t, "builtin.ranked_csv", a.getZoneSeedSourceType("tranco"))
+	assert.Equal(t, "builtin.domain_list", a.getZoneSeedSourceType("dev"))
+	assert.Equal(t, zoneFileSeedSourceType, a.getZoneSeedSourceType("unknown"))
+}
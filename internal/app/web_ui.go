@@ -0,0 +1,69 @@
+package app
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	embedding "github.com/anthony-ozdemir/zfse"
+	"github.com/anthony-ozdemir/zfse/internal/enum"
+)
+
+type webUIIndexTemplateData struct {
+	BIsIndexing        bool
+	Task               string
+	TotalWorkItems     int
+	ProcessedWorkItems int
+	ErrorDetails       string
+}
+
+func (a *Application) webUIIndexHandler() func(w http.ResponseWriter, r *http.Request) {
+	indexTemplate, err := template.ParseFS(embedding.GetWebFiles(), "index.html")
+	if err != nil {
+		zap.L().Fatal("Unable to parse Web UI template.", zap.String("err", err.Error()))
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Design Note: "/" pattern matches all paths that are not registered to the router.
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			a.helperSendJSONError(&w, errMethodNotAllowed)
+			return
+		}
+
+		currentApplicationState := a.applicationStateManager.GetApplicationState()
+		templateData := webUIIndexTemplateData{
+			// Re-runs of the zones don't take the search offline, see IsSearchable
+			BIsIndexing: currentApplicationState.task != enum.ReadyToSearch &&
+				currentApplicationState.task != enum.Errored &&
+				currentApplicationState.task != enum.Shutdown &&
+				!currentApplicationState.bIsSearchable,
+			Task:               currentApplicationState.task.String(),
+			TotalWorkItems:     currentApplicationState.totalWorkItems,
+			ProcessedWorkItems: currentApplicationState.processedWorkItems,
+			ErrorDetails:       currentApplicationState.errorDetails,
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store") // Banner depends on the application state
+		err := indexTemplate.Execute(w, templateData)
+		if err != nil {
+			zap.L().Warn("Unable to render Web UI.", zap.String("err", err.Error()))
+		}
+	}
+}
+
+func (a *Application) webUIStaticHandler() http.Handler {
+	staticFiles, err := fs.Sub(embedding.GetWebFiles(), "static")
+	if err != nil {
+		zap.L().Fatal("Unable to find Web UI static files.", zap.String("err", err.Error()))
+	}
+
+	return http.StripPrefix(webPathStatic, http.FileServer(http.FS(staticFiles)))
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIIndexingBanner(t *testing.T) {
+	a := &Application{applicationStateManager: NewApplicationStateManager()}
+	router := a.getRouter()
+
+	// Application is still initializing, banner should be displayed.
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "indexing-banner")
+
+	// Static files should be served as well.
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Unknown paths should not be served by the index handler.
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestWebUIReadyToSearch(t *testing.T) {
+	a := newTestApplication(t, []string{"a.com"})
+	router := a.getRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "indexing-banner")
+}
@@ -0,0 +1,436 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/helper"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+	"github.com/anthony-ozdemir/zfse/internal/zone_file"
+	"github.com/anthony-ozdemir/zfse/internal/zone_parser"
+)
+
+// Design Note: Zones are published as full snapshots (i.e. daily CZDS zone files), yet only a small portion of the
+// domains change between two snapshots. Thus, once a zone is indexed, only the records of the added & changed domains
+// of its next snapshot are written to a delta zone file, which is fed through the pipeline instead of the zone file.
+// Outputs of the delta are appended to the cache files, and the stale index documents of the removed & changed
+// domains are deleted from the index.
+
+type zoneSnapshotDelta struct {
+	addedDomains   int
+	changedDomains int
+	removedDomains int
+}
+
+// Returns the zone file the pre-crawl filters should read, which is the delta zone file if the zone has one.
+func getPreCrawlInputFilePath(zoneName string, zoneFile string) string {
+	deltaFilePath := path_manager.GetZoneDeltaFilePath(zoneName)
+	if _, err := os.Stat(deltaFilePath); err == nil {
+		return deltaFilePath
+	}
+	return zoneFile
+}
+
+// Removes the delta zone file once the zone is indexed, otherwise pre-crawl filters would keep preferring the stale
+// delta over the zone file, see getPreCrawlInputFilePath.
+func removeZoneDeltaFile(zoneName string) {
+	err := os.Remove(path_manager.GetZoneDeltaFilePath(zoneName))
+	if err != nil && !os.IsNotExist(err) {
+		zap.L().Warn(
+			"Unable to remove zone delta file.", zap.String("zone_name", zoneName), zap.String("err", err.Error()),
+		)
+	}
+}
+
+func getZoneFileFingerprint(zoneFile string) (string, error) {
+	fileInfo, err := os.Stat(zoneFile)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", fileInfo.Size(), fileInfo.ModTime().UnixNano()), nil
+}
+
+// Updates the snapshots of the zones whose zone files are changed since the last run.
+func (a *Application) updateZoneSnapshots() {
+	for zoneName, zoneFile := range a.zoneFileRegistry {
+		// Design Note: Only zone files are published as snapshots, other seed files go through the pipeline once.
+		if a.getZoneSeedSourceType(zoneName) != zoneFileSeedSourceType {
+			continue
+		}
+
+		err := a.updateZoneSnapshot(zoneName, zoneFile)
+		if err != nil {
+			zap.L().Fatal(
+				"Unable to update zone snapshot.",
+				zap.String("zone_name", zoneName),
+				zap.String("err", err.Error()),
+			)
+		}
+	}
+}
+
+func (a *Application) updateZoneSnapshot(zoneName string, zoneFile string) error {
+	fingerprint, err := getZoneFileFingerprint(zoneFile)
+	if err != nil {
+		return err
+	}
+
+	state, bHasSnapshot, err := a.db.GetZoneSnapshotState(zoneName)
+	if err != nil {
+		return err
+	}
+	// Previous run was interrupted after the snapshot was committed, yet before its delta was applied
+	if bHasSnapshot && state.BHasPendingDelta {
+		err = a.applyZoneSnapshotDelta(zoneName)
+		if err != nil {
+			return err
+		}
+	}
+	if bHasSnapshot && state.Fingerprint == fingerprint {
+		return nil
+	}
+
+	// Design Note: The delta is computed against the last processed snapshot. Thus, a new snapshot which arrives
+	// while the previous one is still in the pipeline is compared once the previous one is indexed.
+	bIsIndexed := a.db.GetIndexerTaskState(zoneName).BIsFinished
+	if bHasSnapshot && !bIsIndexed {
+		zap.L().Warn(
+			"Zone file has changed before the previous snapshot was indexed. Delta will be processed on next run.",
+			zap.String("zone_name", zoneName),
+		)
+		return nil
+	}
+
+	builder, err := a.db.NewZoneSnapshotBuilder(zoneName)
+	if err != nil {
+		return err
+	}
+
+	// The first snapshot of a zone goes through the pipeline as a whole, thus there is no delta to write
+	var delta *zoneSnapshotDelta
+	if bHasSnapshot {
+		delta, err = a.writeZoneSnapshotDelta(zoneName, zoneFile, builder)
+	} else {
+		err = buildZoneSnapshot(zoneName, zoneFile, builder)
+		if err == nil {
+			err = builder.Diff(
+				func(change database.ZoneSnapshotChange) error {
+					return nil
+				},
+			)
+		}
+	}
+	if err != nil {
+		_ = builder.Discard()
+		return err
+	}
+
+	if delta == nil {
+		return builder.Commit(fingerprint, false)
+	}
+
+	zap.L().Info(
+		"Zone snapshot has changed.",
+		zap.String("zone_name", zoneName),
+		zap.Int("added_domains", delta.addedDomains),
+		zap.Int("changed_domains", delta.changedDomains),
+		zap.Int("removed_domains", delta.removedDomains),
+	)
+
+	// Design Note: Snapshot is committed before its delta is applied, thus the delta is never computed twice. Should
+	// the delta be interrupted, it is applied again on next run, see applyZoneSnapshotDelta.
+	err = builder.Commit(fingerprint, true)
+	if err != nil {
+		return err
+	}
+	return a.applyZoneSnapshotDelta(zoneName)
+}
+
+// Adds all records of the zone file to the snapshot. Malformed records are skipped, as pre-crawl filters will warn
+// about them.
+func buildZoneSnapshot(zoneName string, zoneFile string, builder *database.ZoneSnapshotBuilder) error {
+	file, err := zone_file.Open(zoneFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	parser := zone_parser.NewParser(file, zoneName)
+	for {
+		record, err := parser.Next()
+		if err == io.EOF {
+			return nil
+		}
+		parseError := &zone_parser.ParseError{}
+		if errors.As(err, &parseError) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = builder.AddRecord(
+			database.ZoneSnapshotRecord{
+				DomainName: strings.TrimSuffix(record.OwnerName, "."),
+				TTL:        record.TTL,
+				Class:      record.Class,
+				Type:       record.Type,
+				Data:       strings.Join(record.Data, " "),
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Builds the new snapshot of the zone & writes the records of the added and changed domains to the delta zone file.
+func (a *Application) writeZoneSnapshotDelta(
+	zoneName string, zoneFile string, builder *database.ZoneSnapshotBuilder,
+) (*zoneSnapshotDelta, error) {
+	err := buildZoneSnapshot(zoneName, zoneFile, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	deltaFilePath := path_manager.GetZoneDeltaFilePath(zoneName)
+	err = os.MkdirAll(filepath.Dir(deltaFilePath), 0700)
+	if err != nil {
+		return nil, err
+	}
+	deltaFile, err := os.Create(deltaFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer deltaFile.Close()
+	writer := bufio.NewWriter(deltaFile)
+
+	// Domains whose documents need to be deleted from the index, i.e. removed & changed domains
+	staleDomainsFile, err := os.Create(path_manager.GetZoneStaleDomainsFilePath(zoneName))
+	if err != nil {
+		return nil, err
+	}
+	defer staleDomainsFile.Close()
+	staleDomainsWriter := bufio.NewWriter(staleDomainsFile)
+
+	delta := &zoneSnapshotDelta{}
+	err = builder.Diff(
+		func(change database.ZoneSnapshotChange) error {
+			switch change.Type {
+			case database.ZoneSnapshotDomainAdded:
+				delta.addedDomains++
+			case database.ZoneSnapshotDomainChanged:
+				delta.changedDomains++
+			case database.ZoneSnapshotDomainRemoved:
+				delta.removedDomains++
+			}
+
+			if change.Type != database.ZoneSnapshotDomainAdded {
+				_, err := staleDomainsWriter.WriteString(change.DomainName + "\n")
+				if err != nil {
+					return err
+				}
+			}
+			if change.Type == database.ZoneSnapshotDomainRemoved {
+				return nil
+			}
+
+			// Names are absolute, thus the delta zone file doesn't depend on the $ORIGIN of the zone file
+			for _, record := range change.Records {
+				_, err := writer.WriteString(
+					strings.Join(
+						[]string{
+							record.DomainName + ".", strconv.FormatUint(uint64(record.TTL), 10), record.Class,
+							record.Type, record.Data,
+						}, " ",
+					) + "\n",
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return nil, err
+	}
+	err = staleDomainsWriter.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return delta, nil
+}
+
+// Deletes the stale documents of the committed delta from the index & schedules the delta zone file for the pipeline.
+// Deleting documents is idempotent & task states are reset along with the pending flag of the snapshot, thus the
+// delta can be applied again should it be interrupted.
+func (a *Application) applyZoneSnapshotDelta(zoneName string) error {
+	staleDomainNames, err := readZoneStaleDomainNames(zoneName)
+	if err != nil {
+		return err
+	}
+	err = a.deleteStaleZoneDocuments(zoneName, staleDomainNames)
+	if err != nil {
+		return err
+	}
+
+	deltaFilePath := path_manager.GetZoneDeltaFilePath(zoneName)
+	deltaFileInfo, err := os.Stat(deltaFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var taskStates *database.ZoneTaskStates
+	if err == nil && deltaFileInfo.Size() == 0 {
+		// Nothing to feed through the pipeline
+		err = os.Remove(deltaFilePath)
+		if err != nil {
+			return err
+		}
+	} else if err == nil {
+		// Design Note: Pre-crawl filters read the delta zone file from the start, while the later stages continue
+		// from the end of their input cache files, as the outputs of the delta are appended to them.
+		preCrawlLines, err := helper.CountLinesInFile(path_manager.GetPreCrawlFilterOutputFilePath(zoneName))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		postCrawlLines, err := helper.CountLinesInFile(path_manager.GetPostCrawlFilterOutputFilePath(zoneName))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		taskStates = &database.ZoneTaskStates{
+			PreCrawlFilter:  database.PreCrawlFilterTaskState{BIsFinished: false, LineIndex: 0},
+			PostCrawlFilter: database.PostCrawlFilterTaskState{BIsFinished: false, LineIndex: preCrawlLines},
+			Indexer:         database.IndexerTaskState{BIsFinished: false, LineIndex: postCrawlLines},
+		}
+	}
+
+	err = a.db.FinishZoneSnapshotDelta(zoneName, taskStates)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path_manager.GetZoneStaleDomainsFilePath(zoneName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Returns the stale domains of the committed delta, see writeZoneSnapshotDelta.
+func readZoneStaleDomainNames(zoneName string) (map[string]bool, error) {
+	domainNames := make(map[string]bool)
+	file, err := os.Open(path_manager.GetZoneStaleDomainsFilePath(zoneName))
+	if os.IsNotExist(err) {
+		return domainNames, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		domainName := strings.TrimSpace(scanner.Text())
+		if len(domainName) > 0 {
+			domainNames[domainName] = true
+		}
+	}
+	return domainNames, scanner.Err()
+}
+
+// Deletes the index documents of the domains. Index IDs are derived from the post-crawl cache file lines.
+func (a *Application) deleteStaleZoneDocuments(zoneName string, domainNames map[string]bool) error {
+	if len(domainNames) == 0 {
+		return nil
+	}
+
+	deleter, ok := (*a.indexer).(interfaces.Deleter)
+	if !ok {
+		zap.L().Warn(
+			"Indexer doesn't support deletion. Removed & changed domains will remain in the index.",
+			zap.String("zone_name", zoneName),
+			zap.String("indexer", (*a.indexer).GetType()),
+		)
+		return nil
+	}
+
+	file, err := os.Open(path_manager.GetPostCrawlFilterOutputFilePath(zoneName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ids := make([]string, 0, adminDeleteBatchSize)
+	deleteIDs := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+		err := deleter.Delete(ids)
+		ids = ids[:0]
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	for lineIndex := 0; ; lineIndex++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		domainProperties := struct {
+			DomainName string `json:"domainName"`
+		}{}
+		err = json.Unmarshal([]byte(line), &domainProperties)
+		if err != nil {
+			return err
+		}
+		if !domainNames[domainProperties.DomainName] {
+			continue
+		}
+
+		ids = append(ids, createIndexID(zoneName, lineIndex))
+		if len(ids) >= adminDeleteBatchSize {
+			err = deleteIDs()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = deleteIDs()
+	if err != nil {
+		return err
+	}
+
+	a.invalidateRankingCache()
+	return nil
+}
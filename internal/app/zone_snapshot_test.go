@@ -0,0 +1,170 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/database"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/path_manager"
+)
+
+func writeTestZoneFile(t *testing.T, zoneFile string, content string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(zoneFile, []byte(content), 0600))
+	require.NoError(t, os.Chtimes(zoneFile, modTime, modTime))
+}
+
+func getIndexedIDs(t *testing.T, a *Application, ids []string) []string {
+	matchingIDs, err := (*a.indexer).(interfaces.Matcher).Match(mustParseQuery(t, "example"), ids)
+	require.NoError(t, err)
+	return matchingIDs
+}
+
+func TestUpdateZoneSnapshot(t *testing.T) {
+	a := newTestApplication(t, []string{"a.dev", "b.dev", "c.dev"})
+	zoneFile := filepath.Join(t.TempDir(), "test.txt")
+	a.zoneFileRegistry["test"] = zoneFile
+	modTime := time.Now().Add(-time.Hour)
+
+	writeTestZoneFile(
+		t, zoneFile, strings.Join(
+			[]string{
+				"$ORIGIN dev.",
+				"a 3600 IN NS ns1.example.com.",
+				"a 3600 IN NS ns2.example.com.",
+				"b 3600 IN NS ns1.example.com.",
+				"c 3600 IN NS ns1.example.com.",
+			}, "\n",
+		)+"\n", modTime,
+	)
+
+	// The first snapshot goes through the pipeline as a whole
+	require.NoError(t, a.updateZoneSnapshot("test", zoneFile))
+	_, bHasSnapshot, err := a.db.GetZoneSnapshotState("test")
+	require.NoError(t, err)
+	assert.True(t, bHasSnapshot)
+	assert.Equal(t, zoneFile, getPreCrawlInputFilePath("test", zoneFile))
+
+	a.db.SavePreCrawlFilterTaskState("test", database.PreCrawlFilterTaskState{BIsFinished: true})
+	a.db.SavePostCrawlFilterTaskState("test", database.PostCrawlFilterTaskState{BIsFinished: true})
+	a.db.SaveIndexerTaskState("test", database.IndexerTaskState{BIsFinished: true, LineIndex: 3})
+
+	// NS records are reordered for a.dev, b.dev's NS record is changed, c.dev is removed & d.dev is added
+	writeTestZoneFile(
+		t, zoneFile, strings.Join(
+			[]string{
+				"$ORIGIN dev.",
+				"a 3600 IN NS ns2.example.com.",
+				"a 3600 IN NS ns1.example.com.",
+				"b 3600 IN NS ns3.example.com.",
+				"d 3600 IN NS ns1.example.com.",
+				"d 3600 IN A 192.0.2.1",
+			}, "\n",
+		)+"\n", modTime.Add(time.Minute),
+	)
+	require.NoError(t, a.updateZoneSnapshot("test", zoneFile))
+
+	deltaFilePath := path_manager.GetZoneDeltaFilePath("test")
+	assert.Equal(t, deltaFilePath, getPreCrawlInputFilePath("test", zoneFile))
+	deltaContent, err := os.ReadFile(deltaFilePath)
+	require.NoError(t, err)
+	assert.Equal(
+		t, "b.dev. 3600 in ns ns3.example.com.\n"+
+			"d.dev. 3600 in a 192.0.2.1\n"+
+			"d.dev. 3600 in ns ns1.example.com.\n",
+		string(deltaContent),
+	)
+
+	// Changed & removed domains are deleted from the index
+	assert.Equal(t, []string{"test_0"}, getIndexedIDs(t, a, []string{"test_0", "test_1", "test_2"}))
+
+	// Delta is fed through the pipeline & its outputs are appended to the cache files
+	assert.Equal(t, database.PreCrawlFilterTaskState{}, a.db.GetPreCrawlFilterTaskState("test"))
+	assert.Equal(t, database.PostCrawlFilterTaskState{}, a.db.GetPostCrawlFilterTaskState("test"))
+	assert.Equal(t, database.IndexerTaskState{LineIndex: 3}, a.db.GetIndexerTaskState("test"))
+
+	// Unchanged zone files are skipped
+	require.NoError(t, a.updateZoneSnapshot("test", zoneFile))
+	assert.Equal(t, database.IndexerTaskState{LineIndex: 3}, a.db.GetIndexerTaskState("test"))
+
+	// Zone files changed before the previous snapshot is indexed are processed later
+	writeTestZoneFile(t, zoneFile, "$ORIGIN dev.\n", modTime.Add(2*time.Minute))
+	require.NoError(t, a.updateZoneSnapshot("test", zoneFile))
+	deltaContent, err = os.ReadFile(deltaFilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(deltaContent), "d.dev.")
+
+	// Delta zone file is removed once the zone is indexed
+	require.True(t, a.applicationStateManager.OnRestart())
+	a.applicationStateManager.OnPreCrawlFiltersStarted()
+	a.applicationStateManager.OnPreCrawlFiltersFinished()
+	a.applicationStateManager.OnPostCrawlFiltersStarted()
+	a.applicationStateManager.OnPostCrawlFiltersFinished()
+	a.applicationStateManager.OnIndexingStarted()
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	a.runIndexer(&wg)
+	assert.True(t, a.db.GetIndexerTaskState("test").BIsFinished)
+	assert.Equal(t, zoneFile, getPreCrawlInputFilePath("test", zoneFile))
+
+	// Purging the zone deletes its snapshot & delta zone file
+	require.NoError(t, a.purgeZoneLocked("test"))
+	_, bHasSnapshot, err = a.db.GetZoneSnapshotState("test")
+	require.NoError(t, err)
+	assert.False(t, bHasSnapshot)
+	assert.Equal(t, zoneFile, getPreCrawlInputFilePath("test", zoneFile))
+}
+
+func TestUpdateZoneSnapshotPendingDelta(t *testing.T) {
+	a := newTestApplication(t, []string{"a.dev", "b.dev"})
+	zoneFile := filepath.Join(t.TempDir(), "test.txt")
+	a.zoneFileRegistry["test"] = zoneFile
+	modTime := time.Now().Add(-time.Hour)
+
+	writeTestZoneFile(
+		t, zoneFile, "$ORIGIN dev.\na 3600 IN NS ns1.example.com.\nb 3600 IN NS ns1.example.com.\n", modTime,
+	)
+	require.NoError(t, a.updateZoneSnapshot("test", zoneFile))
+	a.db.SavePreCrawlFilterTaskState("test", database.PreCrawlFilterTaskState{BIsFinished: true})
+	a.db.SavePostCrawlFilterTaskState("test", database.PostCrawlFilterTaskState{BIsFinished: true})
+	a.db.SaveIndexerTaskState("test", database.IndexerTaskState{BIsFinished: true, LineIndex: 2})
+
+	// Previous run is interrupted right after the snapshot with the delta is committed
+	modTime = modTime.Add(time.Minute)
+	writeTestZoneFile(
+		t, zoneFile, "$ORIGIN dev.\na 3600 IN NS ns1.example.com.\nc 3600 IN NS ns1.example.com.\n", modTime,
+	)
+	fingerprint, err := getZoneFileFingerprint(zoneFile)
+	require.NoError(t, err)
+	builder, err := a.db.NewZoneSnapshotBuilder("test")
+	require.NoError(t, err)
+	_, err = a.writeZoneSnapshotDelta("test", zoneFile, builder)
+	require.NoError(t, err)
+	require.NoError(t, builder.Commit(fingerprint, true))
+
+	// Pending delta is applied on next run
+	require.NoError(t, a.updateZoneSnapshot("test", zoneFile))
+	state, _, err := a.db.GetZoneSnapshotState("test")
+	require.NoError(t, err)
+	assert.False(t, state.BHasPendingDelta)
+	assert.Equal(t, []string{"test_0"}, getIndexedIDs(t, a, []string{"test_0", "test_1"}))
+	assert.Equal(t, database.PostCrawlFilterTaskState{}, a.db.GetPostCrawlFilterTaskState("test"))
+	assert.Equal(t, database.IndexerTaskState{LineIndex: 2}, a.db.GetIndexerTaskState("test"))
+	_, err = os.Stat(path_manager.GetZoneStaleDomainsFilePath("test"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Applied delta isn't applied again, thus the progress of the pipeline is kept
+	a.db.SavePreCrawlFilterTaskState("test", database.PreCrawlFilterTaskState{LineIndex: 1})
+	require.NoError(t, a.updateZoneSnapshot("test", zoneFile))
+	assert.Equal(t, database.PreCrawlFilterTaskState{LineIndex: 1}, a.db.GetPreCrawlFilterTaskState("test"))
+	deltaContent, err := os.ReadFile(path_manager.GetZoneDeltaFilePath("test"))
+	require.NoError(t, err)
+	assert.Equal(t, "c.dev. 3600 in ns ns1.example.com.\n", string(deltaContent))
+}
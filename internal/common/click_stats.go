@@ -0,0 +1,14 @@
+package common
+
+// ClickStats are the position-bias corrected click counts of a search result. Each click is weighted by the inverse of
+// the propensity of its position, i.e. clicks on lower positions weigh more.
+type ClickStats struct {
+	// Clicks of the domain for the (normalized) query
+	QueryDomainClicks float64
+	// Clicks of all domains for the (normalized) query
+	QueryClicks float64
+	// Clicks of the domain for all queries
+	DomainClicks float64
+	// Clicks of all domains for all queries
+	TotalClicks float64
+}
@@ -0,0 +1,20 @@
+package common
+
+import "fmt"
+
+// Seed is a domain read by a seed source, which is fed through the pre-crawl filters.
+type Seed struct {
+	DomainProperties DomainProperties
+	// 1-based line number, on which the seed starts
+	LineNumber int
+}
+
+// SeedParseError is returned by seed readers for malformed seeds. Readers can continue with the next seed afterwards.
+type SeedParseError struct {
+	LineNumber int
+	Message    string
+}
+
+func (e *SeedParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.LineNumber, e.Message)
+}
@@ -25,6 +25,11 @@ type ApplicationConfig struct {
 	PostCrawlFilterOptions []TaskHandlerOptions `toml:"PostCrawlFilters"`
 	IndexerOption          TaskHandlerOptions   `toml:"Indexer"`
 	RankerOptions          []TaskHandlerOptions `toml:"Rankers"`
+	CZDSOptions            CZDSOptions          `toml:"CZDS"`
+
+	// Design Note: Seed sources are declared per file or directory, which aren't necessarily zone files.
+	// Undeclared files under the zone files folder are read as zone files.
+	SeedSourceOptions []TaskHandlerOptions `toml:"SeedSources"`
 }
 
 type GeneralOptions struct {
@@ -32,6 +37,19 @@ type GeneralOptions struct {
 	ListenPort             string `toml:"listen_port"`
 	ServerTimeoutInSeconds int    `toml:"server_timeout_in_seconds"`
 
+	// Base URL of the links in OpenSearch description & feeds, derived from the request when empty
+	PublicURL string `toml:"public_url"`
+
+	// HTTP Limits, zero values disable the limit
+	RateLimitPerSecond    float64  `toml:"rate_limit_per_second"`
+	RateLimitBurst        int      `toml:"rate_limit_burst"`
+	MaxQueryLengthInBytes int      `toml:"max_query_length_in_bytes"`
+	MaxRequestBodyInBytes int64    `toml:"max_request_body_in_bytes"`
+	TrustedProxies        []string `toml:"trusted_proxies"`
+
+	// Admin API is disabled unless at least one key is configured
+	AdminAPIKeys []string `toml:"admin_api_keys"`
+
 	FileBulkOutputQty int64 `toml:"file_bulk_output_qty"`
 
 	NumThreadHint int `toml:"num_thread_hint"`
@@ -47,6 +65,29 @@ type GeneralOptions struct {
 	ConcurrentConnections   int    `toml:"concurrent_connections"`
 
 	IndexerOutputLimit int64 `toml:"indexer_output_limit"`
+
+	MaxConcurrentQueries int      `toml:"max_concurrent_queries"`
+	FacetProperties      []string `toml:"facet_properties"`
+
+	// Ranking cache is disabled unless a TTL is configured, zero max entries disables the eviction
+	RankingCacheTTLInSeconds int `toml:"ranking_cache_ttl_in_seconds"`
+	RankingCacheMaxEntries   int `toml:"ranking_cache_max_entries"`
+
+	// Query log is disabled unless a retention is configured
+	QueryLogRetentionInDays int `toml:"query_log_retention_in_days"`
+}
+
+// CZDSOptions are used by the "zones download" command to download zone files from ICANN CZDS.
+type CZDSOptions struct {
+	Username string `toml:"username"`
+	// Design Note: ZFSE_CZDS_PASSWORD environment variable overrides the password, so that it can be kept out of the
+	// config file.
+	Password string `toml:"password"`
+	// Default ICANN URLs are used when empty
+	AuthURL string `toml:"auth_url"`
+	APIURL  string `toml:"api_url"`
+	// All approved TLDs are downloaded when empty
+	TLDs []string `toml:"tlds"`
 }
 
 type TaskHandlerOptions struct {
@@ -103,31 +144,41 @@ func (f *TaskHandlerOptions) UnmarshalTOML(data interface{}) error {
 func NewApplicationConfig() ApplicationConfig {
 	zap.L().Info("Started parsing configuration.")
 
-	configBytes, err := os.ReadFile(path_manager.GetConfigFilePath())
+	config, err := LoadApplicationConfig()
 	if err != nil {
 		zap.L().Fatal(err.Error())
 	}
 
+	zap.L().Info("Finished parsing configuration.")
+	return config
+}
+
+// LoadApplicationConfig reads & validates the config file. Unlike NewApplicationConfig, it doesn't exit on errors, so
+// that the configuration can be reloaded while the application is running.
+func LoadApplicationConfig() (ApplicationConfig, error) {
+	configBytes, err := os.ReadFile(path_manager.GetConfigFilePath())
+	if err != nil {
+		return ApplicationConfig{}, err
+	}
+
 	// Let's check the version of the config file
 	configVersionOnly := ApplicationConfigVersionOnly{}
 	if _, err := toml.Decode(string(configBytes), &configVersionOnly); err != nil {
-		zap.L().Fatal(err.Error())
+		return ApplicationConfig{}, err
 	}
 
 	if configVersionOnly.GeneralOptions.Version != currentConfigVersion {
-		zap.L().Fatal(
-			fmt.Sprintf(
-				"Existing config.toml version does not match the current version of %v.\n"+
-					" Please either manually edit the existing configuration to the match latest configuration"+
-					" format or delete it.\n Exiting application.",
-				currentConfigVersion,
-			),
+		return ApplicationConfig{}, fmt.Errorf(
+			"Existing config.toml version does not match the current version of %v.\n"+
+				" Please either manually edit the existing configuration to the match latest configuration"+
+				" format or delete it.",
+			currentConfigVersion,
 		)
 	}
 
 	config := ApplicationConfig{}
 	if _, err := toml.Decode(string(configBytes), &config); err != nil {
-		zap.L().Fatal(err.Error())
+		return ApplicationConfig{}, err
 	}
 
 	// Sanity Check
@@ -135,13 +186,22 @@ func NewApplicationConfig() ApplicationConfig {
 	for _, rankerOption := range config.RankerOptions {
 		_, bHasWeight := rankerOption.FloatOptions["weight"]
 		if !bHasWeight {
-			zap.L().Fatal(
-				"Invalid Ranker configuration. \"weight\" option of float type is not specified.",
-				zap.String("ranker_type", rankerOption.Type),
+			return ApplicationConfig{}, fmt.Errorf(
+				"Invalid Ranker configuration. \"weight\" option of float type is not specified for %v.",
+				rankerOption.Type,
 			)
 		}
 	}
 
-	zap.L().Info("Finished parsing configuration.")
-	return config
+	// Every Seed Source needs a string type "path" option to be specified
+	for _, seedSourceOption := range config.SeedSourceOptions {
+		if len(seedSourceOption.StringOptions["path"]) == 0 {
+			return ApplicationConfig{}, fmt.Errorf(
+				"Invalid Seed Source configuration. \"path\" option of string type is not specified for %v.",
+				seedSourceOption.Type,
+			)
+		}
+	}
+
+	return config, nil
 }
@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -22,8 +23,14 @@ type CrawlerOptions struct {
 	MinContentLengthInBytes int64
 	MaxContentLengthInBytes int64
 	ContentReadLimitInBytes int64
+
+	// Optional, called with the number of body bytes read per request. Can be called from multiple threads.
+	OnBytesReadCB func(bytesRead int64)
 }
 
+// ErrContentLength is returned by Crawl when Content-Length is missing, invalid or out of the configured limits.
+var ErrContentLength = errors.New("unsuitable Content-Length")
+
 type Crawler struct {
 	opts       CrawlerOptions
 	httpClient *http.Client
@@ -77,7 +84,9 @@ func (c *Crawler) CanCrawl(ctx context.Context, urlString string) bool {
 		return false
 	}
 	defer resp.Body.Close()
-	content, err := io.ReadAll(resp.Body)
+	countingBody := &countingReader{reader: resp.Body}
+	content, err := io.ReadAll(countingBody)
+	c.onBytesRead(countingBody.bytesRead)
 	if err != nil {
 		return false
 	}
@@ -98,11 +107,11 @@ func (c *Crawler) Crawl(ctx context.Context, urlString string) (*http.Header, *h
 	contentLengthStr := respHead.Header.Get("Content-Length")
 	contentLength, err := strconv.ParseInt(contentLengthStr, 10, 64)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Error parsing Content-Length: %s", err.Error())
+		return nil, nil, fmt.Errorf("%w: error parsing Content-Length: %s", ErrContentLength, err.Error())
 	}
 
 	if contentLength < c.opts.MinContentLengthInBytes || contentLength > c.opts.MaxContentLengthInBytes {
-		return nil, nil, fmt.Errorf("Content-Length is not suitable: %v", contentLength)
+		return nil, nil, fmt.Errorf("%w: %v", ErrContentLength, contentLength)
 	}
 
 	// We can continue reading the body up to the contentLength
@@ -118,12 +127,30 @@ func (c *Crawler) Crawl(ctx context.Context, urlString string) (*http.Header, *h
 	defer resp.Body.Close()
 
 	// Wrap the response body in a LimitedReader to read only the specified number of bytes.
-	limitedBody := io.LimitReader(resp.Body, c.opts.ContentReadLimitInBytes)
+	limitedBody := &countingReader{reader: io.LimitReader(resp.Body, c.opts.ContentReadLimitInBytes)}
 
 	doc, err := html.Parse(limitedBody)
+	c.onBytesRead(limitedBody.bytesRead)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	return &resp.Header, doc, nil
 }
+
+func (c *Crawler) onBytesRead(bytesRead int64) {
+	if c.opts.OnBytesReadCB != nil && bytesRead > 0 {
+		c.opts.OnBytesReadCB(bytesRead)
+	}
+}
+
+type countingReader struct {
+	reader    io.Reader
+	bytesRead int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.bytesRead += int64(n)
+	return n, err
+}
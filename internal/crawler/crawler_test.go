@@ -1,9 +1,18 @@
 package crawler
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/html"
 )
 
@@ -61,3 +70,48 @@ func TestKnownWebsite(t *testing.T) {
 
 }
 */
+
+func TestCrawl(t *testing.T) {
+	body := "<html><head><title>Example</title></head><body>" + strings.Repeat("a", 256) + "</body></html>"
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte(body))
+				}
+			},
+		),
+	)
+	defer server.Close()
+
+	var bytesRead int64
+	opts := CrawlerOptions{
+		TimeOutInSeconds:        5,
+		MinContentLengthInBytes: 128,
+		MaxContentLengthInBytes: 1024,
+		ContentReadLimitInBytes: 64,
+		OnBytesReadCB: func(n int64) {
+			atomic.AddInt64(&bytesRead, n)
+		},
+	}
+	header, baseNode, err := NewCrawler(opts).Crawl(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.NotNil(t, header)
+	assert.NotNil(t, baseNode)
+	// Body should only be read up to the read limit
+	assert.Equal(t, int64(64), atomic.LoadInt64(&bytesRead))
+
+	// Content is too large
+	opts.MaxContentLengthInBytes = 128
+	_, _, err = NewCrawler(opts).Crawl(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Equal(t, ErrorClassContentLength, ClassifyError(err))
+}
+
+func TestClassifyError(t *testing.T) {
+	assert.Equal(t, ErrorClassTimeout, ClassifyError(context.DeadlineExceeded))
+	assert.Equal(t, ErrorClassDNS, ClassifyError(&net.DNSError{Err: "no such host", Name: "example.invalid"}))
+	assert.Equal(t, ErrorClassConnection, ClassifyError(&net.OpError{Op: "dial", Err: errors.New("refused")}))
+	assert.Equal(t, ErrorClassOther, ClassifyError(errors.New("unknown")))
+}
@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+)
+
+// Crawl error classes
+const (
+	ErrorClassTimeout       = "timeout"
+	ErrorClassDNS           = "dns"
+	ErrorClassTLS           = "tls"
+	ErrorClassConnection    = "connection"
+	ErrorClassContentLength = "content_length"
+	ErrorClassOther         = "other"
+)
+
+var ErrorClasses = []string{
+	ErrorClassTimeout, ErrorClassDNS, ErrorClassTLS, ErrorClassConnection, ErrorClassContentLength, ErrorClassOther,
+}
+
+// ClassifyError returns the class of an error returned by Crawl, i.e. for metrics.
+func ClassifyError(err error) string {
+	if errors.Is(err, ErrContentLength) {
+		return ErrorClassContentLength
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorClassTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassDNS
+	}
+
+	var recordHeaderErr tls.RecordHeaderError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certificateInvalidErr x509.CertificateInvalidError
+	if errors.As(err, &recordHeaderErr) || errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &certificateInvalidErr) {
+		return ErrorClassTLS
+	}
+
+	var opErr *net.OpError
+	var urlErr *url.Error
+	if errors.As(err, &opErr) || errors.As(err, &urlErr) {
+		return ErrorClassConnection
+	}
+
+	return ErrorClassOther
+}
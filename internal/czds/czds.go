@@ -0,0 +1,432 @@
+package czds
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Design Note: Client of the ICANN Centralized Zone Data Service (CZDS) REST API. Refer to
+// https://github.com/icann/czds-api-client-java for the API documentation.
+
+const (
+	DefaultAuthURL = "https://account-api.icann.org"
+	DefaultAPIURL  = "https://czds-api.icann.org"
+
+	authenticatePath  = "/api/authenticate"
+	downloadLinksPath = "/czds/downloads/links"
+
+	responseHeaderTimeout = 60 * time.Second
+	// Maximum size of the API replies other than zone files
+	maxAPIReplyInBytes = 4 * 1024 * 1024
+)
+
+var ErrUnauthorized = errors.New("czds: unauthorized, access token is missing or expired")
+
+type Client struct {
+	httpClient  *http.Client
+	authURL     string
+	apiURL      string
+	accessToken string
+}
+
+// NewClient creates a CZDS client. Default URLs are used for empty URLs, and a client without an overall timeout is
+// used if httpClient is nil, since zone files may take hours to download.
+func NewClient(authURL string, apiURL string, httpClient *http.Client) *Client {
+	if authURL == "" {
+		authURL = DefaultAuthURL
+	}
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: responseHeaderTimeout,
+			},
+		}
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		authURL:    strings.TrimSuffix(authURL, "/"),
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+	}
+}
+
+type authenticateReqJSON struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type authenticateRepJSON struct {
+	AccessToken string `json:"accessToken"`
+	Message     string `json:"message"`
+}
+
+// Authenticate retrieves the access token, which is valid for 24 hours.
+func (c *Client) Authenticate(username string, password string) error {
+	reqBody, err := json.Marshal(authenticateReqJSON{Username: username, Password: password})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.authURL+authenticatePath, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	rep, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("czds: authentication failed: %w", err)
+	}
+	defer rep.Body.Close()
+
+	jsonRep := authenticateRepJSON{}
+	err = json.NewDecoder(io.LimitReader(rep.Body, maxAPIReplyInBytes)).Decode(&jsonRep)
+	if rep.StatusCode != http.StatusOK {
+		return fmt.Errorf("czds: authentication failed with status code %d: %s", rep.StatusCode, jsonRep.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("czds: unable to parse authentication reply: %w", err)
+	}
+	if jsonRep.AccessToken == "" {
+		return fmt.Errorf("czds: authentication reply doesn't contain an access token")
+	}
+
+	c.accessToken = jsonRep.AccessToken
+	return nil
+}
+
+func (c *Client) newAuthorizedRequest(method string, requestURL string) (*http.Request, error) {
+	if c.accessToken == "" {
+		return nil, ErrUnauthorized
+	}
+
+	req, err := http.NewRequest(method, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	return req, nil
+}
+
+// ListZoneLinks returns the download links of the zone files, which the account is approved for.
+func (c *Client) ListZoneLinks() ([]string, error) {
+	req, err := c.newAuthorizedRequest(http.MethodGet, c.apiURL+downloadLinksPath)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	rep, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("czds: unable to list zone files: %w", err)
+	}
+	defer rep.Body.Close()
+
+	if rep.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+	if rep.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("czds: listing zone files failed with status code %d", rep.StatusCode)
+	}
+
+	links := make([]string, 0)
+	err = json.NewDecoder(io.LimitReader(rep.Body, maxAPIReplyInBytes)).Decode(&links)
+	if err != nil {
+		return nil, fmt.Errorf("czds: unable to parse zone file links: %w", err)
+	}
+	return links, nil
+}
+
+// GetZoneName returns the zone name of the download link, i.e. ".../czds/downloads/dev.zone" will return "dev".
+func GetZoneName(link string) string {
+	linkPath := link
+	parsedURL, err := url.Parse(link)
+	if err == nil {
+		linkPath = parsedURL.Path
+	}
+	return strings.ToLower(strings.TrimSuffix(path.Base(linkPath), ".zone"))
+}
+
+// DownloadState is persisted between downloads, so that unchanged zone files aren't downloaded again & interrupted
+// downloads can be resumed.
+type DownloadState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	// Expected size of the zone file, -1 if unknown
+	Size        int64 `json:"size"`
+	BIsComplete bool  `json:"b_is_complete"`
+}
+
+type Downloader struct {
+	client *Client
+	// Partially downloaded zone files & download states are kept here, so that they aren't mistaken for zone files
+	stateFolderPath string
+}
+
+func NewDownloader(client *Client, stateFolderPath string) *Downloader {
+	return &Downloader{client: client, stateFolderPath: stateFolderPath}
+}
+
+func (d *Downloader) getStateFilePath(zoneName string) string {
+	return filepath.Join(d.stateFolderPath, zoneName+".json")
+}
+
+func (d *Downloader) getPartFilePath(zoneName string) string {
+	return filepath.Join(d.stateFolderPath, zoneName+".part")
+}
+
+func (d *Downloader) loadState(zoneName string) DownloadState {
+	state := DownloadState{Size: -1}
+	stateBytes, err := os.ReadFile(d.getStateFilePath(zoneName))
+	if err != nil {
+		return state
+	}
+	if json.Unmarshal(stateBytes, &state) != nil {
+		return DownloadState{Size: -1}
+	}
+	return state
+}
+
+func (d *Downloader) saveState(zoneName string, state DownloadState) error {
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.getStateFilePath(zoneName), stateBytes, 0600)
+}
+
+func (d *Downloader) resetPartialDownload(zoneName string) error {
+	err := os.Remove(d.getPartFilePath(zoneName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err = os.Remove(d.getStateFilePath(zoneName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Download downloads the zone file of the link to zoneFilePath, unless it is unchanged since the last download.
+// Returns whether the zone file is updated.
+// Design Note: Zone files are only replaced once they are completely downloaded & their size is verified, so that
+// the pipeline never reads a partial zone file.
+func (d *Downloader) Download(link string, zoneFilePath string) (bool, error) {
+	err := os.MkdirAll(d.stateFolderPath, 0700)
+	if err != nil {
+		return false, err
+	}
+
+	zoneName := GetZoneName(link)
+	if zoneName == "" || strings.HasPrefix(zoneName, ".") {
+		return false, fmt.Errorf("czds: invalid zone file link %s", link)
+	}
+
+	state := d.loadState(zoneName)
+	if state.URL != link {
+		state = DownloadState{URL: link, Size: -1}
+	}
+
+	var partSize int64
+	if partInfo, err := os.Stat(d.getPartFilePath(zoneName)); err == nil && !state.BIsComplete {
+		partSize = partInfo.Size()
+	}
+	_, zoneFileErr := os.Stat(zoneFilePath)
+	bHasZoneFile := zoneFileErr == nil
+
+	req, err := d.client.newAuthorizedRequest(http.MethodGet, link)
+	if err != nil {
+		return false, err
+	}
+
+	validator := state.ETag
+	if validator == "" {
+		validator = state.LastModified
+	}
+	if partSize > 0 && validator != "" {
+		// Resume the interrupted download, server replies with the whole zone file if it has changed since
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", partSize))
+		req.Header.Set("If-Range", validator)
+	} else if state.BIsComplete && bHasZoneFile {
+		if state.ETag != "" {
+			req.Header.Set("If-None-Match", state.ETag)
+		}
+		if state.LastModified != "" {
+			req.Header.Set("If-Modified-Since", state.LastModified)
+		}
+	}
+
+	rep, err := d.client.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("czds: unable to download %s: %w", zoneName, err)
+	}
+	defer rep.Body.Close()
+
+	var partFile *os.File
+	switch rep.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusUnauthorized:
+		return false, ErrUnauthorized
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Partial zone file is corrupted, let's start from scratch on the next attempt
+		err = d.resetPartialDownload(zoneName)
+		if err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("czds: unable to resume download of %s, it will be restarted", zoneName)
+	case http.StatusOK:
+		state = DownloadState{
+			URL:          link,
+			ETag:         rep.Header.Get("ETag"),
+			LastModified: rep.Header.Get("Last-Modified"),
+			Size:         rep.ContentLength,
+		}
+		partFile, err = os.OpenFile(d.getPartFilePath(zoneName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	case http.StatusPartialContent:
+		start, size, parseErr := parseContentRange(rep.Header.Get("Content-Range"))
+		if parseErr != nil || start != partSize {
+			return false, fmt.Errorf("czds: invalid Content-Range reply for %s", zoneName)
+		}
+		if size >= 0 {
+			state.Size = size
+		}
+		partFile, err = os.OpenFile(d.getPartFilePath(zoneName), os.O_APPEND|os.O_WRONLY, 0600)
+	default:
+		return false, fmt.Errorf("czds: download of %s failed with status code %d", zoneName, rep.StatusCode)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// State is saved before downloading, so that an interrupted download can be resumed
+	state.BIsComplete = false
+	err = d.saveState(zoneName, state)
+	if err != nil {
+		partFile.Close()
+		return false, err
+	}
+
+	_, copyErr := io.Copy(partFile, rep.Body)
+	closeErr := partFile.Close()
+	if copyErr != nil {
+		return false, fmt.Errorf("czds: download of %s is interrupted, it will be resumed: %w", zoneName, copyErr)
+	}
+	if closeErr != nil {
+		return false, closeErr
+	}
+
+	// Verify the size
+	partInfo, err := os.Stat(d.getPartFilePath(zoneName))
+	if err != nil {
+		return false, err
+	}
+	if state.Size >= 0 && partInfo.Size() != state.Size {
+		if partInfo.Size() > state.Size {
+			err = d.resetPartialDownload(zoneName)
+			if err != nil {
+				return false, err
+			}
+		}
+		return false, fmt.Errorf(
+			"czds: size of %s is %d bytes, whereas %d bytes are expected", zoneName, partInfo.Size(), state.Size,
+		)
+	}
+
+	err = moveFile(d.getPartFilePath(zoneName), zoneFilePath)
+	if err != nil {
+		return false, err
+	}
+
+	state.BIsComplete = true
+	state.Size = partInfo.Size()
+	return true, d.saveState(zoneName, state)
+}
+
+// Overridden by the tests, as renames across file systems can't be set up there.
+var renameFile = os.Rename
+
+// Moves the file, copying it if the destination is on another file system (i.e. the cache & zone files folders are
+// separate volumes).
+func moveFile(srcFilePath string, dstFilePath string) error {
+	err := renameFile(srcFilePath, dstFilePath)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	srcFile, err := os.Open(srcFilePath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	// Design Note: The copy is renamed to the destination once it is complete, thus the destination is never a partial
+	// file. Copy is hidden, so that it isn't mistaken for a zone file by the users.
+	tmpFilePath := filepath.Join(filepath.Dir(dstFilePath), "."+filepath.Base(dstFilePath)+".tmp")
+	tmpFile, err := os.OpenFile(tmpFilePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(tmpFile, srcFile)
+	closeErr := tmpFile.Close()
+	if copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr == nil {
+		copyErr = os.Rename(tmpFilePath, dstFilePath)
+	}
+	if copyErr != nil {
+		_ = os.Remove(tmpFilePath)
+		return copyErr
+	}
+
+	return os.Remove(srcFilePath)
+}
+
+// Parses "bytes <start>-<end>/<size>", size is -1 if it is unknown.
+func parseContentRange(contentRange string) (int64, int64, error) {
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	rangeSpec := strings.TrimPrefix(contentRange, "bytes ")
+
+	byteRange, sizeString, found := strings.Cut(rangeSpec, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	startString, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+
+	start, err := strconv.ParseInt(startString, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	if sizeString == "*" {
+		return start, -1, nil
+	}
+	size, err := strconv.ParseInt(sizeString, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	return start, size, nil
+}
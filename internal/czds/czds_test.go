@@ -0,0 +1,283 @@
+package czds
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUsername    = "user@example.com"
+	testPassword    = "password"
+	testAccessToken = "token"
+	testETag        = `"v1"`
+)
+
+// Stand-in for the CZDS authentication & download APIs
+type testServer struct {
+	*httptest.Server
+
+	mutex       sync.Mutex
+	zoneFile    []byte
+	eTag        string
+	downloads   int
+	rangeStarts []string
+	// Number of bytes to send before dropping the connection, zero sends the whole zone file
+	interruptAfter int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	s := &testServer{
+		zoneFile: []byte(strings.Repeat("example.dev.\t3600\tin\tns\tns1.example.dev.\n", 100)),
+		eTag:     testETag,
+	}
+
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc(
+		authenticatePath, func(w http.ResponseWriter, r *http.Request) {
+			jsonReq := authenticateReqJSON{}
+			if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&jsonReq) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if jsonReq.Username != testUsername || jsonReq.Password != testPassword {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"message": "Invalid credentials"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"accessToken": "` + testAccessToken + `", "message": "Authentication Successful"}`))
+		},
+	)
+	serveMux.HandleFunc(
+		downloadLinksPath, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]string{s.URL + "/czds/downloads/dev.zone", s.URL + "/czds/downloads/app.zone"})
+		},
+	)
+	serveMux.HandleFunc(
+		"/czds/downloads/dev.zone", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			s.downloads++
+
+			if r.Header.Get("If-None-Match") == s.eTag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", s.eTag)
+			content := s.zoneFile
+			statusCode := http.StatusOK
+			if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && r.Header.Get("If-Range") == s.eTag {
+				startString := strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-")
+				s.rangeStarts = append(s.rangeStarts, startString)
+				start, _ := strconv.Atoi(startString)
+				if start >= len(s.zoneFile) {
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+					return
+				}
+				w.Header().Set(
+					"Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(s.zoneFile)-1, len(s.zoneFile)),
+				)
+				content = s.zoneFile[start:]
+				statusCode = http.StatusPartialContent
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(statusCode)
+
+			if s.interruptAfter > 0 {
+				_, _ = w.Write(content[:s.interruptAfter])
+				w.(http.Flusher).Flush()
+				s.interruptAfter = 0
+				// Let's drop the connection before sending the rest
+				panic(http.ErrAbortHandler)
+			}
+			_, _ = w.Write(content)
+		},
+	)
+
+	s.Server = httptest.NewServer(serveMux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestDownloader(t *testing.T, s *testServer) (*Downloader, string) {
+	client := NewClient(s.URL, s.URL, s.Client())
+	require.NoError(t, client.Authenticate(testUsername, testPassword))
+
+	folderPath := t.TempDir()
+	return NewDownloader(client, filepath.Join(folderPath, "czds")), filepath.Join(folderPath, "dev.txt.gz")
+}
+
+func TestGetZoneName(t *testing.T) {
+	assert.Equal(t, "dev", GetZoneName("https://czds-api.icann.org/czds/downloads/dev.zone"))
+	assert.Equal(t, "xn--p1ai", GetZoneName("https://czds-api.icann.org/czds/downloads/XN--P1AI.zone"))
+}
+
+func TestClient(t *testing.T) {
+	s := newTestServer(t)
+
+	client := NewClient(s.URL, s.URL, s.Client())
+	_, err := client.ListZoneLinks()
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	err = client.Authenticate(testUsername, "wrong")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid credentials")
+
+	require.NoError(t, client.Authenticate(testUsername, testPassword))
+	links, err := client.ListZoneLinks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{s.URL + "/czds/downloads/dev.zone", s.URL + "/czds/downloads/app.zone"}, links)
+}
+
+func TestDownload(t *testing.T) {
+	s := newTestServer(t)
+	downloader, zoneFilePath := newTestDownloader(t, s)
+	link := s.URL + "/czds/downloads/dev.zone"
+
+	bIsUpdated, err := downloader.Download(link, zoneFilePath)
+	require.NoError(t, err)
+	assert.True(t, bIsUpdated)
+	content, err := os.ReadFile(zoneFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, s.zoneFile, content)
+
+	// Unchanged zone files aren't downloaded again
+	bIsUpdated, err = downloader.Download(link, zoneFilePath)
+	require.NoError(t, err)
+	assert.False(t, bIsUpdated)
+
+	// Changed zone files are
+	s.zoneFile = []byte("example.dev.\t3600\tin\tns\tns2.example.dev.\n")
+	s.eTag = `"v2"`
+	bIsUpdated, err = downloader.Download(link, zoneFilePath)
+	require.NoError(t, err)
+	assert.True(t, bIsUpdated)
+	content, err = os.ReadFile(zoneFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, s.zoneFile, content)
+	assert.Equal(t, 3, s.downloads)
+}
+
+func TestDownloadAcrossFileSystems(t *testing.T) {
+	s := newTestServer(t)
+	downloader, zoneFilePath := newTestDownloader(t, s)
+	link := s.URL + "/czds/downloads/dev.zone"
+
+	renameFile = func(oldPath string, newPath string) error {
+		if filepath.Dir(oldPath) != filepath.Dir(newPath) {
+			return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: syscall.EXDEV}
+		}
+		return os.Rename(oldPath, newPath)
+	}
+	t.Cleanup(
+		func() {
+			renameFile = os.Rename
+		},
+	)
+
+	bIsUpdated, err := downloader.Download(link, zoneFilePath)
+	require.NoError(t, err)
+	assert.True(t, bIsUpdated)
+	content, err := os.ReadFile(zoneFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, s.zoneFile, content)
+
+	// Partial zone file is removed once it is copied, & the copy is renamed to the zone file
+	_, err = os.Stat(downloader.getPartFilePath("dev"))
+	assert.True(t, os.IsNotExist(err))
+	entries, err := os.ReadDir(filepath.Dir(zoneFilePath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2) // Zone file & download state folder
+}
+
+func TestResumeDownload(t *testing.T) {
+	s := newTestServer(t)
+	downloader, zoneFilePath := newTestDownloader(t, s)
+	link := s.URL + "/czds/downloads/dev.zone"
+
+	s.interruptAfter = 1000
+	bIsUpdated, err := downloader.Download(link, zoneFilePath)
+	require.Error(t, err)
+	assert.False(t, bIsUpdated)
+
+	// Partial zone files never replace the zone file
+	_, err = os.Stat(zoneFilePath)
+	assert.True(t, os.IsNotExist(err))
+
+	bIsUpdated, err = downloader.Download(link, zoneFilePath)
+	require.NoError(t, err)
+	assert.True(t, bIsUpdated)
+	assert.Equal(t, []string{"1000"}, s.rangeStarts)
+
+	content, err := os.ReadFile(zoneFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, s.zoneFile, content)
+}
+
+func TestDownloadRangeNotSatisfiable(t *testing.T) {
+	s := newTestServer(t)
+	downloader, zoneFilePath := newTestDownloader(t, s)
+	link := s.URL + "/czds/downloads/dev.zone"
+
+	// Let's corrupt the partial zone file, so that it is larger than the zone file
+	s.interruptAfter = 1000
+	_, err := downloader.Download(link, zoneFilePath)
+	require.Error(t, err)
+	partFile, err := os.OpenFile(downloader.getPartFilePath("dev"), os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = partFile.Write(s.zoneFile)
+	require.NoError(t, err)
+	require.NoError(t, partFile.Close())
+
+	_, err = downloader.Download(link, zoneFilePath)
+	require.Error(t, err)
+	_, err = os.Stat(zoneFilePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// Download is restarted from scratch afterwards
+	bIsUpdated, err := downloader.Download(link, zoneFilePath)
+	require.NoError(t, err)
+	assert.True(t, bIsUpdated)
+	assert.Len(t, s.rangeStarts, 1)
+	content, err := os.ReadFile(zoneFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, s.zoneFile, content)
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, size, err := parseContentRange("bytes 100-199/200")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(200), size)
+
+	start, size, err = parseContentRange("bytes 100-199/*")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(-1), size)
+
+	for _, contentRange := range []string{"", "bytes */200", "items 1-2/3", "bytes 1-2"} {
+		_, _, err = parseContentRange(contentRange)
+		assert.Error(t, err, contentRange)
+	}
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+)
+
+const (
+	clickLogKeyPrefix              = "click_log/"
+	clickStatsQueryDomainKeyPrefix = "click_stats/query_domain/"
+	clickStatsQueryKeyPrefix       = "click_stats/query/"
+	clickStatsDomainKeyPrefix      = "click_stats/domain/"
+	clickStatsTotalKey             = "click_stats/total"
+	clickDeduplicationKeyPrefix    = "click_dedup/"
+	clickTokenSecretKey            = "click_token_secret"
+)
+
+// ClickEvent is a single click on a search result, recorded for analytics.
+type ClickEvent struct {
+	Timestamp       time.Time
+	Query           string
+	NormalizedQuery string
+	IndexID         string
+	DomainName      string
+	// 1-based position of the result within the search results
+	Position int
+	// Salted hash of the client address, see GetQueryLogSalt
+	ClientHash string
+}
+
+type clickEventJSON struct {
+	TimestampInNanoseconds int64  `json:"timestamp_in_nanoseconds"`
+	Query                  string `json:"query"`
+	NormalizedQuery        string `json:"normalized_query"`
+	IndexID                string `json:"index_id"`
+	DomainName             string `json:"domain_name"`
+	Position               int    `json:"position"`
+	ClientHash             string `json:"client_hash"`
+}
+
+// SaveClickEvent records the click. Events are deleted after the retention duration, which needs to be positive as
+// the click log is disabled without a retention.
+func (d *Database) SaveClickEvent(event ClickEvent, retention time.Duration) error {
+	if retention <= 0 {
+		return fmt.Errorf("click log retention needs to be positive")
+	}
+
+	jsonBytes, err := json.Marshal(
+		clickEventJSON{
+			TimestampInNanoseconds: event.Timestamp.UnixNano(),
+			Query:                  event.Query,
+			NormalizedQuery:        event.NormalizedQuery,
+			IndexID:                event.IndexID,
+			DomainName:             event.DomainName,
+			Position:               event.Position,
+			ClientHash:             event.ClientHash,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	badgerEntry := badger.NewEntry(d.getTimestampedKey(clickLogKeyPrefix, event.Timestamp), jsonBytes).
+		WithTTL(retention)
+
+	return d.db.Update(
+		func(txn *badger.Txn) error {
+			return txn.SetEntry(badgerEntry)
+		},
+	)
+}
+
+// GetClickEvents returns the clicks recorded since the given time, in chronological order.
+func (d *Database) GetClickEvents(since time.Time) ([]ClickEvent, error) {
+	events := make([]ClickEvent, 0)
+	err := d.iterateTimestampedEntries(
+		clickLogKeyPrefix, since, func(val []byte) error {
+			eventJSON := clickEventJSON{}
+			err := json.Unmarshal(val, &eventJSON)
+			if err != nil {
+				return err
+			}
+
+			events = append(
+				events, ClickEvent{
+					Timestamp:       time.Unix(0, eventJSON.TimestampInNanoseconds),
+					Query:           eventJSON.Query,
+					NormalizedQuery: eventJSON.NormalizedQuery,
+					IndexID:         eventJSON.IndexID,
+					DomainName:      eventJSON.DomainName,
+					Position:        eventJSON.Position,
+					ClientHash:      eventJSON.ClientHash,
+				},
+			)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetClickTokenSecret returns the secret of the click tokens, which is created on first use.
+func (d *Database) GetClickTokenSecret() (string, error) {
+	return d.getOrCreateSecret(clickTokenSecretKey)
+}
+
+// MarkClickSeen marks the click for the deduplication window. Returns false if the click was already marked within
+// the window. Keys are expected to be hashed, so that they don't carry any client data.
+func (d *Database) MarkClickSeen(deduplicationKey string, window time.Duration) (bool, error) {
+	d.clickStatsMutex.Lock()
+	defer d.clickStatsMutex.Unlock()
+
+	bIsNew := false
+	err := d.db.Update(
+		func(txn *badger.Txn) error {
+			key := []byte(clickDeduplicationKeyPrefix + deduplicationKey)
+			_, err := txn.Get(key)
+			if err == nil {
+				return nil
+			} else if err != badger.ErrKeyNotFound {
+				return err
+			}
+
+			bIsNew = true
+			return txn.SetEntry(badger.NewEntry(key, []byte{}).WithTTL(window))
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return bIsNew, nil
+}
+
+// Design Note: Domain names can't contain NUL, thus it safely separates the query from the domain name.
+func getClickStatsKeys(normalizedQuery string, domainName string) (
+	queryDomainKey []byte, queryKey []byte, domainKey []byte,
+) {
+	return []byte(clickStatsQueryDomainKeyPrefix + normalizedQuery + "\x00" + domainName),
+		[]byte(clickStatsQueryKeyPrefix + normalizedQuery),
+		[]byte(clickStatsDomainKeyPrefix + domainName)
+}
+
+func getFloatValue(txn *badger.Txn, key []byte) (float64, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(val), 64)
+}
+
+// AddClickStats adds the weighted click of the domain to the click stats of the query. Unlike click events, click
+// stats are kept forever.
+func (d *Database) AddClickStats(normalizedQuery string, domainName string, weight float64) error {
+	d.clickStatsMutex.Lock()
+	defer d.clickStatsMutex.Unlock()
+
+	queryDomainKey, queryKey, domainKey := getClickStatsKeys(normalizedQuery, domainName)
+	return d.db.Update(
+		func(txn *badger.Txn) error {
+			for _, key := range [][]byte{queryDomainKey, queryKey, domainKey, []byte(clickStatsTotalKey)} {
+				value, err := getFloatValue(txn, key)
+				if err != nil {
+					return err
+				}
+
+				err = txn.Set(key, []byte(strconv.FormatFloat(value+weight, 'g', -1, 64)))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// GetClickStats returns the click stats of the domain for the query.
+func (d *Database) GetClickStats(normalizedQuery string, domainName string) (common.ClickStats, error) {
+	clickStats := common.ClickStats{}
+	queryDomainKey, queryKey, domainKey := getClickStatsKeys(normalizedQuery, domainName)
+	err := d.db.View(
+		func(txn *badger.Txn) error {
+			var err error
+			clickStats.QueryDomainClicks, err = getFloatValue(txn, queryDomainKey)
+			if err != nil {
+				return err
+			}
+			clickStats.QueryClicks, err = getFloatValue(txn, queryKey)
+			if err != nil {
+				return err
+			}
+			clickStats.DomainClicks, err = getFloatValue(txn, domainKey)
+			if err != nil {
+				return err
+			}
+			clickStats.TotalClicks, err = getFloatValue(txn, []byte(clickStatsTotalKey))
+			return err
+		},
+	)
+	if err != nil {
+		return common.ClickStats{}, err
+	}
+	return clickStats, nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"encoding/json"
+	"sync"
 
 	badger "github.com/dgraph-io/badger/v3"
 	"go.uber.org/zap"
@@ -11,6 +12,12 @@ const dbSchemeVersion = "1.0"
 
 type Database struct {
 	db *badger.DB
+
+	// Needs to be accessed atomically
+	timestampedKeySequence uint64
+
+	// Serializes click stats updates, which are read-modify-write operations
+	clickStatsMutex sync.Mutex
 }
 
 func NewDatabase(dbPath string) (*Database, error) {
@@ -184,6 +191,26 @@ func (d *Database) GetIndexerTaskState(zoneName string) IndexerTaskState {
 	return taskState
 }
 
+// DeleteZoneTaskStates deletes all task states of the zone, so that the zone will be processed from scratch.
+func (d *Database) DeleteZoneTaskStates(zoneName string) error {
+	return d.db.Update(
+		func(txn *badger.Txn) error {
+			for _, key := range []string{
+				zoneName + "_pre_crawl_filter_task_state_json",
+				zoneName + "_post_crawl_filter_task_state_json",
+				zoneName + "_" + "indexer_task_state_json",
+				getSavedSearchZoneLineIndexKey(zoneName),
+			} {
+				err := txn.Delete([]byte(key))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
 func (d *Database) getString(key string) (string, error) {
 	var value string
 	err := d.db.View(
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+const (
+	queryLogKeyPrefix = "query_log/"
+	queryLogSaltKey   = "query_log_salt"
+)
+
+// QueryLogEntry is a single search query, recorded for analytics.
+type QueryLogEntry struct {
+	Timestamp       time.Time
+	Query           string
+	NormalizedQuery string
+	Latency         time.Duration
+	ResultCount     uint64
+	// Salted hash of the client address, see GetQueryLogSalt
+	ClientHash string
+}
+
+type queryLogEntryJSON struct {
+	TimestampInNanoseconds int64  `json:"timestamp_in_nanoseconds"`
+	Query                  string `json:"query"`
+	NormalizedQuery        string `json:"normalized_query"`
+	LatencyInMicroseconds  int64  `json:"latency_in_microseconds"`
+	ResultCount            uint64 `json:"result_count"`
+	ClientHash             string `json:"client_hash"`
+}
+
+// Design Note: Keys start with the zero-padded timestamp, thus logs can be iterated in chronological order & from a
+// given point in time. Sequence prevents collisions of the entries recorded at the same time.
+func (d *Database) getTimestampedKey(prefix string, timestamp time.Time) []byte {
+	sequence := atomic.AddUint64(&d.timestampedKeySequence, 1)
+	return []byte(fmt.Sprintf("%s%020d/%020d", prefix, timestamp.UnixNano(), sequence))
+}
+
+// SaveQueryLogEntry records the query. Entries are deleted after the retention duration, which needs to be positive
+// as the query log is disabled without a retention.
+func (d *Database) SaveQueryLogEntry(entry QueryLogEntry, retention time.Duration) error {
+	if retention <= 0 {
+		return fmt.Errorf("query log retention needs to be positive")
+	}
+
+	jsonBytes, err := json.Marshal(
+		queryLogEntryJSON{
+			TimestampInNanoseconds: entry.Timestamp.UnixNano(),
+			Query:                  entry.Query,
+			NormalizedQuery:        entry.NormalizedQuery,
+			LatencyInMicroseconds:  entry.Latency.Microseconds(),
+			ResultCount:            entry.ResultCount,
+			ClientHash:             entry.ClientHash,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	badgerEntry := badger.NewEntry(d.getTimestampedKey(queryLogKeyPrefix, entry.Timestamp), jsonBytes).
+		WithTTL(retention)
+
+	return d.db.Update(
+		func(txn *badger.Txn) error {
+			return txn.SetEntry(badgerEntry)
+		},
+	)
+}
+
+// Calls the callback with the values of the timestamped keys of the prefix, recorded since the given time.
+func (d *Database) iterateTimestampedEntries(prefix string, since time.Time, callback func(val []byte) error) error {
+	return d.db.View(
+		func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = []byte(prefix)
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Seek([]byte(fmt.Sprintf("%s%020d", prefix, since.UnixNano()))); it.Valid(); it.Next() {
+				err := it.Item().Value(callback)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// GetQueryLogEntries returns the queries recorded since the given time, in chronological order.
+func (d *Database) GetQueryLogEntries(since time.Time) ([]QueryLogEntry, error) {
+	entries := make([]QueryLogEntry, 0)
+	err := d.iterateTimestampedEntries(
+		queryLogKeyPrefix, since, func(val []byte) error {
+			entryJSON := queryLogEntryJSON{}
+			err := json.Unmarshal(val, &entryJSON)
+			if err != nil {
+				return err
+			}
+
+			entries = append(
+				entries, QueryLogEntry{
+					Timestamp:       time.Unix(0, entryJSON.TimestampInNanoseconds),
+					Query:           entryJSON.Query,
+					NormalizedQuery: entryJSON.NormalizedQuery,
+					Latency:         time.Duration(entryJSON.LatencyInMicroseconds) * time.Microsecond,
+					ResultCount:     entryJSON.ResultCount,
+					ClientHash:      entryJSON.ClientHash,
+				},
+			)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetQueryLogSalt returns the salt of the client hashes, which is created on first use. Salting prevents recovering
+// client addresses by hashing the whole address space.
+func (d *Database) GetQueryLogSalt() (string, error) {
+	return d.getOrCreateSecret(queryLogSaltKey)
+}
+
+// Returns the random secret stored under the key, which is created on first use.
+func (d *Database) getOrCreateSecret(key string) (string, error) {
+	var secret string
+	err := d.db.Update(
+		func(txn *badger.Txn) error {
+			item, err := txn.Get([]byte(key))
+			if err == nil {
+				val, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				secret = string(val)
+				return nil
+			} else if err != badger.ErrKeyNotFound {
+				return err
+			}
+
+			secretBytes := make([]byte, 32)
+			_, err = rand.Read(secretBytes)
+			if err != nil {
+				return err
+			}
+			secret = hex.EncodeToString(secretBytes)
+			return txn.Set([]byte(key), []byte(secret))
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	return secret, nil
+}
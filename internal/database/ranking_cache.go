@@ -0,0 +1,227 @@
+package database
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+)
+
+const (
+	rankingCacheKeyPrefix = "ranking_cache/"
+	indexGenerationKey    = "index_generation"
+)
+
+// RankingCacheEntry is the ranked output of a query, before pagination.
+type RankingCacheEntry struct {
+	TotalHits uint64
+	Results   []common.DomainProperties
+}
+
+// Design Note: DomainProperties JSON representation is flat, thus integral float properties (i.e. a score of 1.0)
+// would be read back as int properties. Cached entries need to keep property types intact.
+type rankingCacheDomainPropertiesJSON struct {
+	DomainName       string             `json:"domain_name"`
+	StringProperties map[string]string  `json:"string_properties"`
+	IntProperties    map[string]int64   `json:"int_properties"`
+	FloatProperties  map[string]float64 `json:"float_properties"`
+	BoolProperties   map[string]bool    `json:"bool_properties"`
+}
+
+type rankingCacheEntryJSON struct {
+	TotalHits uint64                             `json:"total_hits"`
+	Results   []rankingCacheDomainPropertiesJSON `json:"results"`
+}
+
+func (e *RankingCacheEntry) toJSON() rankingCacheEntryJSON {
+	entryJSON := rankingCacheEntryJSON{
+		TotalHits: e.TotalHits,
+		Results:   make([]rankingCacheDomainPropertiesJSON, 0, len(e.Results)),
+	}
+	for _, domainProperties := range e.Results {
+		entryJSON.Results = append(entryJSON.Results, rankingCacheDomainPropertiesJSON(domainProperties))
+	}
+	return entryJSON
+}
+
+func (e *rankingCacheEntryJSON) toEntry() RankingCacheEntry {
+	entry := RankingCacheEntry{
+		TotalHits: e.TotalHits,
+		Results:   make([]common.DomainProperties, 0, len(e.Results)),
+	}
+	for _, domainPropertiesJSON := range e.Results {
+		domainProperties := common.DomainProperties(domainPropertiesJSON)
+		if domainProperties.StringProperties == nil {
+			domainProperties.StringProperties = make(map[string]string)
+		}
+		if domainProperties.IntProperties == nil {
+			domainProperties.IntProperties = make(map[string]int64)
+		}
+		if domainProperties.FloatProperties == nil {
+			domainProperties.FloatProperties = make(map[string]float64)
+		}
+		if domainProperties.BoolProperties == nil {
+			domainProperties.BoolProperties = make(map[string]bool)
+		}
+		entry.Results = append(entry.Results, domainProperties)
+	}
+	return entry
+}
+
+// GetRankingCacheEntry returns the cached ranking of the key. Expired entries are never returned.
+func (d *Database) GetRankingCacheEntry(key string) (RankingCacheEntry, bool, error) {
+	entryJSON := rankingCacheEntryJSON{}
+	bIsFound := false
+	err := d.db.View(
+		func(txn *badger.Txn) error {
+			item, err := txn.Get([]byte(rankingCacheKeyPrefix + key))
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			bIsFound = true
+			return item.Value(
+				func(val []byte) error {
+					return json.Unmarshal(val, &entryJSON)
+				},
+			)
+		},
+	)
+	if err != nil {
+		return RankingCacheEntry{}, false, err
+	}
+	return entryJSON.toEntry(), bIsFound, nil
+}
+
+// SaveRankingCacheEntry caches the ranking of the key for the TTL duration. Once there are more than maxEntries
+// entries, entries closest to expiry are evicted. Zero maxEntries disables the eviction.
+func (d *Database) SaveRankingCacheEntry(key string, entry RankingCacheEntry, ttl time.Duration, maxEntries int) error {
+	jsonBytes, err := json.Marshal(entry.toJSON())
+	if err != nil {
+		return err
+	}
+
+	err = d.db.Update(
+		func(txn *badger.Txn) error {
+			return txn.SetEntry(badger.NewEntry([]byte(rankingCacheKeyPrefix+key), jsonBytes).WithTTL(ttl))
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if maxEntries <= 0 {
+		return nil
+	}
+	return d.evictRankingCacheEntries(maxEntries)
+}
+
+// Design Note: Expiry times have a resolution of seconds, thus entries expiring within the same second are ordered by
+// their commit version, i.e. the oldest one is evicted first.
+func (d *Database) evictRankingCacheEntries(maxEntries int) error {
+	type keyExpiry struct {
+		key       []byte
+		expiresAt uint64
+		version   uint64
+	}
+
+	keyExpiries := make([]keyExpiry, 0)
+	err := d.db.View(
+		func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchValues = false
+			opts.Prefix = []byte(rankingCacheKeyPrefix)
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Rewind(); it.Valid(); it.Next() {
+				item := it.Item()
+				keyExpiries = append(
+					keyExpiries, keyExpiry{key: item.KeyCopy(nil), expiresAt: item.ExpiresAt(), version: item.Version()},
+				)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(keyExpiries) <= maxEntries {
+		return nil
+	}
+
+	sort.Slice(
+		keyExpiries, func(i, j int) bool {
+			if keyExpiries[i].expiresAt != keyExpiries[j].expiresAt {
+				return keyExpiries[i].expiresAt < keyExpiries[j].expiresAt
+			}
+			return keyExpiries[i].version < keyExpiries[j].version
+		},
+	)
+
+	return d.db.Update(
+		func(txn *badger.Txn) error {
+			for _, item := range keyExpiries[:len(keyExpiries)-maxEntries] {
+				err := txn.Delete(item.key)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// PurgeRankingCache deletes all cached rankings.
+func (d *Database) PurgeRankingCache() error {
+	return d.db.DropPrefix([]byte(rankingCacheKeyPrefix))
+}
+
+// GetIndexGeneration returns the index generation, which is incremented whenever the index changes.
+func (d *Database) GetIndexGeneration() (int64, error) {
+	generationString, err := d.getString(indexGenerationKey)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(generationString, 10, 64)
+}
+
+// IncrementIndexGeneration increments & returns the index generation.
+func (d *Database) IncrementIndexGeneration() (int64, error) {
+	var generation int64
+	err := d.db.Update(
+		func(txn *badger.Txn) error {
+			item, err := txn.Get([]byte(indexGenerationKey))
+			if err == nil {
+				val, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				generation, err = strconv.ParseInt(string(val), 10, 64)
+				if err != nil {
+					return err
+				}
+			} else if err != badger.ErrKeyNotFound {
+				return err
+			}
+
+			generation++
+			return txn.Set([]byte(indexGenerationKey), []byte(strconv.FormatInt(generation, 10)))
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return generation, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+const (
+	savedSearchKeyPrefix        = "saved_search/"
+	savedSearchAlertedKeyPrefix = "saved_search_alerted/"
+)
+
+// SavedSearch is a query whose newly indexed matches are delivered as alerts.
+type SavedSearch struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Query  string   `json:"query"`
+	Facets []string `json:"facets"`
+	// Alerts are appended to the alerts file unless a webhook URL is set
+	WebhookURL string    `json:"webhook_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func getSavedSearchZoneLineIndexKey(zoneName string) string {
+	return zoneName + "_saved_search_line_index"
+}
+
+// SaveSavedSearch creates or replaces the saved search.
+func (d *Database) SaveSavedSearch(savedSearch SavedSearch) error {
+	jsonBytes, err := json.Marshal(savedSearch)
+	if err != nil {
+		return err
+	}
+	return d.setString(savedSearchKeyPrefix+savedSearch.ID, string(jsonBytes))
+}
+
+// GetSavedSearches returns all saved searches, ordered by their creation time.
+func (d *Database) GetSavedSearches() ([]SavedSearch, error) {
+	savedSearches := make([]SavedSearch, 0)
+	err := d.db.View(
+		func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = []byte(savedSearchKeyPrefix)
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Rewind(); it.Valid(); it.Next() {
+				savedSearch := SavedSearch{}
+				err := it.Item().Value(
+					func(val []byte) error {
+						return json.Unmarshal(val, &savedSearch)
+					},
+				)
+				if err != nil {
+					return err
+				}
+				savedSearches = append(savedSearches, savedSearch)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(
+		savedSearches, func(i, j int) bool {
+			return savedSearches[i].CreatedAt.Before(savedSearches[j].CreatedAt)
+		},
+	)
+	return savedSearches, nil
+}
+
+// DeleteSavedSearch deletes the saved search along with its alert history. Returns false if it doesn't exist.
+func (d *Database) DeleteSavedSearch(id string) (bool, error) {
+	bIsFound := false
+	err := d.db.Update(
+		func(txn *badger.Txn) error {
+			_, err := txn.Get([]byte(savedSearchKeyPrefix + id))
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			bIsFound = true
+			return txn.Delete([]byte(savedSearchKeyPrefix + id))
+		},
+	)
+	if err != nil || !bIsFound {
+		return false, err
+	}
+
+	return true, d.db.DropPrefix([]byte(savedSearchAlertedKeyPrefix + id + "/"))
+}
+
+// MarkSavedSearchAlerted records the domains as alerted for the saved search & returns the ones which weren't alerted
+// before, so that domains are alerted only once even when they are indexed again.
+func (d *Database) MarkSavedSearchAlerted(id string, domainNames []string) ([]string, error) {
+	newDomainNames := make([]string, 0)
+	err := d.db.Update(
+		func(txn *badger.Txn) error {
+			for _, domainName := range domainNames {
+				key := []byte(savedSearchAlertedKeyPrefix + id + "/" + domainName)
+				_, err := txn.Get(key)
+				if err == nil {
+					continue
+				}
+				if err != badger.ErrKeyNotFound {
+					return err
+				}
+
+				err = txn.Set(key, nil)
+				if err != nil {
+					return err
+				}
+				newDomainNames = append(newDomainNames, domainName)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return newDomainNames, nil
+}
+
+// GetSavedSearchZoneLineIndex returns the post-crawl cache file line of the zone, up to which saved searches are
+// evaluated.
+func (d *Database) GetSavedSearchZoneLineIndex(zoneName string) (int, error) {
+	lineIndexString, err := d.getString(getSavedSearchZoneLineIndexKey(zoneName))
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(lineIndexString)
+}
+
+func (d *Database) SaveSavedSearchZoneLineIndex(zoneName string, lineIndex int) error {
+	return d.setString(getSavedSearchZoneLineIndexKey(zoneName), strconv.Itoa(lineIndex))
+}
@@ -0,0 +1,372 @@
+package database
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+// Design Note: The domain set of the last processed zone snapshot is kept as "zone_snapshot/<zone>/<version>/<domain>"
+// keys, whose values are the hashes of the NS records of the domains. A new snapshot is first written as
+// "zone_snapshot_record/<zone>/<domain>\x00<type>\x00<data>" keys, so that both snapshots can be iterated in the
+// same order & merge-joined without loading large zones (i.e. .com) into the memory. The new snapshot is written
+// with the next version, thus the previous one stays intact until the new one is committed.
+const (
+	zoneSnapshotKeyPrefix       = "zone_snapshot/"
+	zoneSnapshotRecordKeyPrefix = "zone_snapshot_record/"
+	zoneSnapshotStateKeyPrefix  = "zone_snapshot_state/"
+
+	zoneSnapshotKeySeparator = "\x00"
+)
+
+type ZoneSnapshotChangeType int
+
+const (
+	ZoneSnapshotDomainAdded ZoneSnapshotChangeType = iota
+	ZoneSnapshotDomainChanged
+	ZoneSnapshotDomainRemoved
+)
+
+// ZoneSnapshotState is the state of the last committed snapshot of a zone.
+type ZoneSnapshotState struct {
+	Version int `json:"version"`
+	// Identifies the zone file the snapshot was built from, i.e. its size & modification time
+	Fingerprint string `json:"fingerprint"`
+	// Delta of the snapshot is committed, yet not applied to the index & task states
+	BHasPendingDelta bool `json:"b_has_pending_delta"`
+}
+
+// ZoneTaskStates are the task states of all pipeline stages of a zone.
+type ZoneTaskStates struct {
+	PreCrawlFilter  PreCrawlFilterTaskState
+	PostCrawlFilter PostCrawlFilterTaskState
+	Indexer         IndexerTaskState
+}
+
+type ZoneSnapshotRecord struct {
+	DomainName string
+	TTL        uint32
+	Class      string
+	Type       string
+	Data       string
+}
+
+// ZoneSnapshotChange is a domain which was added, removed or whose NS records were changed in the new snapshot.
+type ZoneSnapshotChange struct {
+	Type       ZoneSnapshotChangeType
+	DomainName string
+	// Records of the domain in the new snapshot, empty for removed domains
+	Records []ZoneSnapshotRecord
+}
+
+// ZoneSnapshotBuilder writes a new snapshot of a zone & compares it with the last committed one.
+type ZoneSnapshotBuilder struct {
+	d          *Database
+	zoneName   string
+	state      ZoneSnapshotState
+	bHasState  bool
+	writeBatch *badger.WriteBatch
+}
+
+func getZoneSnapshotVersionPrefix(zoneName string, version int) []byte {
+	return []byte(zoneSnapshotKeyPrefix + zoneName + "/" + strconv.Itoa(version) + "/")
+}
+
+func getZoneSnapshotRecordPrefix(zoneName string) []byte {
+	return []byte(zoneSnapshotRecordKeyPrefix + zoneName + "/")
+}
+
+// GetZoneSnapshotState returns the state of the last committed snapshot of the zone. Returns false if the zone
+// doesn't have a snapshot yet.
+func (d *Database) GetZoneSnapshotState(zoneName string) (ZoneSnapshotState, bool, error) {
+	state := ZoneSnapshotState{}
+	stateString, err := d.getString(zoneSnapshotStateKeyPrefix + zoneName)
+	if err == badger.ErrKeyNotFound {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, err
+	}
+
+	err = json.Unmarshal([]byte(stateString), &state)
+	if err != nil {
+		return state, false, err
+	}
+	return state, true, nil
+}
+
+// NewZoneSnapshotBuilder starts a new snapshot of the zone. Leftovers of an uncommitted snapshot are dropped.
+func (d *Database) NewZoneSnapshotBuilder(zoneName string) (*ZoneSnapshotBuilder, error) {
+	state, bHasState, err := d.GetZoneSnapshotState(zoneName)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &ZoneSnapshotBuilder{d: d, zoneName: zoneName, state: state, bHasState: bHasState}
+	err = b.dropUncommitted()
+	if err != nil {
+		return nil, err
+	}
+
+	b.writeBatch = d.db.NewWriteBatch()
+	return b, nil
+}
+
+func (b *ZoneSnapshotBuilder) getNextVersion() int {
+	if !b.bHasState {
+		return 0
+	}
+	return b.state.Version + 1
+}
+
+func (b *ZoneSnapshotBuilder) dropUncommitted() error {
+	err := b.d.db.DropPrefix(getZoneSnapshotRecordPrefix(b.zoneName))
+	if err != nil {
+		return err
+	}
+	return b.d.db.DropPrefix(getZoneSnapshotVersionPrefix(b.zoneName, b.getNextVersion()))
+}
+
+// AddRecord adds the record to the new snapshot. Duplicate records are stored once.
+func (b *ZoneSnapshotBuilder) AddRecord(record ZoneSnapshotRecord) error {
+	key := string(getZoneSnapshotRecordPrefix(b.zoneName)) + record.DomainName + zoneSnapshotKeySeparator +
+		record.Type + zoneSnapshotKeySeparator + record.Data
+	value := strconv.FormatUint(uint64(record.TTL), 10) + zoneSnapshotKeySeparator + record.Class
+	return b.writeBatch.Set([]byte(key), []byte(value))
+}
+
+// Reads the records of the next domain from the record iterator & returns the hash of its NS records.
+func readNextZoneSnapshotDomain(it *badger.Iterator, prefixLength int) (string, []ZoneSnapshotRecord, uint64, error) {
+	domainName := ""
+	records := make([]ZoneSnapshotRecord, 0)
+	hash := fnv.New64a()
+	for ; it.Valid(); it.Next() {
+		key := string(it.Item().Key()[prefixLength:])
+		keyParts := strings.SplitN(key, zoneSnapshotKeySeparator, 3)
+		if len(keyParts) != 3 {
+			continue
+		}
+		if len(records) > 0 && keyParts[0] != domainName {
+			break
+		}
+		domainName = keyParts[0]
+
+		record := ZoneSnapshotRecord{DomainName: domainName, Type: keyParts[1], Data: keyParts[2]}
+		err := it.Item().Value(
+			func(val []byte) error {
+				ttl, class, _ := strings.Cut(string(val), zoneSnapshotKeySeparator)
+				parsedTTL, err := strconv.ParseUint(ttl, 10, 32)
+				if err != nil {
+					return err
+				}
+				record.TTL = uint32(parsedTTL)
+				record.Class = class
+				return nil
+			},
+		)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		records = append(records, record)
+
+		// Records are iterated in the order of their data, thus the hash doesn't depend on the zone file order
+		if record.Type == "ns" {
+			hash.Write([]byte(record.Data + "\n"))
+		}
+	}
+	return domainName, records, hash.Sum64(), nil
+}
+
+// Diff compares the new snapshot with the last committed one & calls the callback for each added, removed or changed
+// domain, in the order of the domain names. Domains whose NS records are changed are reported as changed. All domains
+// are reported as added if the zone doesn't have a snapshot yet.
+func (b *ZoneSnapshotBuilder) Diff(callback func(change ZoneSnapshotChange) error) error {
+	err := b.writeBatch.Flush()
+	if err != nil {
+		return err
+	}
+
+	newVersionPrefix := getZoneSnapshotVersionPrefix(b.zoneName, b.getNextVersion())
+	writeBatch := b.d.db.NewWriteBatch()
+	defer writeBatch.Cancel()
+	setDomainHash := func(domainName string, hash uint64) error {
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, hash)
+		return writeBatch.Set(append(append([]byte{}, newVersionPrefix...), domainName...), value)
+	}
+
+	err = b.d.db.View(
+		func(txn *badger.Txn) error {
+			recordOpts := badger.DefaultIteratorOptions
+			recordOpts.Prefix = getZoneSnapshotRecordPrefix(b.zoneName)
+			recordIt := txn.NewIterator(recordOpts)
+			defer recordIt.Close()
+
+			// Design Note: A zone without a snapshot is compared against an empty version prefix.
+			oldOpts := badger.DefaultIteratorOptions
+			oldOpts.Prefix = getZoneSnapshotVersionPrefix(b.zoneName, b.state.Version)
+			if !b.bHasState {
+				oldOpts.Prefix = newVersionPrefix
+			}
+			oldIt := txn.NewIterator(oldOpts)
+			defer oldIt.Close()
+
+			recordIt.Rewind()
+			oldIt.Rewind()
+			bHasNewDomain := recordIt.Valid()
+			newDomainName, records, newHash, err := readNextZoneSnapshotDomain(recordIt, len(recordOpts.Prefix))
+			if err != nil {
+				return err
+			}
+
+			for bHasNewDomain || oldIt.Valid() {
+				oldDomainName := ""
+				if oldIt.Valid() {
+					oldDomainName = string(oldIt.Item().Key()[len(oldOpts.Prefix):])
+				}
+
+				var change *ZoneSnapshotChange
+				switch {
+				case bHasNewDomain && (!oldIt.Valid() || newDomainName < oldDomainName):
+					change = &ZoneSnapshotChange{
+						Type: ZoneSnapshotDomainAdded, DomainName: newDomainName, Records: records,
+					}
+				case oldIt.Valid() && (!bHasNewDomain || oldDomainName < newDomainName):
+					change = &ZoneSnapshotChange{Type: ZoneSnapshotDomainRemoved, DomainName: oldDomainName}
+				default:
+					oldHash, err := oldIt.Item().ValueCopy(nil)
+					if err != nil {
+						return err
+					}
+					if len(oldHash) != 8 || binary.BigEndian.Uint64(oldHash) != newHash {
+						change = &ZoneSnapshotChange{
+							Type: ZoneSnapshotDomainChanged, DomainName: newDomainName, Records: records,
+						}
+					}
+					oldIt.Next()
+				}
+
+				if change != nil {
+					err = callback(*change)
+					if err != nil {
+						return err
+					}
+				}
+
+				if change != nil && change.Type == ZoneSnapshotDomainRemoved {
+					oldIt.Next()
+					continue
+				}
+
+				// The new domain is either added, changed or the same
+				err = setDomainHash(newDomainName, newHash)
+				if err != nil {
+					return err
+				}
+				bHasNewDomain = recordIt.Valid()
+				newDomainName, records, newHash, err = readNextZoneSnapshotDomain(recordIt, len(recordOpts.Prefix))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return writeBatch.Flush()
+}
+
+// Commit replaces the last committed snapshot of the zone with the new one. Snapshots with a delta are committed as
+// pending, until the delta is applied via FinishZoneSnapshotDelta.
+func (b *ZoneSnapshotBuilder) Commit(fingerprint string, bHasPendingDelta bool) error {
+	state := ZoneSnapshotState{
+		Version: b.getNextVersion(), Fingerprint: fingerprint, BHasPendingDelta: bHasPendingDelta,
+	}
+	jsonBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	err = b.d.setString(zoneSnapshotStateKeyPrefix+b.zoneName, string(jsonBytes))
+	if err != nil {
+		return err
+	}
+
+	if b.bHasState {
+		err = b.d.db.DropPrefix(getZoneSnapshotVersionPrefix(b.zoneName, b.state.Version))
+		if err != nil {
+			return err
+		}
+	}
+	b.state = state
+	b.bHasState = true
+	return b.d.db.DropPrefix(getZoneSnapshotRecordPrefix(b.zoneName))
+}
+
+// Discard drops the new snapshot, the last committed one is kept as is.
+func (b *ZoneSnapshotBuilder) Discard() error {
+	b.writeBatch.Cancel()
+	return b.dropUncommitted()
+}
+
+// FinishZoneSnapshotDelta marks the pending delta of the zone as applied. Task states, if given, are saved within the
+// same transaction, so that the delta is fed through the pipeline exactly once.
+func (d *Database) FinishZoneSnapshotDelta(zoneName string, taskStates *ZoneTaskStates) error {
+	state, bHasState, err := d.GetZoneSnapshotState(zoneName)
+	if err != nil {
+		return err
+	}
+	if !bHasState {
+		return fmt.Errorf("zone %s doesn't have a snapshot", zoneName)
+	}
+	state.BHasPendingDelta = false
+
+	values := make(map[string]interface{})
+	values[zoneSnapshotStateKeyPrefix+zoneName] = state
+	if taskStates != nil {
+		values[zoneName+"_pre_crawl_filter_task_state_json"] = taskStates.PreCrawlFilter
+		values[zoneName+"_post_crawl_filter_task_state_json"] = taskStates.PostCrawlFilter
+		values[zoneName+"_"+"indexer_task_state_json"] = taskStates.Indexer
+	}
+
+	return d.db.Update(
+		func(txn *badger.Txn) error {
+			for key, value := range values {
+				jsonBytes, err := json.Marshal(value)
+				if err != nil {
+					return err
+				}
+				err = txn.Set([]byte(key), jsonBytes)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// DeleteZoneSnapshot deletes all snapshots of the zone, so that the next one will be treated as the first one.
+func (d *Database) DeleteZoneSnapshot(zoneName string) error {
+	err := d.db.Update(
+		func(txn *badger.Txn) error {
+			return txn.Delete([]byte(zoneSnapshotStateKeyPrefix + zoneName))
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = d.db.DropPrefix([]byte(zoneSnapshotKeyPrefix + zoneName + "/"))
+	if err != nil {
+		return err
+	}
+	return d.db.DropPrefix(getZoneSnapshotRecordPrefix(zoneName))
+}
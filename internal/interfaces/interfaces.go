@@ -7,8 +7,35 @@ import (
 
 	"github.com/anthony-ozdemir/zfse/internal/common"
 	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
 )
 
+// SeedSource reads the seed domains of a file, i.e. a DNS zone file or a curated domain list. Seed sources are declared
+// per file or directory, thus the options of the declaration are passed to Open.
+type SeedSource interface {
+	// Open returns a reader over the seeds of the file. Relative names are completed with the zone name.
+	Open(filePath string, zoneName string, config config.TaskHandlerOptions) (SeedReader, error)
+
+	GetType() string
+}
+
+type SeedReader interface {
+	// Next returns the next seed. Returns io.EOF once all seeds are read, and *common.SeedParseError for malformed
+	// seeds, which are skipped.
+	Next() (*common.Seed, error)
+
+	// GetLineCount returns the number of lines read so far.
+	GetLineCount() int
+
+	Close() error
+}
+
+// MultiSeedReader is an optional SeedReader capability, for seed sources whose lines may hold multiple seeds.
+// HasPendingSeeds returns true while seeds of the last read line are yet to be returned by Next.
+type MultiSeedReader interface {
+	HasPendingSeeds() bool
+}
+
 type PreConnectionFilter interface {
 	Initialize(config config.TaskHandlerOptions) error
 
@@ -30,15 +57,63 @@ type Indexer interface {
 
 	Index(id string, properties common.DomainProperties) error
 
-	Query(userQuery string) (map[string]float64, error)
+	// Query returns the scores of matching IDs in the [from, from+size) window, along with the total number of
+	// matching IDs. The window is capped by the outputLimit. Indexers should translate the parsed query into their
+	// own query format. Filter clauses are also verified by the application after querying.
+	// Design Note: Query will be called concurrently once the application is ready to search.
+	Query(query *query_parser.Query, from int, size int) (map[string]float64, uint64, error)
 
 	GetType() string
 }
 
+// Highlighter is an optional Indexer capability. Highlight returns a snippet for each given ID, where the user query
+// terms are highlighted via HTML <mark> tags. Snippets should be HTML escaped.
+// Design Note: Highlight will be called concurrently once the application is ready to search.
+type Highlighter interface {
+	Highlight(query *query_parser.Query, ids []string) (map[string]string, error)
+}
+
+// Suggester is an optional Indexer capability. Suggest returns up to limit completions for the given prefix.
+// Design Note: Suggest will be called concurrently once the application is ready to search.
+type Suggester interface {
+	Suggest(prefix string, limit int) ([]string, error)
+}
+
+// Deleter is an optional Indexer capability. Delete removes the given IDs from the index, unknown IDs are ignored.
+// Design Note: Delete is never called concurrently with queries.
+type Deleter interface {
+	Delete(ids []string) error
+}
+
+// Matcher is an optional Indexer capability. Match returns the given IDs which match the query, regardless of the
+// output limit. Filter clauses are also verified by the application after matching.
+// Design Note: Match is never called concurrently with Index.
+type Matcher interface {
+	Match(query *query_parser.Query, ids []string) ([]string, error)
+}
+
+// Reindexer is an optional Indexer capability. NeedsReindex returns true if the index was recreated during
+// Initialize, i.e. due to an outdated index mapping, thus all documents need to be indexed again.
+type Reindexer interface {
+	NeedsReindex() bool
+}
+
 type Ranker interface {
 	Initialize(config config.TaskHandlerOptions) error
 
+	// Design Note: Input will be called concurrently, as multiple queries can be ranked at once.
 	Input(inProperties *common.DomainProperties, userQuery string) float64
 
 	GetType() string
 }
+
+// ClickStatsProvider provides the click statistics of search results, see common.ClickStats.
+// Design Note: GetClickStats will be called concurrently, as multiple queries can be ranked at once.
+type ClickStatsProvider interface {
+	GetClickStats(userQuery string, domainName string) (common.ClickStats, error)
+}
+
+// ClickStatsConsumer is an optional Ranker capability. SetClickStatsProvider is called once the Ranker is initialized.
+type ClickStatsConsumer interface {
+	SetClickStatsProvider(provider ClickStatsProvider)
+}
@@ -0,0 +1,51 @@
+package metrics_manager
+
+import (
+	"math"
+	"sort"
+)
+
+// Commonly used histogram buckets for durations in seconds.
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Design Note: go-metrics histograms are sample based, which can't be exposed as Prometheus histograms. Thus, we
+// keep our own cumulative bucket counts. Histograms are guarded by the MetricsManager mutex.
+type histogram struct {
+	buckets      []float64
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	sortedBuckets := make([]float64, 0, len(buckets))
+	for _, bucket := range buckets {
+		if !math.IsInf(bucket, +1) {
+			sortedBuckets = append(sortedBuckets, bucket)
+		}
+	}
+	sort.Float64s(sortedBuckets)
+
+	return &histogram{
+		buckets:      sortedBuckets,
+		bucketCounts: make([]uint64, len(sortedBuckets)),
+	}
+}
+
+func (h *histogram) observe(value float64) {
+	for i, bucket := range h.buckets {
+		if value <= bucket {
+			h.bucketCounts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (h *histogram) reset() {
+	for i := range h.bucketCounts {
+		h.bucketCounts[i] = 0
+	}
+	h.count = 0
+	h.sum = 0
+}
@@ -10,6 +10,9 @@ import (
 type MetricsManager struct {
 	metricsRegistry metrics.Registry
 	counterNames    map[string]bool
+	gaugeNames      map[string]bool
+	histograms      map[string]*histogram
+	descriptions    map[string]string
 	mutex           sync.RWMutex
 }
 
@@ -17,9 +20,21 @@ func New() *MetricsManager {
 	m := MetricsManager{}
 	m.metricsRegistry = metrics.NewRegistry()
 	m.counterNames = make(map[string]bool)
+	m.gaugeNames = make(map[string]bool)
+	m.histograms = make(map[string]*histogram)
+	m.descriptions = make(map[string]string)
 	return &m
 }
 
+// Describe records the help text of a metric. Labeled metrics share the description of their base name.
+func (m *MetricsManager) Describe(metricName string, description string) {
+	// Write-Lock Mutex
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.descriptions[getBaseMetricName(metricName)] = description
+}
+
 func (m *MetricsManager) NewCounter(counterName string) {
 	// Write-Lock Mutex
 	m.mutex.Lock()
@@ -88,6 +103,51 @@ func (m *MetricsManager) GetAllCounterCountsAsZapFields() []zap.Field {
 	return fields
 }
 
+func (m *MetricsManager) NewGauge(gaugeName string) {
+	// Write-Lock Mutex
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.metricsRegistry.GetOrRegister(gaugeName, &metrics.StandardGauge{})
+	m.gaugeNames[gaugeName] = true
+}
+
+func (m *MetricsManager) SetGauge(gaugeName string, value int64) {
+	// Write-Lock Mutex
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	metric, ok := m.metricsRegistry.Get(gaugeName).(*metrics.StandardGauge)
+	if !ok {
+		zap.L().Fatal("Invalid gauge name.", zap.String("gauge_name", gaugeName))
+	}
+	metric.Update(value)
+}
+
+// NewHistogram registers a histogram with the given upper bounds of buckets, in ascending order.
+func (m *MetricsManager) NewHistogram(histogramName string, buckets []float64) {
+	// Write-Lock Mutex
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.histograms[histogramName]; ok {
+		return
+	}
+	m.histograms[histogramName] = newHistogram(buckets)
+}
+
+func (m *MetricsManager) ObserveHistogram(histogramName string, value float64) {
+	// Write-Lock Mutex
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	h, ok := m.histograms[histogramName]
+	if !ok {
+		zap.L().Fatal("Invalid histogram name.", zap.String("histogram_name", histogramName))
+	}
+	h.observe(value)
+}
+
 func (m *MetricsManager) Reset() {
 	// Write-Lock Mutex
 	m.mutex.Lock()
@@ -97,4 +157,13 @@ func (m *MetricsManager) Reset() {
 		metric := m.metricsRegistry.Get(counterName).(*metrics.StandardCounter)
 		metric.Clear()
 	}
+
+	for gaugeName, _ := range m.gaugeNames {
+		metric := m.metricsRegistry.Get(gaugeName).(*metrics.StandardGauge)
+		metric.Update(0)
+	}
+
+	for _, h := range m.histograms {
+		h.reset()
+	}
 }
@@ -0,0 +1,64 @@
+package metrics_manager
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLabels(t *testing.T) {
+	assert.Equal(t, "requests_total", WithLabels("requests_total"))
+	assert.Equal(t, `requests_total{code="200",path="/"}`, WithLabels("requests_total", "code", "200", "path", "/"))
+	assert.Equal(t, `requests_total{path="\"a\\b\""}`, WithLabels("requests_total", "path", `"a\b"`))
+}
+
+func TestWritePrometheus(t *testing.T) {
+	m := New()
+
+	m.NewCounter("lines_read_total")
+	m.Describe("lines_read_total", "Lines read.")
+	m.IncCounter("lines_read_total", 3)
+
+	m.NewCounter(WithLabels("errors_total", "class", "timeout"))
+	m.NewCounter(WithLabels("errors_total", "class", "dns"))
+	m.Describe("errors_total", "Errors by class.")
+	m.IncCounter(WithLabels("errors_total", "class", "dns"), 2)
+
+	m.NewGauge("work_items")
+	m.SetGauge("work_items", 42)
+
+	m.NewHistogram("query_duration_seconds", []float64{1, 0.1})
+	m.ObserveHistogram("query_duration_seconds", 0.05)
+	m.ObserveHistogram("query_duration_seconds", 0.5)
+	m.ObserveHistogram("query_duration_seconds", 5)
+
+	var buffer bytes.Buffer
+	require.NoError(t, m.WritePrometheus(&buffer))
+
+	expected := `# HELP errors_total Errors by class.
+# TYPE errors_total counter
+errors_total{class="dns"} 2
+errors_total{class="timeout"} 0
+# HELP lines_read_total Lines read.
+# TYPE lines_read_total counter
+lines_read_total 3
+# TYPE query_duration_seconds histogram
+query_duration_seconds_bucket{le="0.1"} 1
+query_duration_seconds_bucket{le="1"} 2
+query_duration_seconds_bucket{le="+Inf"} 3
+query_duration_seconds_sum 5.55
+query_duration_seconds_count 3
+# TYPE work_items gauge
+work_items 42
+`
+	assert.Equal(t, expected, buffer.String())
+
+	// Reset should clear all metrics
+	m.Reset()
+	assert.Equal(t, int64(0), m.GetCounterCount("lines_read_total"))
+	buffer.Reset()
+	require.NoError(t, m.WritePrometheus(&buffer))
+	assert.Contains(t, buffer.String(), "query_duration_seconds_count 0\n")
+}
@@ -0,0 +1,154 @@
+package metrics_manager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WithLabels returns the metric name with the given label key & value pairs, i.e.
+// WithLabels("zfse_filter_dropped_total", "filter", "builtin.length_filter") will return
+// `zfse_filter_dropped_total{filter="builtin.length_filter"}`.
+func WithLabels(metricName string, labelPairs ...string) string {
+	if len(labelPairs)%2 != 0 {
+		panic("Programming error.")
+	}
+	if len(labelPairs) == 0 {
+		return metricName
+	}
+
+	labels := make([]string, 0, len(labelPairs)/2)
+	for i := 0; i < len(labelPairs); i += 2 {
+		labels = append(labels, labelPairs[i]+`="`+labelValueReplacer.Replace(labelPairs[i+1])+`"`)
+	}
+	return metricName + "{" + strings.Join(labels, ",") + "}"
+}
+
+func getBaseMetricName(metricName string) string {
+	baseName, _, _ := strings.Cut(metricName, "{")
+	return baseName
+}
+
+// Returns the labels of the metric name without the braces.
+func getMetricLabels(metricName string) string {
+	_, labels, found := strings.Cut(metricName, "{")
+	if !found {
+		return ""
+	}
+	return strings.TrimSuffix(labels, "}")
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+type prometheusSample struct {
+	metricName string
+	lines      []string
+}
+
+type prometheusFamily struct {
+	metricType string
+	samples    []prometheusSample
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format.
+func (m *MetricsManager) WritePrometheus(w io.Writer) error {
+	// Read-Lock Mutex
+	m.mutex.RLock()
+	families := make(map[string]*prometheusFamily)
+	addSample := func(metricName string, metricType string, lines []string) {
+		baseName := getBaseMetricName(metricName)
+		family, ok := families[baseName]
+		if !ok {
+			family = &prometheusFamily{metricType: metricType}
+			families[baseName] = family
+		}
+		family.samples = append(family.samples, prometheusSample{metricName: metricName, lines: lines})
+	}
+
+	for counterName, _ := range m.counterNames {
+		count := m.metricsRegistry.Get(counterName).(*metrics.StandardCounter).Count()
+		addSample(counterName, "counter", []string{counterName + " " + strconv.FormatInt(count, 10)})
+	}
+
+	for gaugeName, _ := range m.gaugeNames {
+		value := m.metricsRegistry.Get(gaugeName).(*metrics.StandardGauge).Value()
+		addSample(gaugeName, "gauge", []string{gaugeName + " " + strconv.FormatInt(value, 10)})
+	}
+
+	for histogramName, h := range m.histograms {
+		baseName := getBaseMetricName(histogramName)
+		labels := getMetricLabels(histogramName)
+		labelPrefix := ""
+		if labels != "" {
+			labelPrefix = labels + ","
+		}
+		suffix := ""
+		if labels != "" {
+			suffix = "{" + labels + "}"
+		}
+
+		lines := make([]string, 0, len(h.buckets)+3)
+		for i, bucket := range h.buckets {
+			lines = append(
+				lines, fmt.Sprintf(
+					`%s_bucket{%sle="%s"} %d`, baseName, labelPrefix, formatFloat(bucket), h.bucketCounts[i],
+				),
+			)
+		}
+		lines = append(lines, fmt.Sprintf(`%s_bucket{%sle="+Inf"} %d`, baseName, labelPrefix, h.count))
+		lines = append(lines, fmt.Sprintf("%s_sum%s %s", baseName, suffix, formatFloat(h.sum)))
+		lines = append(lines, fmt.Sprintf("%s_count%s %d", baseName, suffix, h.count))
+		addSample(histogramName, "histogram", lines)
+	}
+
+	descriptions := make(map[string]string, len(m.descriptions))
+	for baseName, description := range m.descriptions {
+		descriptions[baseName] = description
+	}
+	m.mutex.RUnlock()
+
+	// Design Note: Output is sorted, so that consecutive scrapes are easy to compare.
+	baseNames := make([]string, 0, len(families))
+	for baseName := range families {
+		baseNames = append(baseNames, baseName)
+	}
+	sort.Strings(baseNames)
+
+	writer := bufio.NewWriter(w)
+	for _, baseName := range baseNames {
+		family := families[baseName]
+		sort.Slice(
+			family.samples, func(i, j int) bool {
+				return family.samples[i].metricName < family.samples[j].metricName
+			},
+		)
+
+		if description, ok := descriptions[baseName]; ok {
+			fmt.Fprintf(writer, "# HELP %s %s\n", baseName, strings.ReplaceAll(description, "\n", " "))
+		}
+		fmt.Fprintf(writer, "# TYPE %s %s\n", baseName, family.metricType)
+		for _, sample := range family.samples {
+			for _, line := range sample.lines {
+				fmt.Fprintln(writer, line)
+			}
+		}
+	}
+
+	return writer.Flush()
+}
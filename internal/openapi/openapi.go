@@ -0,0 +1,199 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Design Note: Only the subset of OpenAPI 3.0 used by the ZFSE API is supported, i.e. fixed paths, query parameters,
+// JSON request/response bodies and local schema references. Thus, the package doesn't need any third party
+// dependencies.
+
+type Spec struct {
+	OpenAPI    string                          `json:"openapi"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Parameters  []Parameter         `json:"parameters"`
+	RequestBody *RequestBody        `json:"requestBody"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Nullable   bool               `json:"nullable"`
+	Enum       []interface{}      `json:"enum"`
+	Properties map[string]*Schema `json:"properties"`
+	Required   []string           `json:"required"`
+	// Either a boolean or a schema
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	Items                *Schema         `json:"items"`
+	Minimum              *float64        `json:"minimum"`
+	Maximum              *float64        `json:"maximum"`
+	MinLength            *int            `json:"minLength"`
+	MaxLength            *int            `json:"maxLength"`
+
+	// Parsed from AdditionalProperties
+	additionalPropertiesSchema *Schema
+	bDisallowsAdditional       bool
+}
+
+const jsonContentType = "application/json"
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// Load parses the OpenAPI document & verifies that all schema references can be resolved.
+func Load(data []byte) (*Spec, error) {
+	spec := Spec{}
+	err := json.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", spec.OpenAPI)
+	}
+
+	for _, schema := range spec.Components.Schemas {
+		err = spec.prepareSchema(schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			for _, parameter := range operation.Parameters {
+				if parameter.In != "query" {
+					return nil, fmt.Errorf("%s %s: unsupported parameter location %q", method, path, parameter.In)
+				}
+				err = spec.prepareSchema(parameter.Schema)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if operation.RequestBody != nil {
+				for _, mediaType := range operation.RequestBody.Content {
+					err = spec.prepareSchema(mediaType.Schema)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+			for _, response := range operation.Responses {
+				for _, mediaType := range response.Content {
+					err = spec.prepareSchema(mediaType.Schema)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	return &spec, nil
+}
+
+// Parses "additionalProperties" & verifies the references of the schema and its sub-schemas.
+func (s *Spec) prepareSchema(schema *Schema) error {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		_, err := s.resolve(schema)
+		return err
+	}
+
+	if len(schema.AdditionalProperties) > 0 {
+		var bIsAllowed bool
+		if json.Unmarshal(schema.AdditionalProperties, &bIsAllowed) == nil {
+			schema.bDisallowsAdditional = !bIsAllowed
+		} else {
+			schema.additionalPropertiesSchema = &Schema{}
+			err := json.Unmarshal(schema.AdditionalProperties, schema.additionalPropertiesSchema)
+			if err != nil {
+				return err
+			}
+			err = s.prepareSchema(schema.additionalPropertiesSchema)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, propertySchema := range schema.Properties {
+		err := s.prepareSchema(propertySchema)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.prepareSchema(schema.Items)
+}
+
+// Returns the referenced schema, or the schema itself if it isn't a reference.
+func (s *Spec) resolve(schema *Schema) (*Schema, error) {
+	if schema.Ref == "" {
+		return schema, nil
+	}
+
+	if !strings.HasPrefix(schema.Ref, schemaRefPrefix) {
+		return nil, fmt.Errorf("unsupported schema reference %q", schema.Ref)
+	}
+
+	resolvedSchema, ok := s.Components.Schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)]
+	if !ok || resolvedSchema == nil {
+		return nil, fmt.Errorf("unknown schema reference %q", schema.Ref)
+	}
+	return resolvedSchema, nil
+}
+
+// GetOperation returns the operation of the path & method. bIsPathFound is false if the path isn't a part of the
+// document.
+func (s *Spec) GetOperation(path string, method string) (operation Operation, bIsPathFound bool, bIsFound bool) {
+	operations, ok := s.Paths[path]
+	if !ok {
+		return Operation{}, false, false
+	}
+
+	operation, ok = operations[strings.ToLower(method)]
+	return operation, true, ok
+}
+
+// GetSchema returns the component schema of the name, i.e. to validate JSON payloads which aren't a part of an
+// operation.
+func (s *Spec) GetSchema(name string) (*Schema, bool) {
+	schema, ok := s.Components.Schemas[name]
+	return schema, ok
+}
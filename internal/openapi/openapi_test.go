@@ -0,0 +1,164 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	embedding "github.com/anthony-ozdemir/zfse"
+)
+
+const testSpec = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/items": {
+      "get": {
+        "parameters": [
+          {"name": "q", "in": "query", "required": true, "schema": {"type": "string", "minLength": 1}},
+          {"name": "size", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 10}},
+          {"name": "tag", "in": "query", "schema": {"type": "array", "items": {"type": "string", "maxLength": 3}}}
+        ],
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ItemsRep"}}}
+          }
+        }
+      },
+      "post": {
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ItemReq"}}}
+        },
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "ItemReq": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "kind": {"type": "string", "enum": ["a", "b"]}
+        },
+        "additionalProperties": false
+      },
+      "ItemsRep": {
+        "type": "object",
+        "required": ["items"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/Item"}},
+          "counts": {"type": "object", "additionalProperties": {"type": "integer"}}
+        }
+      },
+      "Item": {
+        "type": "object",
+        "properties": {"name": {"type": "string"}, "score": {"type": "number", "nullable": true}}
+      }
+    }
+  }
+}`
+
+func mustLoadTestSpec(t *testing.T) *Spec {
+	spec, err := Load([]byte(testSpec))
+	require.NoError(t, err)
+	return spec
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load([]byte(`{"openapi": "2.0"}`))
+	assert.Error(t, err)
+
+	_, err = Load([]byte(`{"openapi": "3.0.3", "components": {"schemas": {"A": {"$ref": "#/components/schemas/B"}}}}`))
+	assert.Error(t, err)
+
+	// Embedded document of the HTTP API should always be valid
+	_, err = Load(embedding.GetOpenAPISpec())
+	assert.NoError(t, err)
+}
+
+func TestValidateRequestQueryParameters(t *testing.T) {
+	spec := mustLoadTestSpec(t)
+
+	validate := func(method string, target string) error {
+		return spec.ValidateRequest(httptest.NewRequest(method, target, nil))
+	}
+
+	assert.NoError(t, validate(http.MethodGet, "/items?q=a"))
+	assert.NoError(t, validate(http.MethodGet, "/items?q=a&size=10&tag=x&tag=yz"))
+	// Undocumented paths are not validated
+	assert.NoError(t, validate(http.MethodGet, "/unknown?size=a"))
+
+	assert.ErrorIs(t, validate(http.MethodDelete, "/items?q=a"), ErrMethodNotAllowed)
+
+	for _, target := range []string{
+		"/items",
+		"/items?q=",
+		"/items?q=a&size=0",
+		"/items?q=a&size=11",
+		"/items?q=a&size=a",
+		"/items?q=a&size=1&size=2",
+		"/items?q=a&tag=long",
+	} {
+		err := validate(http.MethodGet, target)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr, target)
+	}
+}
+
+func TestValidateRequestBody(t *testing.T) {
+	spec := mustLoadTestSpec(t)
+
+	validate := func(body string) error {
+		r := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return spec.ValidateRequest(r)
+	}
+
+	assert.NoError(t, validate(`{"name": "x", "kind": "a"}`))
+
+	for _, body := range []string{
+		``,
+		`{`,
+		`[]`,
+		`{}`,
+		`{"name": ""}`,
+		`{"name": 1}`,
+		`{"name": "x", "kind": "c"}`,
+		`{"name": "x", "extra": true}`,
+		`{"name": "x"} {}`,
+	} {
+		var validationErr *ValidationError
+		assert.ErrorAs(t, validate(body), &validationErr, body)
+	}
+
+	// Body should still be readable by the handlers
+	r := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name": "x"}`))
+	require.NoError(t, spec.ValidateRequest(r))
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "x"}`, string(body))
+}
+
+func TestValidateResponse(t *testing.T) {
+	spec := mustLoadTestSpec(t)
+
+	validate := func(body string) error {
+		return spec.ValidateResponse("/items", http.MethodGet, http.StatusOK, "application/json", []byte(body))
+	}
+
+	assert.NoError(t, validate(`{"items": [{"name": "x", "score": 1.5}, {"score": null}], "counts": {"a": 1}}`))
+	assert.Error(t, validate(`{}`))
+	assert.Error(t, validate(`{"items": [{"name": 1}]}`))
+	assert.Error(t, validate(`{"items": [], "counts": {"a": 1.5}}`))
+
+	assert.Error(t, spec.ValidateResponse("/items", http.MethodGet, http.StatusNotFound, "application/json", nil))
+	assert.Error(t, spec.ValidateResponse("/items", http.MethodGet, http.StatusOK, "text/plain", nil))
+}
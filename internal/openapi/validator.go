@@ -0,0 +1,333 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ErrMethodNotAllowed is returned when the path is documented, but the method isn't.
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// ValidationError describes why a value doesn't conform to its schema.
+type ValidationError struct {
+	// Location of the value, i.e. "query.size" or "body.zone_name"
+	Location string
+	Message  string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Location, e.Message)
+}
+
+func newValidationError(location string, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Location: location, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateRequest validates the query parameters & JSON body of the request against the document. Requests to paths
+// which aren't documented are not validated. The request body is restored, so that handlers can read it again.
+func (s *Spec) ValidateRequest(r *http.Request) error {
+	operation, bIsPathFound, bIsFound := s.GetOperation(r.URL.Path, r.Method)
+	if !bIsPathFound {
+		return nil
+	}
+	if !bIsFound {
+		return ErrMethodNotAllowed
+	}
+
+	query := r.URL.Query()
+	for _, parameter := range operation.Parameters {
+		location := "query." + parameter.Name
+
+		values, ok := query[parameter.Name]
+		if !ok || len(values) == 0 {
+			if parameter.Required {
+				return newValidationError(location, "parameter is required")
+			}
+			continue
+		}
+
+		schema, err := s.resolve(parameter.Schema)
+		if err != nil {
+			return err
+		}
+
+		if schema.Type == "array" {
+			itemSchema, err := s.resolve(schema.Items)
+			if err != nil {
+				return err
+			}
+			items := make([]interface{}, 0, len(values))
+			for _, value := range values {
+				item, err := parseParameterValue(itemSchema, value, location)
+				if err != nil {
+					return err
+				}
+				items = append(items, item)
+			}
+			err = s.ValidateValue(schema, items, location)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(values) > 1 {
+			return newValidationError(location, "parameter can only be set once")
+		}
+		value, err := parseParameterValue(schema, values[0], location)
+		if err != nil {
+			return err
+		}
+		err = s.ValidateValue(schema, value, location)
+		if err != nil {
+			return err
+		}
+	}
+
+	if operation.RequestBody == nil {
+		return nil
+	}
+
+	mediaType, ok := operation.RequestBody.Content[jsonContentType]
+	if !ok {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.RequestBody.Required {
+			return newValidationError("body", "request body is required")
+		}
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" {
+		parsedContentType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || parsedContentType != jsonContentType {
+			return newValidationError("body", "content type should be %s", jsonContentType)
+		}
+	}
+
+	return s.ValidateJSON(mediaType.Schema, body, "body")
+}
+
+// ValidateResponse validates the JSON reply of the operation against the documented response. Replies which aren't
+// JSON are not validated.
+func (s *Spec) ValidateResponse(path string, method string, statusCode int, contentType string, body []byte) error {
+	operation, _, bIsFound := s.GetOperation(path, method)
+	if !bIsFound {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+
+	response, ok := operation.Responses[strconv.Itoa(statusCode)]
+	if !ok {
+		response, ok = operation.Responses["default"]
+		if !ok {
+			return fmt.Errorf("%s %s: status code %d is not documented", method, path, statusCode)
+		}
+	}
+
+	parsedContentType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%s %s: invalid content type %q", method, path, contentType)
+	}
+
+	mediaType, ok := response.Content[parsedContentType]
+	if !ok {
+		return fmt.Errorf("%s %s: content type %q is not documented", method, path, parsedContentType)
+	}
+	if parsedContentType != jsonContentType {
+		return nil
+	}
+
+	return s.ValidateJSON(mediaType.Schema, body, "response")
+}
+
+// ValidateJSON validates the JSON document against the schema.
+func (s *Spec) ValidateJSON(schema *Schema, data []byte, location string) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Design Note: Numbers need to be kept intact, otherwise large integers would lose precision.
+	decoder.UseNumber()
+
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return newValidationError(location, "invalid JSON: %v", err)
+	}
+	if decoder.More() {
+		return newValidationError(location, "invalid JSON: unexpected data after the top-level value")
+	}
+
+	return s.ValidateValue(schema, value, location)
+}
+
+// ValidateValue validates a decoded JSON value against the schema. Numbers are expected to be json.Number, float64 or
+// int64.
+func (s *Spec) ValidateValue(schema *Schema, value interface{}, location string) error {
+	if schema == nil {
+		return nil
+	}
+
+	schema, err := s.resolve(schema)
+	if err != nil {
+		return err
+	}
+
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return newValidationError(location, "value should be %s, not null", schema.Type)
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return newValidationError(location, "value should be an object")
+		}
+		return s.validateObject(schema, object, location)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return newValidationError(location, "value should be an array")
+		}
+		for i, item := range array {
+			err = s.ValidateValue(schema.Items, item, fmt.Sprintf("%s[%d]", location, i))
+			if err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return newValidationError(location, "value should be a string")
+		}
+		length := utf8.RuneCountInString(str)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			return newValidationError(location, "value should be at least %d characters long", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			return newValidationError(location, "value should be at most %d characters long", *schema.MaxLength)
+		}
+	case "integer", "number":
+		number, ok := toFloat64(value)
+		if !ok {
+			return newValidationError(location, "value should be a %s", schema.Type)
+		}
+		if schema.Type == "integer" && number != math.Trunc(number) {
+			return newValidationError(location, "value should be an integer")
+		}
+		if schema.Minimum != nil && number < *schema.Minimum {
+			return newValidationError(location, "value should be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && number > *schema.Maximum {
+			return newValidationError(location, "value should be at most %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return newValidationError(location, "value should be a boolean")
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		for _, enumValue := range schema.Enum {
+			if enumValue == value {
+				return nil
+			}
+		}
+		return newValidationError(location, "value %v is not allowed", value)
+	}
+
+	return nil
+}
+
+func (s *Spec) validateObject(schema *Schema, object map[string]interface{}, location string) error {
+	for _, requiredProperty := range schema.Required {
+		if _, ok := object[requiredProperty]; !ok {
+			return newValidationError(location+"."+requiredProperty, "property is required")
+		}
+	}
+
+	// Let's validate in a deterministic order, so that the same error is always reported
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propertyLocation := location + "." + key
+		propertySchema, ok := schema.Properties[key]
+		if !ok {
+			if schema.bDisallowsAdditional {
+				return newValidationError(propertyLocation, "property is not allowed")
+			}
+			propertySchema = schema.additionalPropertiesSchema
+		}
+
+		err := s.ValidateValue(propertySchema, object[key], propertyLocation)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Converts the query parameter value to the type of the schema.
+func parseParameterValue(schema *Schema, value string, location string) (interface{}, error) {
+	switch schema.Type {
+	case "integer":
+		parsedValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, newValidationError(location, "value should be an integer")
+		}
+		return parsedValue, nil
+	case "number":
+		parsedValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, newValidationError(location, "value should be a number")
+		}
+		return parsedValue, nil
+	case "boolean":
+		parsedValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, newValidationError(location, "value should be a boolean")
+		}
+		return parsedValue, nil
+	default:
+		return value, nil
+	}
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		number, err := value.Float64()
+		return number, err == nil
+	case float64:
+		return value, true
+	case int64:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	default:
+		return 0, false
+	}
+}
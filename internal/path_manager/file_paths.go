@@ -15,6 +15,7 @@ func init() {
 	registry.cacheFolderPath = "./cache"
 	registry.zoneFilesFolderPath = "./zone-files"
 	registry.pluginFolderPath = "./plugin"
+	registry.userDataFolderPath = "./user-data"
 }
 
 type PathRegistry struct {
@@ -22,6 +23,9 @@ type PathRegistry struct {
 	cacheFolderPath     string
 	zoneFilesFolderPath string
 	pluginFolderPath    string
+	// Design Note: Data created by the users (i.e. saved searches) can't be recreated from the zone files, thus it is
+	// kept out of the cache folder, which can be purged at any time.
+	userDataFolderPath string
 }
 
 func GetConfigFilePath() string {
@@ -92,6 +96,23 @@ func SetPluginFolderPath(pluginFolderPath string) {
 	registry.pluginFolderPath = absFilePath
 }
 
+func GetUserDataFolderPath() string {
+	return registry.userDataFolderPath
+}
+
+func SetUserDataFolderPath(userDataFolderPath string) {
+	absFilePath, err := filepath.Abs(userDataFolderPath)
+	if err != nil {
+		zap.L().Fatal(
+			"Unable to convert to absolute file path.",
+			zap.String("file_path", userDataFolderPath),
+			zap.String("err", err.Error()),
+		)
+	}
+
+	registry.userDataFolderPath = absFilePath
+}
+
 func GetPreCrawlFilterOutputFilePath(tldName string) string {
 	return filepath.Join(GetCacheFolderPath(), "zone", tldName, "pre_crawl_output.txt")
 }
@@ -100,6 +121,18 @@ func GetPostCrawlFilterOutputFilePath(tldName string) string {
 	return filepath.Join(GetCacheFolderPath(), "zone", tldName, "post_crawl_output.txt")
 }
 
+// GetZoneDeltaFilePath returns the zone file which contains only the records of the domains added or changed since the
+// last processed snapshot of the zone.
+func GetZoneDeltaFilePath(tldName string) string {
+	return filepath.Join(GetCacheFolderPath(), "zone", tldName, "zone_delta.txt")
+}
+
+// GetZoneStaleDomainsFilePath returns the list of the domains removed or changed since the last processed snapshot of
+// the zone, whose index documents need to be deleted.
+func GetZoneStaleDomainsFilePath(tldName string) string {
+	return filepath.Join(GetCacheFolderPath(), "zone", tldName, "zone_stale_domains.txt")
+}
+
 func GetIndexerDatabaseFilePath(indexerType string) string {
 	// Let's replace dots in the indexerType
 	indexerName := strings.Replace(indexerType, ".", "-", -1)
@@ -107,8 +140,14 @@ func GetIndexerDatabaseFilePath(indexerType string) string {
 	return filepath.Join(GetCacheFolderPath(), "indexer", indexerName, "index.bleve")
 }
 
-func GetRankingFilePath(userQuery string) string {
-	// TODO [HP]: We need to Base64 encode the userQuery and cache it as needed.
-	// Alternatively, we can just use a metadata field.
-	return filepath.Join(GetCacheFolderPath(), "ranking", "output.txt")
+func GetZoneDownloadFolderPath() string {
+	return filepath.Join(GetCacheFolderPath(), "zone_download")
+}
+
+func GetUserDatabaseFilePath() string {
+	return filepath.Join(GetUserDataFolderPath(), "db")
+}
+
+func GetSavedSearchAlertsFilePath() string {
+	return filepath.Join(GetUserDataFolderPath(), "alerts", "saved_search_alerts.jsonl")
 }
@@ -0,0 +1,122 @@
+package query_parser
+
+import (
+	"strings"
+)
+
+// Helpers to extract filterable values from domain properties. Indexers are encouraged to use these helpers, so that
+// filters behave the same regardless of the Indexer.
+
+// GetDomainSuffixes returns the domain name and all of its parent domains, i.e. "a.example.com" will return
+// ["a.example.com", "example.com", "com"].
+func GetDomainSuffixes(domainName string) []string {
+	domainName = strings.Trim(strings.ToLower(strings.TrimSpace(domainName)), ".")
+	suffixes := make([]string, 0)
+	if domainName == "" {
+		return suffixes
+	}
+
+	labels := strings.Split(domainName, ".")
+	for i := range labels {
+		suffixes = append(suffixes, strings.Join(labels[i:], "."))
+	}
+	return suffixes
+}
+
+// GetTLD returns the top-level domain of the domain name.
+func GetTLD(domainName string) string {
+	suffixes := GetDomainSuffixes(domainName)
+	if len(suffixes) == 0 {
+		return ""
+	}
+	return suffixes[len(suffixes)-1]
+}
+
+// GetNameserverSuffixes returns the nameserver host & its parent domains from the "record_data" string property.
+// Returns nil for records other than NS records.
+func GetNameserverSuffixes(stringProperties map[string]string) []string {
+	if !strings.EqualFold(stringProperties["record_type"], "ns") {
+		return nil
+	}
+	return GetDomainSuffixes(stringProperties["record_data"])
+}
+
+// GetLanguages returns the language tag & its primary sub-tag from the "lang" string property, i.e. "en-US" will
+// return ["en-us", "en"].
+func GetLanguages(stringProperties map[string]string) []string {
+	lang := strings.ToLower(strings.TrimSpace(stringProperties["lang"]))
+	if lang == "" {
+		return nil
+	}
+
+	languages := []string{lang}
+	primaryTag, _, bHasSubTag := strings.Cut(strings.Replace(lang, "_", "-", -1), "-")
+	if bHasSubTag && primaryTag != "" {
+		languages = append(languages, primaryTag)
+	}
+	return languages
+}
+
+// GetNameserverProvider returns the registrable domain of the nameserver host, i.e. "jason.ns.cloudflare.com." will
+// return "cloudflare.com".
+// Design Note: Public suffixes with multiple labels (i.e. "co.uk") are not taken into account.
+func GetNameserverProvider(stringProperties map[string]string) string {
+	suffixes := GetNameserverSuffixes(stringProperties)
+	if len(suffixes) == 0 {
+		return ""
+	}
+	if len(suffixes) == 1 {
+		return suffixes[0]
+	}
+	return suffixes[len(suffixes)-2]
+}
+
+// GetFilterValues returns all values of the filter field for the given domain. Fields other than the built-in
+// filter fields are read from the string properties.
+func GetFilterValues(field string, domainName string, stringProperties map[string]string) []string {
+	switch field {
+	case FilterFieldTLD:
+		return []string{GetTLD(domainName)}
+	case FilterFieldSite:
+		return GetDomainSuffixes(domainName)
+	case FilterFieldNameserver:
+		return GetNameserverSuffixes(stringProperties)
+	case FilterFieldLanguage:
+		return GetLanguages(stringProperties)
+	case FilterFieldZone:
+		return getStringPropertyValues("zone_name", stringProperties)
+	default:
+		return getStringPropertyValues(field, stringProperties)
+	}
+}
+
+// MatchFilters checks the domain against the filter clauses of the query. Term & phrase clauses are ignored.
+func (q *Query) MatchFilters(domainName string, stringProperties map[string]string) bool {
+	for _, clause := range q.GetFilterClauses() {
+		bIsMatched := false
+		for _, value := range GetFilterValues(clause.Field, domainName, stringProperties) {
+			if value == clause.Value {
+				bIsMatched = true
+				break
+			}
+		}
+
+		if clause.Occurrence == MustNot && bIsMatched {
+			return false
+		}
+
+		if clause.Occurrence != MustNot && !bIsMatched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getStringPropertyValues(key string, stringProperties map[string]string) []string {
+	value := strings.ToLower(strings.TrimSpace(stringProperties[key]))
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
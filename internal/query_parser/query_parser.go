@@ -0,0 +1,301 @@
+package query_parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Query Language
+// Design Note: The query language is intentionally kept flat (no parentheses), so that any Indexer can translate it
+// into its own query format with ease.
+//
+//   gaming news         : Terms are optional, but at least one of them should match.
+//   "gaming news"       : Quoted phrases should match as a whole.
+//   gaming AND news     : Both terms need to match. AND marks its neighbouring clauses as required.
+//   gaming OR news      : Either term can match. This is the default behaviour.
+//   +gaming             : Term is required.
+//   -gaming             : Term is excluded.
+//   tld:dev             : Field filters are always required, unless excluded via "-".
+//   ns:cloudflare.com   : Filters by nameserver (record_data) host or any of its parent domains.
+//   site:example.com    : Filters by domain name or any of its parent domains.
+//   lang:en             : Filters by language of the domain's index page.
+//   zone:dev            : Filters by the zone file the domain is read from.
+
+type Occurrence int
+
+const (
+	Should Occurrence = iota
+	Must
+	MustNot
+)
+
+type ClauseKind int
+
+const (
+	Term ClauseKind = iota
+	Phrase
+	Filter
+)
+
+// Supported filter fields
+const (
+	FilterFieldTLD        = "tld"
+	FilterFieldNameserver = "ns"
+	FilterFieldSite       = "site"
+	FilterFieldLanguage   = "lang"
+	FilterFieldZone       = "zone"
+)
+
+var filterFields = []string{
+	FilterFieldTLD, FilterFieldNameserver, FilterFieldSite, FilterFieldLanguage, FilterFieldZone,
+}
+
+type Clause struct {
+	Occurrence Occurrence
+	Kind       ClauseKind
+	Field      string // Only set for filters
+	Value      string // Filter values are lower-cased
+}
+
+type Query struct {
+	Raw     string
+	Clauses []Clause
+}
+
+// HasTextClauses returns true if the query contains any term or phrase clause which isn't excluded.
+func (q *Query) HasTextClauses() bool {
+	for _, clause := range q.Clauses {
+		if clause.Kind != Filter && clause.Occurrence != MustNot {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTextClauses returns the term & phrase values which aren't excluded. Useful for highlighting & suggestions.
+func (q *Query) GetTextClauses() []string {
+	values := make([]string, 0)
+	for _, clause := range q.Clauses {
+		if clause.Kind != Filter && clause.Occurrence != MustNot {
+			values = append(values, clause.Value)
+		}
+	}
+	return values
+}
+
+// GetFilterClauses returns all filter clauses.
+func (q *Query) GetFilterClauses() []Clause {
+	clauses := make([]Clause, 0)
+	for _, clause := range q.Clauses {
+		if clause.Kind == Filter {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}
+
+// AddFilter appends a required filter clause. Unlike the query language, any string property key can be used as a
+// filter field, i.e. for facet selections.
+func (q *Query) AddFilter(field string, value string) {
+	q.Clauses = append(
+		q.Clauses, Clause{
+			Occurrence: Must,
+			Kind:       Filter,
+			Field:      strings.ToLower(field),
+			Value:      strings.Trim(strings.ToLower(strings.TrimSpace(value)), "."),
+		},
+	)
+}
+
+// Normalize returns a canonical representation of the query, i.e. for caching. Queries which only differ in letter
+// case, whitespace, clause order or duplicate clauses are normalized to the same value.
+func (q *Query) Normalize() string {
+	normalizedClauses := make([]string, 0, len(q.Clauses))
+	bIsSeen := make(map[string]bool)
+	for _, clause := range q.Clauses {
+		var builder strings.Builder
+		switch clause.Occurrence {
+		case Must:
+			builder.WriteRune('+')
+		case MustNot:
+			builder.WriteRune('-')
+		}
+
+		value := strings.Join(strings.Fields(strings.ToLower(clause.Value)), " ")
+		switch clause.Kind {
+		case Phrase:
+			builder.WriteString(`"` + value + `"`)
+		case Filter:
+			builder.WriteString(clause.Field + ":" + value)
+		default:
+			builder.WriteString(value)
+		}
+
+		normalizedClause := builder.String()
+		if bIsSeen[normalizedClause] {
+			continue
+		}
+		bIsSeen[normalizedClause] = true
+		normalizedClauses = append(normalizedClauses, normalizedClause)
+	}
+
+	sort.Strings(normalizedClauses)
+	return strings.Join(normalizedClauses, " ")
+}
+
+// Parse parses the user query into a Query.
+func Parse(userQuery string) (*Query, error) {
+	q := Query{
+		Raw:     userQuery,
+		Clauses: make([]Clause, 0),
+	}
+
+	tokens, err := tokenize(userQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	bPendingAnd := false
+	for _, token := range tokens {
+		if !token.bIsQuoted && token.text == "AND" {
+			if len(q.Clauses) > 0 {
+				bPendingAnd = true
+				markRequired(&q.Clauses[len(q.Clauses)-1])
+			}
+			continue
+		}
+
+		if !token.bIsQuoted && token.text == "OR" {
+			bPendingAnd = false
+			continue
+		}
+
+		clause, err := parseClause(token)
+		if err != nil {
+			return nil, err
+		}
+		if clause == nil {
+			continue
+		}
+
+		if bPendingAnd {
+			markRequired(clause)
+			bPendingAnd = false
+		}
+
+		q.Clauses = append(q.Clauses, *clause)
+	}
+
+	if len(q.Clauses) == 0 {
+		return nil, fmt.Errorf("query is empty")
+	}
+
+	return &q, nil
+}
+
+func markRequired(clause *Clause) {
+	if clause.Occurrence == Should {
+		clause.Occurrence = Must
+	}
+}
+
+type token struct {
+	text      string
+	prefix    rune // '+', '-' or 0
+	bIsQuoted bool
+}
+
+// Splits the user query into whitespace separated tokens, while keeping quoted values intact.
+func tokenize(userQuery string) ([]token, error) {
+	tokens := make([]token, 0)
+
+	runes := []rune(userQuery)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		t := token{}
+		if runes[i] == '+' || runes[i] == '-' {
+			t.prefix = runes[i]
+			i++
+		}
+
+		var builder strings.Builder
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if runes[i] == '"' {
+				// Let's read until the closing quote
+				closingIndex := -1
+				for j := i + 1; j < len(runes); j++ {
+					if runes[j] == '"' {
+						closingIndex = j
+						break
+					}
+				}
+				if closingIndex == -1 {
+					return nil, fmt.Errorf("unterminated quote at position %d", i)
+				}
+				builder.WriteString(string(runes[i+1 : closingIndex]))
+				t.bIsQuoted = true
+				i = closingIndex + 1
+				continue
+			}
+			builder.WriteRune(runes[i])
+			i++
+		}
+
+		t.text = builder.String()
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
+func parseClause(t token) (*Clause, error) {
+	clause := Clause{
+		Occurrence: Should,
+		Kind:       Term,
+		Value:      t.text,
+	}
+
+	// Let's check if this is a field filter
+	field, value, bHasSeparator := strings.Cut(t.text, ":")
+	if bHasSeparator && isFilterField(strings.ToLower(field)) {
+		value = strings.Trim(strings.ToLower(strings.TrimSpace(value)), ".")
+		if value == "" {
+			return nil, fmt.Errorf("missing value for %v filter", field)
+		}
+		clause.Kind = Filter
+		clause.Occurrence = Must
+		clause.Field = strings.ToLower(field)
+		clause.Value = value
+	} else if t.bIsQuoted {
+		clause.Kind = Phrase
+	}
+
+	if strings.TrimSpace(clause.Value) == "" {
+		// Nothing to match, i.e. a lone "-" or an empty phrase
+		return nil, nil
+	}
+
+	switch t.prefix {
+	case '+':
+		clause.Occurrence = Must
+	case '-':
+		clause.Occurrence = MustNot
+	}
+
+	return &clause, nil
+}
+
+func isFilterField(field string) bool {
+	for _, filterField := range filterFields {
+		if field == filterField {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package query_parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`MMORPG "open world" -pvp tld:dev -ns:Cloudflare.com. site:example.com lang:en`)
+	require.NoError(t, err)
+
+	expectedClauses := []Clause{
+		{Occurrence: Should, Kind: Term, Value: "MMORPG"},
+		{Occurrence: Should, Kind: Phrase, Value: "open world"},
+		{Occurrence: MustNot, Kind: Term, Value: "pvp"},
+		{Occurrence: Must, Kind: Filter, Field: FilterFieldTLD, Value: "dev"},
+		{Occurrence: MustNot, Kind: Filter, Field: FilterFieldNameserver, Value: "cloudflare.com"},
+		{Occurrence: Must, Kind: Filter, Field: FilterFieldSite, Value: "example.com"},
+		{Occurrence: Must, Kind: Filter, Field: FilterFieldLanguage, Value: "en"},
+	}
+	assert.Equal(t, expectedClauses, q.Clauses)
+	assert.Equal(t, []string{"MMORPG", "open world"}, q.GetTextClauses())
+	assert.Len(t, q.GetFilterClauses(), 4)
+}
+
+func TestParseBooleanOperators(t *testing.T) {
+	q, err := Parse(`gaming AND news OR "tech blog" +rpg`)
+	require.NoError(t, err)
+
+	expectedClauses := []Clause{
+		{Occurrence: Must, Kind: Term, Value: "gaming"},
+		{Occurrence: Must, Kind: Term, Value: "news"},
+		{Occurrence: Should, Kind: Phrase, Value: "tech blog"},
+		{Occurrence: Must, Kind: Term, Value: "rpg"},
+	}
+	assert.Equal(t, expectedClauses, q.Clauses)
+
+	// Quoted operators are phrases
+	q, err = Parse(`"AND"`)
+	require.NoError(t, err)
+	assert.Equal(t, []Clause{{Occurrence: Should, Kind: Phrase, Value: "AND"}}, q.Clauses)
+
+	// Unknown fields are plain terms
+	q, err = Parse(`foo:bar`)
+	require.NoError(t, err)
+	assert.Equal(t, []Clause{{Occurrence: Should, Kind: Term, Value: "foo:bar"}}, q.Clauses)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse(`   `)
+	assert.Error(t, err)
+
+	_, err = Parse(`AND OR`)
+	assert.Error(t, err)
+
+	_, err = Parse(`"open world`)
+	assert.Error(t, err)
+
+	_, err = Parse(`gaming tld:`)
+	assert.Error(t, err)
+}
+
+func TestMatchFilters(t *testing.T) {
+	stringProperties := map[string]string{
+		"record_type": "ns",
+		"record_data": "jason.ns.cloudflare.com.",
+		"lang":        "en-US",
+		"zone_name":   "dev",
+	}
+
+	testCases := map[string]bool{
+		"tld:dev":                    true,
+		"tld:com":                    false,
+		"-tld:com":                   true,
+		"ns:cloudflare.com":          true,
+		"ns:jason.ns.cloudflare.com": true,
+		"ns:flare.com":               false,
+		"site:galaxiesofeden.dev":    true,
+		"site:eden.dev":              false,
+		"lang:en":                    true,
+		"lang:en-us":                 true,
+		"lang:de":                    false,
+		"tld:dev -lang:en":           false,
+		// Terms & phrases are ignored
+		"gaming \"open world\"": true,
+	}
+
+	for userQuery, bExpected := range testCases {
+		q, err := Parse(userQuery)
+		require.NoError(t, err)
+		assert.Equal(t, bExpected, q.MatchFilters("galaxiesofeden.dev", stringProperties), userQuery)
+	}
+}
+
+func TestGetDomainSuffixes(t *testing.T) {
+	assert.Equal(t, []string{"a.example.com", "example.com", "com"}, GetDomainSuffixes("A.Example.com."))
+	assert.Len(t, GetDomainSuffixes(""), 0)
+	assert.Equal(t, "com", GetTLD("a.example.com"))
+}
+
+func TestAddFilter(t *testing.T) {
+	q, err := Parse("gaming")
+	require.NoError(t, err)
+
+	q.AddFilter("Category", "Games")
+	assert.Equal(t, Clause{Occurrence: Must, Kind: Filter, Field: "category", Value: "games"}, q.Clauses[1])
+
+	assert.True(t, q.MatchFilters("a.dev", map[string]string{"category": "games"}))
+	assert.False(t, q.MatchFilters("a.dev", map[string]string{"category": "news"}))
+	assert.False(t, q.MatchFilters("a.dev", map[string]string{}))
+}
+
+func TestGetNameserverProvider(t *testing.T) {
+	stringProperties := map[string]string{
+		"record_type": "ns",
+		"record_data": "jason.ns.cloudflare.com.",
+	}
+	assert.Equal(t, "cloudflare.com", GetNameserverProvider(stringProperties))
+	assert.Equal(t, "", GetNameserverProvider(map[string]string{}))
+}
+
+func TestNormalize(t *testing.T) {
+	normalize := func(userQuery string) string {
+		q, err := Parse(userQuery)
+		require.NoError(t, err)
+		return q.Normalize()
+	}
+
+	assert.Equal(t, `"open world" +gaming +tld:dev -news`, normalize(`tld:dev +gaming "open  world" -news`))
+	// Letter case, clause order & duplicates shouldn't matter
+	assert.Equal(
+		t, normalize(`tld:dev +gaming "open world" -news`), normalize(`-News "Open World" +GAMING TLD:dev +gaming`),
+	)
+	// Occurrences should matter
+	assert.NotEqual(t, normalize("gaming news"), normalize("gaming AND news"))
+}
@@ -0,0 +1,130 @@
+package rate_limiter
+
+import (
+	"container/list"
+	"net"
+	"sync"
+	"time"
+)
+
+// Least recently used buckets are evicted once the number of buckets exceeds this limit.
+const defaultMaxBuckets = 65536
+
+// IPv6 clients are usually assigned a whole /64 prefix, thus they are limited per prefix.
+const ipv6PrefixLength = 64
+
+type bucket struct {
+	key        string
+	tokens     float64
+	lastRefill time.Time
+}
+
+// RateLimiter is a per-key (i.e. per client IP) token bucket rate limiter. It is safe to use concurrently.
+type RateLimiter struct {
+	ratePerSecond float64
+	burst         float64
+	maxBuckets    int
+
+	// Design Note: Buckets are ordered by their last use, so that rotating keys (i.e. source addresses) can only evict
+	// the buckets which weren't used recently, rather than resetting the limits of all keys.
+	buckets     map[string]*list.Element
+	bucketOrder *list.List
+	mutex       sync.Mutex
+
+	// Design Note: Overridable for tests
+	now func() time.Time
+}
+
+// New creates a rate limiter which refills ratePerSecond tokens per second, up to burst tokens per key.
+func New(ratePerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	r := RateLimiter{}
+	r.ratePerSecond = ratePerSecond
+	r.burst = float64(burst)
+	r.maxBuckets = defaultMaxBuckets
+	r.buckets = make(map[string]*list.Element)
+	r.bucketOrder = list.New()
+	r.now = time.Now
+	return &r
+}
+
+// GetClientKey returns the rate limit key of the client IP. IPv6 addresses are keyed by their /64 prefix.
+func GetClientKey(clientIP string) string {
+	ip := net.ParseIP(clientIP)
+	if ip == nil || ip.To4() != nil {
+		return clientIP
+	}
+	return ip.Mask(net.CIDRMask(ipv6PrefixLength, 8*net.IPv6len)).String() + "/64"
+}
+
+// Allow consumes a token of the key, if there's any available.
+func (r *RateLimiter) Allow(key string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+
+	var b *bucket
+	element, ok := r.buckets[key]
+	if !ok {
+		if len(r.buckets) >= r.maxBuckets {
+			r.evictLeastRecentlyUsedBucket()
+		}
+		b = &bucket{key: key, tokens: r.burst, lastRefill: now}
+		r.buckets[key] = r.bucketOrder.PushFront(b)
+	} else {
+		b = element.Value.(*bucket)
+		r.bucketOrder.MoveToFront(element)
+		r.refill(b, now)
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RetryAfter returns the duration until the next token of the key is available.
+func (r *RateLimiter) RetryAfter(key string) time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	element, ok := r.buckets[key]
+	if !ok {
+		return 0
+	}
+	b := element.Value.(*bucket)
+	r.refill(b, r.now())
+
+	if b.tokens >= 1 || r.ratePerSecond <= 0 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / r.ratePerSecond * float64(time.Second))
+}
+
+func (r *RateLimiter) refill(b *bucket, now time.Time) {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens += elapsed * r.ratePerSecond
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+	b.lastRefill = now
+}
+
+// Removes the bucket which wasn't used for the longest time.
+func (r *RateLimiter) evictLeastRecentlyUsedBucket() {
+	element := r.bucketOrder.Back()
+	if element == nil {
+		return
+	}
+	r.bucketOrder.Remove(element)
+	delete(r.buckets, element.Value.(*bucket).key)
+}
@@ -0,0 +1,60 @@
+package rate_limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	r := New(2, 3)
+	r.now = func() time.Time { return now }
+
+	// Burst should be allowed at once
+	assert.True(t, r.Allow("1.1.1.1"))
+	assert.True(t, r.Allow("1.1.1.1"))
+	assert.True(t, r.Allow("1.1.1.1"))
+	assert.False(t, r.Allow("1.1.1.1"))
+	assert.Equal(t, 500*time.Millisecond, r.RetryAfter("1.1.1.1"))
+
+	// Other keys shouldn't be affected
+	assert.True(t, r.Allow("2.2.2.2"))
+
+	// Tokens are refilled over time, up to the burst
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, r.Allow("1.1.1.1"))
+	assert.False(t, r.Allow("1.1.1.1"))
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, r.Allow("1.1.1.1"))
+	}
+	assert.False(t, r.Allow("1.1.1.1"))
+}
+
+func TestRateLimiterEviction(t *testing.T) {
+	now := time.Now()
+	r := New(1, 1)
+	r.now = func() time.Time { return now }
+	r.maxBuckets = 2
+
+	assert.True(t, r.Allow("1.1.1.1"))
+	assert.True(t, r.Allow("2.2.2.2"))
+	assert.False(t, r.Allow("1.1.1.1"))
+
+	// Least recently used bucket is evicted, while the limit of the recently used one is kept
+	assert.True(t, r.Allow("3.3.3.3"))
+	assert.Len(t, r.buckets, 2)
+	assert.False(t, r.Allow("1.1.1.1"))
+	assert.True(t, r.Allow("2.2.2.2"))
+}
+
+func TestGetClientKey(t *testing.T) {
+	assert.Equal(t, "1.1.1.1", GetClientKey("1.1.1.1"))
+	assert.Equal(t, "2001:db8:1:2::/64", GetClientKey("2001:db8:1:2:3:4:5:6"))
+	assert.Equal(t, GetClientKey("2001:db8:1:2::1"), GetClientKey("2001:db8:1:2:ffff::1"))
+	assert.NotEqual(t, GetClientKey("2001:db8:1:2::1"), GetClientKey("2001:db8:1:3::1"))
+	assert.Equal(t, "invalid", GetClientKey("invalid"))
+}
@@ -0,0 +1,120 @@
+package snippet
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightBefore = "<mark>"
+	highlightAfter  = "</mark>"
+	ellipsis        = "…"
+)
+
+// Generate creates an HTML-escaped snippet of the text, where the query terms are highlighted via <mark> tags.
+// Snippet is centered around the first matching query term and is limited to maxLength runes of text.
+// Design Note: This is a generic fallback for Indexers which can't produce their own highlights.
+func Generate(text string, userQuery string, maxLength int) string {
+	textRunes := []rune(text)
+	lowerTextRunes := []rune(strings.ToLower(text))
+	if len(lowerTextRunes) != len(textRunes) {
+		// Design Note: Some runes change their length when lower-cased. Let's not risk out of bounds offsets.
+		lowerTextRunes = textRunes
+	}
+
+	queryTerms := extractTerms(userQuery)
+
+	// Let's find the locations of all query terms
+	bIsHighlighted := make([]bool, len(textRunes))
+	firstMatch := -1
+	for _, term := range queryTerms {
+		termRunes := []rune(term)
+		for i := 0; i+len(termRunes) <= len(lowerTextRunes); i++ {
+			if !hasPrefixAt(lowerTextRunes, termRunes, i) || !isWordBoundary(lowerTextRunes, i, len(termRunes)) {
+				continue
+			}
+			for j := i; j < i+len(termRunes); j++ {
+				bIsHighlighted[j] = true
+			}
+			if firstMatch == -1 || i < firstMatch {
+				firstMatch = i
+			}
+		}
+	}
+
+	// Let's find the snippet window
+	start := 0
+	end := len(textRunes)
+	if maxLength > 0 && len(textRunes) > maxLength {
+		if firstMatch > maxLength/4 {
+			start = firstMatch - maxLength/4
+		}
+		end = start + maxLength
+		if end > len(textRunes) {
+			end = len(textRunes)
+			start = end - maxLength
+		}
+	}
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString(ellipsis)
+	}
+
+	bInHighlight := false
+	for i := start; i < end; i++ {
+		if bIsHighlighted[i] && !bInHighlight {
+			builder.WriteString(highlightBefore)
+			bInHighlight = true
+		} else if !bIsHighlighted[i] && bInHighlight {
+			builder.WriteString(highlightAfter)
+			bInHighlight = false
+		}
+		builder.WriteString(html.EscapeString(string(textRunes[i])))
+	}
+	if bInHighlight {
+		builder.WriteString(highlightAfter)
+	}
+
+	if end < len(textRunes) {
+		builder.WriteString(ellipsis)
+	}
+
+	return builder.String()
+}
+
+// Splits the user query into lower-case terms.
+func extractTerms(userQuery string) []string {
+	return strings.FieldsFunc(
+		strings.ToLower(userQuery), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		},
+	)
+}
+
+func hasPrefixAt(text []rune, prefix []rune, index int) bool {
+	for i, r := range prefix {
+		if text[index+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks whether the [index, index+length) range starts and ends at word boundaries.
+func isWordBoundary(text []rune, index int, length int) bool {
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsNumber(r)
+	}
+
+	if index > 0 && isWordRune(text[index-1]) {
+		return false
+	}
+
+	if index+length < len(text) && isWordRune(text[index+length]) {
+		return false
+	}
+
+	return true
+}
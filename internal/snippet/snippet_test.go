@@ -0,0 +1,41 @@
+package snippet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	text := "Visit Example-02 for the latest news and updates in technology, gaming, and entertainment."
+
+	output := Generate(text, "GAMING news", 0)
+	assert.Equal(
+		t, "Visit Example-02 for the latest <mark>news</mark> and updates in technology, "+
+			"<mark>gaming</mark>, and entertainment.", output,
+	)
+
+	// Partial words should not be highlighted
+	output = Generate(text, "game", 0)
+	assert.NotContains(t, output, "<mark>")
+}
+
+func TestGenerateEscapesHTML(t *testing.T) {
+	output := Generate("<script>alert('gaming')</script>", "gaming", 0)
+	assert.NotContains(t, output, "<script>")
+	assert.Contains(t, output, "<mark>gaming</mark>")
+}
+
+func TestGenerateWindow(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 50) + "gaming" + strings.Repeat(" dolor sit amet", 50)
+
+	output := Generate(text, "gaming", 100)
+	assert.Contains(t, output, "<mark>gaming</mark>")
+	assert.True(t, strings.HasPrefix(output, ellipsis))
+	assert.True(t, strings.HasSuffix(output, ellipsis))
+
+	// Window should be limited to the max length, excluding highlight tags and ellipses
+	plainOutput := strings.NewReplacer(highlightBefore, "", highlightAfter, "", ellipsis, "").Replace(output)
+	assert.Len(t, []rune(plainOutput), 100)
+}
@@ -1,38 +1,133 @@
 package indexers
 
 import (
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	bleveQuery "github.com/blevesearch/bleve/v2/search/query"
+	"go.uber.org/zap"
 
 	"github.com/anthony-ozdemir/zfse/internal/common"
 	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
 )
 
+const (
+	basicIndexerContentField    = "content"
+	basicIndexerDomainNameField = "domain_name"
+
+	// Maximum number of dictionary entries to consider for suggestions
+	basicIndexerMaxSuggestionCandidates = 1024
+
+	// Design Note: Index mappings only apply when the index is created. Thus, the mapping version is stored within the
+	// index, and indexes with an outdated mapping are recreated. Needs to be incremented whenever the mapping changes.
+	basicIndexerMappingVersion    = "1"
+	basicIndexerMappingVersionKey = "zfse_mapping_version"
+)
+
+// Filter fields recorded within the index. Other filter fields are verified by the application.
+var basicIndexerFilterFields = []string{
+	query_parser.FilterFieldTLD, query_parser.FilterFieldSite, query_parser.FilterFieldNameserver,
+	query_parser.FilterFieldLanguage, query_parser.FilterFieldZone,
+}
+
 type BasicIndexer struct {
-	index      bleve.Index
-	baseFolder string
+	index         bleve.Index
+	baseFolder    string
+	outputLimit   int64
+	bNeedsReindex bool
 }
 
 func (b *BasicIndexer) Initialize(config config.TaskHandlerOptions, baseFolder string, outputLimit int64) error {
 
 	// Try to open an existing index
 	index, err := bleve.Open(baseFolder)
+	bIsOpened := err == nil
+	if bIsOpened {
+		mappingVersion, err := index.GetInternal([]byte(basicIndexerMappingVersionKey))
+		if err != nil {
+			index.Close()
+			return err
+		}
+
+		if string(mappingVersion) != basicIndexerMappingVersion {
+			zap.L().Warn(
+				"Index mapping is outdated. Recreating the index, all documents will be indexed again.",
+				zap.String("path", baseFolder),
+				zap.String("mapping_version", string(mappingVersion)),
+				zap.String("current_mapping_version", basicIndexerMappingVersion),
+			)
+			err = index.Close()
+			if err != nil {
+				return err
+			}
+			err = os.RemoveAll(baseFolder)
+			if err != nil {
+				return err
+			}
+			bIsOpened = false
+			b.bNeedsReindex = true
+		}
+	}
 
 	// If the index doesn't exist, create a new one
-	if err != nil {
-		indexMapping := bleve.NewIndexMapping()
-		index, err = bleve.New(baseFolder, indexMapping)
+	if !bIsOpened {
+		index, err = bleve.New(baseFolder, newBasicIndexMapping())
 		if err != nil {
 			return err
 		}
+		err = index.SetInternal([]byte(basicIndexerMappingVersionKey), []byte(basicIndexerMappingVersion))
+		if err != nil {
+			index.Close()
+			return err
+		}
 	}
 
 	b.index = index
 	b.baseFolder = baseFolder
+	b.outputLimit = outputLimit
 	return nil
 }
 
+// NeedsReindex returns true if the index was recreated due to an outdated mapping.
+func (b *BasicIndexer) NeedsReindex() bool {
+	return b.bNeedsReindex
+}
+
+func newBasicIndexMapping() *mapping.IndexMappingImpl {
+	contentFieldMapping := bleve.NewTextFieldMapping()
+	contentFieldMapping.Store = true
+	contentFieldMapping.IncludeTermVectors = true
+
+	documentMapping := bleve.NewDocumentMapping()
+	documentMapping.AddFieldMappingsAt(basicIndexerContentField, contentFieldMapping)
+	// Design Note: Domain names are only recorded for suggestions. Thus, they shouldn't be matched by user queries.
+	documentMapping.AddFieldMappingsAt(basicIndexerDomainNameField, newKeywordFieldMapping())
+
+	// Filter fields
+	for _, field := range basicIndexerFilterFields {
+		documentMapping.AddFieldMappingsAt(field, newKeywordFieldMapping())
+	}
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = documentMapping
+
+	return indexMapping
+}
+
+// Keyword fields are matched as a whole and are excluded from user query matches.
+func newKeywordFieldMapping() *mapping.FieldMapping {
+	fieldMapping := bleve.NewTextFieldMapping()
+	fieldMapping.Analyzer = keyword.Name
+	fieldMapping.IncludeInAll = false
+	return fieldMapping
+}
+
 func (b *BasicIndexer) Index(id string, properties common.DomainProperties) error {
 
 	// Let's record Description and Body fields
@@ -47,7 +142,21 @@ func (b *BasicIndexer) Index(id string, properties common.DomainProperties) erro
 	}
 
 	if len(record) > 0 {
-		err := b.index.Index(id, record)
+		// Design Note: Content is stored as a field, so that it can be highlighted during queries.
+		document := map[string]interface{}{
+			basicIndexerContentField:    record,
+			basicIndexerDomainNameField: strings.ToLower(properties.DomainName),
+		}
+
+		// Let's record filter fields
+		for _, field := range basicIndexerFilterFields {
+			values := query_parser.GetFilterValues(field, properties.DomainName, properties.StringProperties)
+			if len(values) > 0 {
+				document[field] = values
+			}
+		}
+
+		err := b.index.Index(id, document)
 		if err != nil {
 			return err
 		}
@@ -56,24 +165,201 @@ func (b *BasicIndexer) Index(id string, properties common.DomainProperties) erro
 	return nil
 }
 
-func (b *BasicIndexer) Query(userQuery string) (map[string]float64, error) {
-	// We need to lower-case the query for default bleve index.
-	userQuery = strings.ToLower(userQuery)
+func (b *BasicIndexer) Query(query *query_parser.Query, from int, size int) (map[string]float64, uint64, error) {
+	idToScoreMap := make(map[string]float64)
+
+	// Let's enforce the output limit
+	from, size = capQueryWindow(from, size, b.outputLimit)
 
-	query := bleve.NewMatchQuery(userQuery)
-	search := bleve.NewSearchRequest(query)
+	search := bleve.NewSearchRequestOptions(translateQuery(query), size, from, false)
 
 	results, err := b.index.Search(search)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	idToScoreMap := make(map[string]float64)
 	for _, hit := range results.Hits {
 		idToScoreMap[hit.ID] = hit.Score
 	}
 
-	return idToScoreMap, nil
+	return idToScoreMap, results.Total, nil
+}
+
+// Translates the parsed query into a bleve query.
+func translateQuery(query *query_parser.Query) bleveQuery.Query {
+	booleanQuery := bleve.NewBooleanQuery()
+
+	bHasMustClause := false
+	bHasShouldClause := false
+	for _, clause := range query.Clauses {
+		var clauseQuery bleveQuery.Query
+
+		// We need to lower-case the query for default bleve index.
+		switch clause.Kind {
+		case query_parser.Term:
+			clauseQuery = bleve.NewMatchQuery(strings.ToLower(clause.Value))
+		case query_parser.Phrase:
+			clauseQuery = bleve.NewMatchPhraseQuery(strings.ToLower(clause.Value))
+		case query_parser.Filter:
+			if !isBasicIndexerFilterField(clause.Field) {
+				// Design Note: Filters on arbitrary properties (i.e. facet selections) are not indexed. The
+				// application verifies all filters on the indexer output anyway.
+				continue
+			}
+			termQuery := bleve.NewTermQuery(clause.Value)
+			termQuery.SetField(clause.Field)
+			clauseQuery = termQuery
+		}
+
+		switch clause.Occurrence {
+		case query_parser.Must:
+			booleanQuery.AddMust(clauseQuery)
+			bHasMustClause = true
+		case query_parser.Should:
+			booleanQuery.AddShould(clauseQuery)
+			bHasShouldClause = true
+		case query_parser.MustNot:
+			booleanQuery.AddMustNot(clauseQuery)
+		}
+	}
+
+	// Design Note: Optional terms should still narrow down the results when there are required clauses, i.e.
+	// "tld:dev gaming" should not match every ".dev" domain.
+	if bHasMustClause && bHasShouldClause {
+		booleanQuery.SetMinShould(1)
+	}
+
+	return booleanQuery
+}
+
+func (b *BasicIndexer) Delete(ids []string) error {
+	batch := b.index.NewBatch()
+	for _, id := range ids {
+		batch.Delete(id)
+	}
+	return b.index.Batch(batch)
+}
+
+func isBasicIndexerFilterField(field string) bool {
+	for _, filterField := range basicIndexerFilterFields {
+		if filterField == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BasicIndexer) Highlight(query *query_parser.Query, ids []string) (map[string]string, error) {
+	idToSnippetMap := make(map[string]string)
+	if len(ids) == 0 {
+		return idToSnippetMap, nil
+	}
+
+	// Let's only search within the given IDs
+	idQuery := bleve.NewConjunctionQuery(translateQuery(query), bleve.NewDocIDQuery(ids))
+	search := bleve.NewSearchRequestOptions(idQuery, len(ids), 0, false)
+	search.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	search.Highlight.AddField(basicIndexerContentField)
+
+	results, err := b.index.Search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range results.Hits {
+		fragments := hit.Fragments[basicIndexerContentField]
+		if len(fragments) > 0 {
+			idToSnippetMap[hit.ID] = strings.Join(fragments, " … ")
+		}
+	}
+
+	return idToSnippetMap, nil
+}
+
+func (b *BasicIndexer) Match(query *query_parser.Query, ids []string) ([]string, error) {
+	matchingIDs := make([]string, 0)
+	if len(ids) == 0 {
+		return matchingIDs, nil
+	}
+
+	// Let's only search within the given IDs
+	idQuery := bleve.NewConjunctionQuery(translateQuery(query), bleve.NewDocIDQuery(ids))
+	search := bleve.NewSearchRequestOptions(idQuery, len(ids), 0, false)
+
+	results, err := b.index.Search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range results.Hits {
+		matchingIDs = append(matchingIDs, hit.ID)
+	}
+
+	return matchingIDs, nil
+}
+
+func (b *BasicIndexer) Suggest(prefix string, limit int) ([]string, error) {
+	suggestions := make([]string, 0)
+	prefix = strings.ToLower(prefix)
+	if prefix == "" || limit <= 0 {
+		return suggestions, nil
+	}
+
+	// Term completions are more relevant than domain name completions
+	for _, field := range []string{basicIndexerContentField, basicIndexerDomainNameField} {
+		completions, err := b.getTermCompletions(field, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, completion := range completions {
+			if len(suggestions) >= limit {
+				return suggestions, nil
+			}
+			suggestions = append(suggestions, completion)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// Returns terms of the field starting with the prefix, sorted by document frequency.
+func (b *BasicIndexer) getTermCompletions(field string, prefix string) ([]string, error) {
+	fieldDict, err := b.index.FieldDictPrefix(field, []byte(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer fieldDict.Close()
+
+	type termCount struct {
+		Term  string
+		Count uint64
+	}
+
+	termCounts := make([]termCount, 0)
+	for len(termCounts) < basicIndexerMaxSuggestionCandidates {
+		entry, err := fieldDict.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			break
+		}
+		termCounts = append(termCounts, termCount{Term: entry.Term, Count: entry.Count})
+	}
+
+	sort.SliceStable(
+		termCounts, func(i, j int) bool {
+			return termCounts[i].Count > termCounts[j].Count
+		},
+	)
+
+	completions := make([]string, 0, len(termCounts))
+	for _, item := range termCounts {
+		completions = append(completions, item.Term)
+	}
+
+	return completions, nil
 }
 
 func (b *BasicIndexer) GetType() string {
@@ -0,0 +1,22 @@
+package indexers
+
+// Caps the [from, from+size) query window by the outputLimit.
+func capQueryWindow(from int, size int, outputLimit int64) (int, int) {
+	if from < 0 {
+		from = 0
+	}
+
+	if size < 0 {
+		size = 0
+	}
+
+	if int64(from) >= outputLimit {
+		return from, 0
+	}
+
+	if int64(from+size) > outputLimit {
+		size = int(outputLimit) - from
+	}
+
+	return from, size
+}
@@ -1,14 +1,17 @@
 package indexers
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
+	"github.com/blevesearch/bleve/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/anthony-ozdemir/zfse/internal/common"
 	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
 )
 
 func TestBasicIndexer(t *testing.T) {
@@ -49,10 +52,239 @@ func TestBasicIndexer(t *testing.T) {
 	require.NoError(t, err)
 
 	// Test query
-	query := "GAMING NEWS. Entertainment. Test."
-	scoreMap, err := indexer.Query(query)
+	query, err := query_parser.Parse("GAMING NEWS. Entertainment. Test.")
+	require.NoError(t, err)
+	scoreMap, totalHits, err := indexer.Query(query, 0, 10)
 	require.NoError(t, err)
 	assert.Len(t, scoreMap, 1)
+	assert.Equal(t, uint64(1), totalHits)
 	_, ok := scoreMap["example_02"]
 	assert.True(t, ok)
+
+	// Test highlight
+	snippetMap, err := indexer.Highlight(query, []string{"example_01", "example_02"})
+	require.NoError(t, err)
+	assert.Len(t, snippetMap, 1)
+	assert.Contains(t, snippetMap["example_02"], "<mark>gaming</mark>")
+
+	// Test match
+	matchingIDs, err := indexer.Match(query, []string{"example_01", "example_02", "example_03"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example_02"}, matchingIDs)
+
+	matchingIDs, err = indexer.Match(query, []string{"example_01"})
+	require.NoError(t, err)
+	assert.Len(t, matchingIDs, 0)
+
+	// Test suggestions
+	suggestions, err := indexer.Suggest("ga", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gadgets", "gaming"}, suggestions)
+
+	suggestions, err = indexer.Suggest("EXAMPLE-0", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example-01.com", "example-02.com"}, suggestions)
+
+	suggestions, err = indexer.Suggest("ga", 1)
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 1)
+}
+
+func TestBasicIndexerPagination(t *testing.T) {
+	// Test setup
+	conf := config.TaskHandlerOptions{
+		Type:          "builtin.basic_indexer",
+		StringOptions: make(map[string]string),
+		IntOptions:    make(map[string]int64),
+		FloatOptions:  make(map[string]float64),
+		BoolOptions:   make(map[string]bool),
+	}
+
+	indexer := BasicIndexer{}
+
+	// Create a temporary folder for the index database
+	tempFolderPath, err := os.MkdirTemp("", "indexer_temp_folder")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempFolderPath)
+
+	// Initialize the indexer with an output limit of 15
+	err = indexer.Initialize(conf, tempFolderPath, 15)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		domainProperties := common.NewDomainProperties()
+		domainProperties.DomainName = fmt.Sprintf("example-%02d.com", i)
+		domainProperties.StringProperties["description"] = "Latest gaming news."
+		err = indexer.Index(fmt.Sprintf("example_%02d", i), domainProperties)
+		require.NoError(t, err)
+	}
+
+	// Pagination should go beyond the first ten results
+	query, err := query_parser.Parse("gaming")
+	require.NoError(t, err)
+	scoreMap, totalHits, err := indexer.Query(query, 10, 10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), totalHits)
+	// Output limit should cap the window
+	assert.Len(t, scoreMap, 5)
+
+	scoreMap, _, err = indexer.Query(query, 15, 10)
+	require.NoError(t, err)
+	assert.Len(t, scoreMap, 0)
+}
+
+func TestRandomIndexerPagination(t *testing.T) {
+	conf := config.TaskHandlerOptions{
+		Type:          "builtin.random_indexer",
+		StringOptions: make(map[string]string),
+		IntOptions:    make(map[string]int64),
+		FloatOptions:  make(map[string]float64),
+		BoolOptions:   make(map[string]bool),
+	}
+
+	indexer := RandomIndexer{}
+	err := indexer.Initialize(conf, "", 100)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		err = indexer.Index(fmt.Sprintf("example_%02d", i), common.NewDomainProperties())
+		require.NoError(t, err)
+	}
+
+	query, err := query_parser.Parse("example")
+	require.NoError(t, err)
+
+	firstPage, totalHits, err := indexer.Query(query, 0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), totalHits)
+	assert.Len(t, firstPage, 3)
+
+	secondPage, _, err := indexer.Query(query, 3, 3)
+	require.NoError(t, err)
+	assert.Len(t, secondPage, 2)
+	for id := range secondPage {
+		_, ok := firstPage[id]
+		assert.False(t, ok)
+	}
+}
+
+func TestBasicIndexerStructuredQuery(t *testing.T) {
+	// Test setup
+	conf := config.TaskHandlerOptions{
+		Type:          "builtin.basic_indexer",
+		StringOptions: make(map[string]string),
+		IntOptions:    make(map[string]int64),
+		FloatOptions:  make(map[string]float64),
+		BoolOptions:   make(map[string]bool),
+	}
+
+	indexer := BasicIndexer{}
+
+	// Create a temporary folder for the index database
+	tempFolderPath, err := os.MkdirTemp("", "indexer_temp_folder")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempFolderPath)
+
+	err = indexer.Initialize(conf, tempFolderPath, 100)
+	require.NoError(t, err)
+
+	generateDomainProperties := func(domainName, nameserver, lang, description string) common.DomainProperties {
+		domainProperties := common.NewDomainProperties()
+		domainProperties.DomainName = domainName
+		domainProperties.StringProperties["record_type"] = "ns"
+		domainProperties.StringProperties["record_data"] = nameserver
+		domainProperties.StringProperties["lang"] = lang
+		domainProperties.StringProperties["description"] = description
+		domainProperties.StringProperties["zone_name"] = query_parser.GetTLD(domainName)
+		return domainProperties
+	}
+
+	require.NoError(
+		t, indexer.Index(
+			"dev_0", generateDomainProperties(
+				"studio.dev", "jason.ns.cloudflare.com.", "en-US", "Indie game studio building an open world MMORPG.",
+			),
+		),
+	)
+	require.NoError(
+		t, indexer.Index(
+			"com_0", generateDomainProperties(
+				"studio.com", "ns1.example-dns.com.", "de", "Game studio for mobile games and world puzzles.",
+			),
+		),
+	)
+	require.NoError(
+		t, indexer.Index(
+			"dev_1", generateDomainProperties(
+				"news.dev", "ns2.example-dns.com.", "en", "Daily tech news.",
+			),
+		),
+	)
+
+	testCases := map[string][]string{
+		"game":                   {"dev_0", "com_0"},
+		"game tld:dev":           {"dev_0"},
+		"game -tld:dev":          {"com_0"},
+		"game ns:cloudflare.com": {"dev_0"},
+		"ns:example-dns.com":     {"com_0", "dev_1"},
+		"site:studio.dev":        {"dev_0"},
+		"lang:en":                {"dev_0", "dev_1"},
+		"\"open world\"":         {"dev_0"},
+		"\"world open\"":         {},
+		"game -mmorpg":           {"com_0"},
+		"studio AND news":        {},
+		"studio OR news":         {"dev_0", "com_0", "dev_1"},
+		"tld:dev":                {"dev_0", "dev_1"},
+		"tld:dev news":           {"dev_1"},
+		"-tld:dev":               {"com_0"},
+		"zone:dev":               {"dev_0", "dev_1"},
+		"game -zone:dev":         {"com_0"},
+	}
+
+	for userQuery, expectedIDs := range testCases {
+		query, err := query_parser.Parse(userQuery)
+		require.NoError(t, err)
+
+		scoreMap, _, err := indexer.Query(query, 0, 10)
+		require.NoError(t, err)
+
+		ids := make([]string, 0)
+		for id := range scoreMap {
+			ids = append(ids, id)
+		}
+		assert.ElementsMatch(t, expectedIDs, ids, userQuery)
+	}
+
+	// Filters on fields which aren't indexed should be left to the application
+	query, err := query_parser.Parse("game")
+	require.NoError(t, err)
+	query.AddFilter("category", "games")
+	scoreMap, _, err := indexer.Query(query, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, scoreMap, 2)
+}
+
+func TestBasicIndexerOutdatedMapping(t *testing.T) {
+	conf := config.TaskHandlerOptions{Type: "builtin.basic_indexer"}
+	indexFolderPath := t.TempDir() + "/index.bleve"
+
+	// Index created with an older mapping, which doesn't record the mapping version
+	oldIndex, err := bleve.New(indexFolderPath, bleve.NewIndexMapping())
+	require.NoError(t, err)
+	require.NoError(t, oldIndex.Index("test_0", map[string]interface{}{"content": "example"}))
+	require.NoError(t, oldIndex.Close())
+
+	indexer := BasicIndexer{}
+	require.NoError(t, indexer.Initialize(conf, indexFolderPath, 100))
+	assert.True(t, indexer.NeedsReindex())
+	documentCount, err := indexer.index.DocCount()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), documentCount)
+	require.NoError(t, indexer.index.Close())
+
+	// Recreated index is up-to-date
+	indexer = BasicIndexer{}
+	require.NoError(t, indexer.Initialize(conf, indexFolderPath, 100))
+	assert.False(t, indexer.NeedsReindex())
+	require.NoError(t, indexer.index.Close())
 }
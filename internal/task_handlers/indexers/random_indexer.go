@@ -2,10 +2,12 @@ package indexers
 
 import (
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/anthony-ozdemir/zfse/internal/common"
 	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/query_parser"
 )
 
 type RandomIndexer struct {
@@ -37,8 +39,45 @@ func (i *RandomIndexer) Index(id string, properties common.DomainProperties) err
 	return nil
 }
 
-func (i *RandomIndexer) Query(userQuery string) (map[string]float64, error) {
-	return i.outputScoreMap, nil
+func (i *RandomIndexer) Query(query *query_parser.Query, from int, size int) (map[string]float64, uint64, error) {
+	// Let's enforce the output limit
+	from, size = capQueryWindow(from, size, i.outputLimit)
+
+	// Let's sort IDs by their scores, so that windows are consistent between queries
+	ids := make([]string, 0, len(i.outputScoreMap))
+	for id := range i.outputScoreMap {
+		ids = append(ids, id)
+	}
+	sort.Slice(
+		ids, func(a, b int) bool {
+			return i.outputScoreMap[ids[a]] > i.outputScoreMap[ids[b]]
+		},
+	)
+
+	idToScoreMap := make(map[string]float64)
+	for index := from; index < len(ids) && index < from+size; index++ {
+		idToScoreMap[ids[index]] = i.outputScoreMap[ids[index]]
+	}
+
+	return idToScoreMap, uint64(len(ids)), nil
+}
+
+// Match returns the given IDs which are indexed, as random indexer matches any query.
+func (i *RandomIndexer) Match(query *query_parser.Query, ids []string) ([]string, error) {
+	matchingIDs := make([]string, 0)
+	for _, id := range ids {
+		if _, ok := i.outputScoreMap[id]; ok {
+			matchingIDs = append(matchingIDs, id)
+		}
+	}
+	return matchingIDs, nil
+}
+
+func (i *RandomIndexer) Delete(ids []string) error {
+	for _, id := range ids {
+		delete(i.outputScoreMap, id)
+	}
+	return nil
 }
 
 func (i *RandomIndexer) GetType() string {
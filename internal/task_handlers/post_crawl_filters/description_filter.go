@@ -3,6 +3,7 @@ package post_crawl_filters
 import (
 	"net/http"
 	"regexp"
+	"strings"
 
 	"go.uber.org/zap"
 	"golang.org/x/net/html"
@@ -42,8 +43,24 @@ func (d *DescriptionFilter) Input(
 ) *common.DomainProperties {
 
 	description := ""
+	title := ""
+	lang := ""
 	var crawler func(*html.Node)
 	crawler = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "html" && lang == "" {
+			for _, attr := range node.Attr {
+				if attr.Key == "lang" {
+					lang = strings.TrimSpace(attr.Val)
+				}
+			}
+		}
+
+		if node.Type == html.ElementNode && node.Data == "title" && title == "" {
+			if node.FirstChild != nil && node.FirstChild.Type == html.TextNode {
+				title = strings.TrimSpace(node.FirstChild.Data)
+			}
+		}
+
 		if node.Type == html.ElementNode && node.Data == "meta" {
 			var nameAttr, contentAttr *html.Attribute
 			for _, attr := range node.Attr {
@@ -71,6 +88,14 @@ func (d *DescriptionFilter) Input(
 
 	if d.descriptionRegex.MatchString(description) {
 		inProperties.StringProperties["description"] = description
+		// Design Note: Title is recorded as well, so that search results can be displayed properly.
+		if title != "" {
+			inProperties.StringProperties["title"] = title
+		}
+		// Design Note: Language is recorded as well, so that search results can be filtered by language.
+		if lang != "" {
+			inProperties.StringProperties["lang"] = lang
+		}
 		return inProperties
 	} else {
 		return nil
@@ -29,7 +29,7 @@ func TestDescriptionFilter(t *testing.T) {
 	assert.Equal(t, conf.Type, descriptionFilter.GetType())
 
 	htmlDocument := `<!DOCTYPE html>
-					<html>
+					<html lang="en">
 						<head>
 							<meta name="description" content="This is an example meta description."/>
 							<title>Example</title>
@@ -49,4 +49,6 @@ func TestDescriptionFilter(t *testing.T) {
 	output := descriptionFilter.Input(&domainproperties, nil, parsedDoc)
 	assert.NotNil(t, output)
 	assert.Equal(t, output.DomainName, domainproperties.DomainName)
+	assert.Equal(t, "Example", output.StringProperties["title"])
+	assert.Equal(t, "en", output.StringProperties["lang"])
 }
@@ -57,4 +57,13 @@ func TestUniqueDomainFilter(t *testing.T) {
 	assert.Equal(t, outPropertiesArray[0].DomainName, "a.com")
 	assert.Equal(t, outPropertiesArray[1].DomainName, "b.com")
 	assert.Equal(t, outPropertiesArray[2].DomainName, "c.com")
+
+	// Non-nameserver records are dropped, while seeds without a record type are kept
+	aRecordProperties := generateDomainProperties("d.com")
+	aRecordProperties.StringProperties["record_type"] = "a"
+	assert.Nil(t, filter.Input(&aRecordProperties))
+
+	seedProperties := common.NewDomainProperties()
+	seedProperties.DomainName = "e.com"
+	assert.NotNil(t, filter.Input(&seedProperties))
 }
@@ -36,7 +36,9 @@ func (f *UniqueDomainFilter) Initialize(config config.TaskHandlerOptions) error
 
 func (f *UniqueDomainFilter) Input(inProperties *common.DomainProperties) *common.DomainProperties {
 	// Check if this URL contains a name-server
-	if f.bNameserverCheck && inProperties.StringProperties["record_type"] != "ns" {
+	// Design Note: Seeds which aren't DNS records (i.e. domain lists) don't have a record type, thus they are kept.
+	recordType, bIsDNSRecord := inProperties.StringProperties["record_type"]
+	if f.bNameserverCheck && bIsDNSRecord && recordType != "ns" {
 		return nil
 	}
 
@@ -0,0 +1,55 @@
+package rankers
+
+import (
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+)
+
+const defaultClickRankerSmoothing = 10.0
+
+// ClickRanker boosts domains that are frequently clicked for the same query. Click stats are position-bias corrected
+// by the ClickStatsProvider.
+type ClickRanker struct {
+	smoothing          float64
+	clickStatsProvider interfaces.ClickStatsProvider
+}
+
+func (r *ClickRanker) Initialize(config config.TaskHandlerOptions) error {
+	// Read config
+	r.smoothing = defaultClickRankerSmoothing
+	if smoothing, ok := config.FloatOptions["smoothing"]; ok && smoothing > 0 {
+		r.smoothing = smoothing
+	}
+
+	return nil
+}
+
+func (r *ClickRanker) SetClickStatsProvider(provider interfaces.ClickStatsProvider) {
+	r.clickStatsProvider = provider
+}
+
+// Input returns the click share of the domain for the query, between 0.0 and 1.0.
+// Design Note: Queries with only a few clicks would produce extreme shares. Thus, the share is smoothed towards the
+// click share of the domain across all queries, so that domains popular for other queries still get a small boost.
+func (r *ClickRanker) Input(inProperties *common.DomainProperties, userQuery string) float64 {
+	if r.clickStatsProvider == nil {
+		return 0
+	}
+
+	clickStats, err := r.clickStatsProvider.GetClickStats(userQuery, inProperties.DomainName)
+	if err != nil {
+		return 0
+	}
+
+	domainShare := 0.0
+	if clickStats.TotalClicks > 0 {
+		domainShare = clickStats.DomainClicks / clickStats.TotalClicks
+	}
+
+	return (clickStats.QueryDomainClicks + r.smoothing*domainShare) / (clickStats.QueryClicks + r.smoothing)
+}
+
+func (r *ClickRanker) GetType() string {
+	return "builtin.click_ranker"
+}
@@ -31,3 +31,48 @@ func TestIndexerRanker(t *testing.T) {
 	score := indexerRanker.Input(&domainproperties, "")
 	assert.Equal(t, score, assumedScore)
 }
+
+type testClickStatsProvider struct {
+	clickStatsMap map[string]common.ClickStats
+}
+
+func (p *testClickStatsProvider) GetClickStats(userQuery string, domainName string) (common.ClickStats, error) {
+	return p.clickStatsMap[userQuery+"|"+domainName], nil
+}
+
+func TestClickRanker(t *testing.T) {
+	// Test setup
+	conf := config.TaskHandlerOptions{
+		Type:          "builtin.click_ranker",
+		StringOptions: make(map[string]string),
+		IntOptions:    make(map[string]int64),
+		FloatOptions:  map[string]float64{"smoothing": 2},
+		BoolOptions:   make(map[string]bool),
+	}
+
+	clickRanker := ClickRanker{}
+	err := clickRanker.Initialize(conf)
+	require.NoError(t, err)
+
+	domainProperties := common.NewDomainProperties()
+	domainProperties.DomainName = "example-01.com"
+
+	// No click stats provider
+	assert.Equal(t, 0.0, clickRanker.Input(&domainProperties, "mmorpg"))
+
+	clickRanker.SetClickStatsProvider(
+		&testClickStatsProvider{
+			clickStatsMap: map[string]common.ClickStats{
+				"mmorpg|example-01.com": {QueryDomainClicks: 6, QueryClicks: 8, DomainClicks: 10, TotalClicks: 20},
+				"game|example-01.com":   {QueryDomainClicks: 0, QueryClicks: 8, DomainClicks: 10, TotalClicks: 20},
+			},
+		},
+	)
+
+	// (6 + 2 * 0.5) / (8 + 2)
+	assert.InDelta(t, 0.7, clickRanker.Input(&domainProperties, "mmorpg"), 1e-9)
+	// Domain is popular for other queries: (0 + 2 * 0.5) / (8 + 2)
+	assert.InDelta(t, 0.1, clickRanker.Input(&domainProperties, "game"), 1e-9)
+	// No clicks at all
+	assert.Equal(t, 0.0, clickRanker.Input(&domainProperties, "unknown"))
+}
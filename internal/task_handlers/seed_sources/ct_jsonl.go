@@ -0,0 +1,125 @@
+package seed_sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+)
+
+// Fields which hold the certificate names in the exports of common certificate-transparency tools, i.e. certstream,
+// crt.sh & gungnir.
+var defaultCTDomainsFields = []string{"data.leaf_cert.all_domains", "all_domains", "domains", "dns_names", "name_value"}
+
+// CTJSONLSource reads JSON-lines exports of certificate-transparency tooling, one certificate per line. Each name of
+// the certificate is a seed, wildcard labels are removed.
+//
+// Options:
+//   - domains_field: dot-separated path of the field which holds the names, either as an array of strings or as a
+//     string of whitespace-separated names. Well-known fields are tried by default.
+type CTJSONLSource struct{}
+
+type ctJSONLReader struct {
+	*lineReader
+	domainsFields []string
+	// Names of the last read line, which are yet to be returned
+	pendingSeeds []*common.Seed
+}
+
+func (s *CTJSONLSource) Open(
+	filePath string, zoneName string, config config.TaskHandlerOptions,
+) (interfaces.SeedReader, error) {
+	r := &ctJSONLReader{domainsFields: defaultCTDomainsFields}
+	if domainsField, ok := config.StringOptions["domains_field"]; ok && len(domainsField) > 0 {
+		r.domainsFields = []string{domainsField}
+	}
+
+	reader, err := openLineReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	r.lineReader = reader
+	return r, nil
+}
+
+func (s *CTJSONLSource) GetType() string {
+	return "builtin.ct_jsonl"
+}
+
+// Returns the value of the dot-separated field path, or nil if it doesn't exist.
+func getJSONField(object map[string]interface{}, fieldPath string) interface{} {
+	var value interface{} = object
+	for _, field := range strings.Split(fieldPath, ".") {
+		fieldObject, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = fieldObject[field]
+	}
+	return value
+}
+
+func (r *ctJSONLReader) readDomainNames(line string) ([]string, error) {
+	object := make(map[string]interface{})
+	err := json.Unmarshal([]byte(line), &object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, domainsField := range r.domainsFields {
+		switch value := getJSONField(object, domainsField).(type) {
+		case string:
+			return strings.Fields(value), nil
+		case []interface{}:
+			domainNames := make([]string, 0, len(value))
+			for _, item := range value {
+				domainName, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s contains a non-string value", domainsField)
+				}
+				domainNames = append(domainNames, domainName)
+			}
+			return domainNames, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the fields %v found", r.domainsFields)
+}
+
+func (r *ctJSONLReader) Next() (*common.Seed, error) {
+	for len(r.pendingSeeds) == 0 {
+		line, lineNumber, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		domainNames, err := r.readDomainNames(line)
+		if err != nil {
+			return nil, &common.SeedParseError{LineNumber: lineNumber, Message: err.Error()}
+		}
+
+		// Certificates usually contain both "*.example.com" & "example.com"
+		domainNameSet := make(map[string]bool)
+		for _, domainName := range domainNames {
+			domainName, err := normalizeDomainName(domainName)
+			if err != nil || domainNameSet[domainName] {
+				continue
+			}
+			domainNameSet[domainName] = true
+			r.pendingSeeds = append(r.pendingSeeds, newSeed(domainName, lineNumber))
+		}
+	}
+
+	seed := r.pendingSeeds[0]
+	r.pendingSeeds = r.pendingSeeds[1:]
+	return seed, nil
+}
+
+func (r *ctJSONLReader) HasPendingSeeds() bool {
+	return len(r.pendingSeeds) > 0
+}
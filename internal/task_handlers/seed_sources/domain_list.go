@@ -0,0 +1,50 @@
+package seed_sources
+
+import (
+	"strings"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+)
+
+// DomainListSource reads plain domain lists, one domain per line. Empty lines & "#" comments are skipped.
+type DomainListSource struct{}
+
+type domainListReader struct {
+	*lineReader
+}
+
+func (s *DomainListSource) Open(
+	filePath string, zoneName string, config config.TaskHandlerOptions,
+) (interfaces.SeedReader, error) {
+	reader, err := openLineReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &domainListReader{lineReader: reader}, nil
+}
+
+func (s *DomainListSource) GetType() string {
+	return "builtin.domain_list"
+}
+
+func (r *domainListReader) Next() (*common.Seed, error) {
+	for {
+		line, lineNumber, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		line, _, _ = strings.Cut(line, "#")
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		domainName, err := normalizeDomainName(line)
+		if err != nil {
+			return nil, &common.SeedParseError{LineNumber: lineNumber, Message: err.Error()}
+		}
+		return newSeed(domainName, lineNumber), nil
+	}
+}
@@ -0,0 +1,65 @@
+package seed_sources
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/zone_file"
+)
+
+// Reads the lines of a seed file one by one. Compressed seed files are decompressed while reading, the same way as
+// zone files.
+type lineReader struct {
+	file      io.ReadCloser
+	reader    *bufio.Reader
+	lineCount int
+}
+
+func openLineReader(filePath string) (*lineReader, error) {
+	file, err := zone_file.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &lineReader{file: file, reader: bufio.NewReader(file)}, nil
+}
+
+// Returns the next line without its line terminator, along with its 1-based line number.
+func (r *lineReader) readLine() (string, int, error) {
+	line, err := r.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return "", 0, err
+	}
+	r.lineCount++
+	return strings.TrimRight(line, "\r\n"), r.lineCount, nil
+}
+
+func (r *lineReader) GetLineCount() int {
+	return r.lineCount
+}
+
+func (r *lineReader) Close() error {
+	return r.file.Close()
+}
+
+// Normalizes the domain name the same way as zone file owner names, i.e. lower-cased without the trailing dot.
+// Wildcard labels of certificate names are removed.
+func normalizeDomainName(domainName string) (string, error) {
+	domainName = strings.ToLower(strings.TrimSpace(domainName))
+	domainName = strings.TrimPrefix(strings.TrimSuffix(domainName, "."), "*.")
+	if len(domainName) == 0 {
+		return "", fmt.Errorf("empty domain name")
+	}
+	if strings.ContainsAny(domainName, " \t/:@*") {
+		return "", fmt.Errorf("invalid domain name %q", domainName)
+	}
+	return domainName, nil
+}
+
+func newSeed(domainName string, lineNumber int) *common.Seed {
+	domainProperties := common.NewDomainProperties()
+	domainProperties.DomainName = domainName
+	return &common.Seed{DomainProperties: domainProperties, LineNumber: lineNumber}
+}
@@ -0,0 +1,106 @@
+package seed_sources
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+)
+
+// RankedCSVSource reads ranked domain lists in CSV format, i.e. Tranco ("rank,domain") or Majestic Million
+// ("GlobalRank,TldRank,Domain,..." with a header). Rank of the domain is kept as an int property.
+//
+// Options:
+//   - rank_column & domain_column: 0-based column indexes, 0 & 1 by default.
+//   - b_has_header: skips the first line, false by default.
+//   - rank_property: name of the int property, "rank" by default.
+type RankedCSVSource struct{}
+
+type rankedCSVReader struct {
+	*lineReader
+	rankColumn   int
+	domainColumn int
+	bHasHeader   bool
+	rankProperty string
+}
+
+func (s *RankedCSVSource) Open(
+	filePath string, zoneName string, config config.TaskHandlerOptions,
+) (interfaces.SeedReader, error) {
+	r := &rankedCSVReader{rankColumn: 0, domainColumn: 1, rankProperty: "rank"}
+	if rankColumn, ok := config.IntOptions["rank_column"]; ok {
+		r.rankColumn = int(rankColumn)
+	}
+	if domainColumn, ok := config.IntOptions["domain_column"]; ok {
+		r.domainColumn = int(domainColumn)
+	}
+	if r.rankColumn < 0 || r.domainColumn < 0 || r.rankColumn == r.domainColumn {
+		return nil, fmt.Errorf("invalid rank_column %d & domain_column %d", r.rankColumn, r.domainColumn)
+	}
+	r.bHasHeader = config.BoolOptions["b_has_header"]
+	if rankProperty, ok := config.StringOptions["rank_property"]; ok && len(rankProperty) > 0 {
+		r.rankProperty = rankProperty
+	}
+
+	reader, err := openLineReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	r.lineReader = reader
+	return r, nil
+}
+
+func (s *RankedCSVSource) GetType() string {
+	return "builtin.ranked_csv"
+}
+
+func (r *rankedCSVReader) Next() (*common.Seed, error) {
+	for {
+		line, lineNumber, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if (r.bHasHeader && lineNumber == 1) || len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		// Design Note: Lines are parsed one by one, as ranked lists don't have multi-line quoted fields.
+		csvReader := csv.NewReader(strings.NewReader(line))
+		csvReader.FieldsPerRecord = -1
+		fields, err := csvReader.Read()
+		if err != nil {
+			return nil, &common.SeedParseError{LineNumber: lineNumber, Message: err.Error()}
+		}
+		if r.rankColumn >= len(fields) || r.domainColumn >= len(fields) {
+			return nil, &common.SeedParseError{
+				LineNumber: lineNumber, Message: fmt.Sprintf("expected at least %d columns", r.getColumnCount()),
+			}
+		}
+
+		rank, err := strconv.ParseInt(strings.TrimSpace(fields[r.rankColumn]), 10, 64)
+		if err != nil {
+			return nil, &common.SeedParseError{
+				LineNumber: lineNumber, Message: fmt.Sprintf("invalid rank %q", fields[r.rankColumn]),
+			}
+		}
+		domainName, err := normalizeDomainName(fields[r.domainColumn])
+		if err != nil {
+			return nil, &common.SeedParseError{LineNumber: lineNumber, Message: err.Error()}
+		}
+
+		seed := newSeed(domainName, lineNumber)
+		seed.DomainProperties.IntProperties[r.rankProperty] = rank
+		return seed, nil
+	}
+}
+
+func (r *rankedCSVReader) getColumnCount() int {
+	if r.rankColumn > r.domainColumn {
+		return r.rankColumn + 1
+	}
+	return r.domainColumn + 1
+}
@@ -0,0 +1,204 @@
+package seed_sources
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+)
+
+func newTestSeedSourceOptions(seedSourceType string) config.TaskHandlerOptions {
+	return config.TaskHandlerOptions{
+		Type:          seedSourceType,
+		StringOptions: make(map[string]string),
+		IntOptions:    make(map[string]int64),
+		FloatOptions:  make(map[string]float64),
+		BoolOptions:   make(map[string]bool),
+	}
+}
+
+func writeTestSeedFile(t *testing.T, fileName string, content string) string {
+	filePath := filepath.Join(t.TempDir(), fileName)
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0600))
+	return filePath
+}
+
+// Reads all seeds, malformed seeds are returned as parse errors.
+func readTestSeeds(
+	t *testing.T, seedSource interfaces.SeedSource, filePath string, options config.TaskHandlerOptions,
+) ([]common.Seed, []common.SeedParseError) {
+	reader, err := seedSource.Open(filePath, "test", options)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	seeds := make([]common.Seed, 0)
+	parseErrors := make([]common.SeedParseError, 0)
+	for {
+		seed, err := reader.Next()
+		if err == io.EOF {
+			return seeds, parseErrors
+		}
+		parseError := &common.SeedParseError{}
+		if errors.As(err, &parseError) {
+			parseErrors = append(parseErrors, *parseError)
+			continue
+		}
+		require.NoError(t, err)
+		seeds = append(seeds, *seed)
+	}
+}
+
+func getSeedDomainNames(seeds []common.Seed) []string {
+	domainNames := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		domainNames = append(domainNames, seed.DomainProperties.DomainName)
+	}
+	return domainNames
+}
+
+func TestZoneFileSource(t *testing.T) {
+	seedSource := &ZoneFileSource{}
+	assert.Equal(t, "builtin.zone_file", seedSource.GetType())
+
+	filePath := writeTestSeedFile(
+		t, "test.txt", "a 3600 IN NS ns1.example.com.\nb 3600 IN BOGUS\nc.dev. 3600 IN NS ns1.example.com.\n",
+	)
+	seeds, parseErrors := readTestSeeds(t, seedSource, filePath, newTestSeedSourceOptions(seedSource.GetType()))
+
+	// Relative names are completed with the zone name
+	assert.Equal(t, []string{"a.test", "c.dev"}, getSeedDomainNames(seeds))
+	assert.Equal(t, "ns", seeds[0].DomainProperties.StringProperties["record_type"])
+	assert.Equal(t, 3, seeds[1].LineNumber)
+	require.Len(t, parseErrors, 1)
+	assert.Equal(t, 2, parseErrors[0].LineNumber)
+}
+
+func TestDomainListSource(t *testing.T) {
+	seedSource := &DomainListSource{}
+	assert.Equal(t, "builtin.domain_list", seedSource.GetType())
+
+	// Compressed lists are decompressed while reading
+	compressed := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&compressed)
+	_, err := gzipWriter.Write([]byte("# Curated list\nExample.COM.\n\nfoo.dev # Game studio\nnot a domain\r\nbar.dev"))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	filePath := writeTestSeedFile(t, "curated.txt.gz", compressed.String())
+
+	seeds, parseErrors := readTestSeeds(t, seedSource, filePath, newTestSeedSourceOptions(seedSource.GetType()))
+	assert.Equal(t, []string{"example.com", "foo.dev", "bar.dev"}, getSeedDomainNames(seeds))
+	assert.Equal(t, []int{2, 4, 6}, []int{seeds[0].LineNumber, seeds[1].LineNumber, seeds[2].LineNumber})
+	assert.Empty(t, seeds[0].DomainProperties.StringProperties)
+	require.Len(t, parseErrors, 1)
+	assert.Equal(t, 5, parseErrors[0].LineNumber)
+}
+
+func TestRankedCSVSource(t *testing.T) {
+	seedSource := &RankedCSVSource{}
+	assert.Equal(t, "builtin.ranked_csv", seedSource.GetType())
+
+	// Tranco
+	filePath := writeTestSeedFile(t, "tranco.csv", "1,google.com\n2,\"example.com\"\nx,bad.com\n3\n")
+	seeds, parseErrors := readTestSeeds(t, seedSource, filePath, newTestSeedSourceOptions(seedSource.GetType()))
+	assert.Equal(t, []string{"google.com", "example.com"}, getSeedDomainNames(seeds))
+	assert.Equal(t, int64(2), seeds[1].DomainProperties.IntProperties["rank"])
+	require.Len(t, parseErrors, 2)
+	assert.Equal(t, 3, parseErrors[0].LineNumber)
+	assert.Equal(t, 4, parseErrors[1].LineNumber)
+
+	// Majestic Million
+	filePath = writeTestSeedFile(
+		t, "majestic.csv", "GlobalRank,TldRank,Domain,TLD\n1,1,google.com,com\n2,1,wikipedia.org,org\n",
+	)
+	options := newTestSeedSourceOptions(seedSource.GetType())
+	options.IntOptions["domain_column"] = 2
+	options.BoolOptions["b_has_header"] = true
+	options.StringOptions["rank_property"] = "majestic_rank"
+	seeds, parseErrors = readTestSeeds(t, seedSource, filePath, options)
+	assert.Equal(t, []string{"google.com", "wikipedia.org"}, getSeedDomainNames(seeds))
+	assert.Equal(t, int64(2), seeds[1].DomainProperties.IntProperties["majestic_rank"])
+	assert.Empty(t, parseErrors)
+
+	options.IntOptions["rank_column"] = 2
+	_, err := seedSource.Open(filePath, "test", options)
+	assert.Error(t, err)
+}
+
+func TestCTJSONLSource(t *testing.T) {
+	seedSource := &CTJSONLSource{}
+	assert.Equal(t, "builtin.ct_jsonl", seedSource.GetType())
+
+	filePath := writeTestSeedFile(
+		t, "ct.jsonl",
+		`{"data": {"leaf_cert": {"all_domains": ["*.example.com", "example.com", "www.example.com"]}}}`+"\n"+
+			`{"name_value": "foo.dev\n*.bar.dev"}`+"\n"+
+			`{"unknown": []}`+"\n"+
+			`not json`+"\n",
+	)
+	seeds, parseErrors := readTestSeeds(t, seedSource, filePath, newTestSeedSourceOptions(seedSource.GetType()))
+	assert.Equal(
+		t, []string{"example.com", "www.example.com", "foo.dev", "bar.dev"}, getSeedDomainNames(seeds),
+	)
+	assert.Equal(t, []int{1, 1, 2, 2}, []int{
+		seeds[0].LineNumber, seeds[1].LineNumber, seeds[2].LineNumber, seeds[3].LineNumber,
+	})
+	require.Len(t, parseErrors, 2)
+	assert.Equal(t, 3, parseErrors[0].LineNumber)
+
+	// Pipeline saves its progress at line boundaries, thus it needs to know whether the line has more seeds
+	reader, err := seedSource.Open(filePath, "test", newTestSeedSourceOptions(seedSource.GetType()))
+	require.NoError(t, err)
+	defer reader.Close()
+	multiSeedReader, ok := reader.(interfaces.MultiSeedReader)
+	require.True(t, ok)
+	assert.False(t, multiSeedReader.HasPendingSeeds())
+	_, err = reader.Next()
+	require.NoError(t, err)
+	assert.True(t, multiSeedReader.HasPendingSeeds())
+	_, err = reader.Next()
+	require.NoError(t, err)
+	assert.False(t, multiSeedReader.HasPendingSeeds())
+	assert.Equal(t, 1, reader.GetLineCount())
+
+	// Shutdown is requested mid-line, pipeline processes the remaining seeds of the line before saving its progress.
+	// Seeds starting on the saved line are skipped on resume, thus no seed is emitted twice.
+	reader, err = seedSource.Open(filePath, "test", newTestSeedSourceOptions(seedSource.GetType()))
+	require.NoError(t, err)
+	defer reader.Close()
+	resumedSeeds := make([]common.Seed, 0)
+	seed, err := reader.Next()
+	require.NoError(t, err)
+	resumedSeeds = append(resumedSeeds, *seed)
+	for reader.(interfaces.MultiSeedReader).HasPendingSeeds() {
+		seed, err = reader.Next()
+		require.NoError(t, err)
+		resumedSeeds = append(resumedSeeds, *seed)
+	}
+	startLineIndex := reader.GetLineCount()
+
+	remainingSeeds, _ := readTestSeeds(t, seedSource, filePath, newTestSeedSourceOptions(seedSource.GetType()))
+	for _, seed := range remainingSeeds {
+		if seed.LineNumber > startLineIndex {
+			resumedSeeds = append(resumedSeeds, seed)
+		}
+	}
+	assert.Equal(t, getSeedDomainNames(seeds), getSeedDomainNames(resumedSeeds))
+
+	// Custom field
+	filePath = writeTestSeedFile(t, "custom.jsonl", `{"cert": {"sans": ["a.dev"]}, "domains": ["b.dev"]}`+"\n")
+	options := newTestSeedSourceOptions(seedSource.GetType())
+	options.StringOptions["domains_field"] = "cert.sans"
+	seeds, parseErrors = readTestSeeds(t, seedSource, filePath, options)
+	assert.Equal(t, []string{"a.dev"}, getSeedDomainNames(seeds))
+	assert.Empty(t, parseErrors)
+}
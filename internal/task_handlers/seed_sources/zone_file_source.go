@@ -0,0 +1,57 @@
+package seed_sources
+
+import (
+	"errors"
+	"io"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+	"github.com/anthony-ozdemir/zfse/internal/config"
+	"github.com/anthony-ozdemir/zfse/internal/interfaces"
+	"github.com/anthony-ozdemir/zfse/internal/zone_file"
+	"github.com/anthony-ozdemir/zfse/internal/zone_parser"
+)
+
+// ZoneFileSource reads the resource records of DNS zone files in RFC 1035 master file format. Each record is a seed,
+// along with its TTL, class, type & data.
+type ZoneFileSource struct{}
+
+type zoneFileReader struct {
+	file   io.ReadCloser
+	parser *zone_parser.Parser
+}
+
+func (s *ZoneFileSource) Open(
+	filePath string, zoneName string, config config.TaskHandlerOptions,
+) (interfaces.SeedReader, error) {
+	file, err := zone_file.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Design Note: Relative names are completed with the zone name, unless the zone file sets its own $ORIGIN.
+	return &zoneFileReader{file: file, parser: zone_parser.NewParser(file, zoneName)}, nil
+}
+
+func (s *ZoneFileSource) GetType() string {
+	return "builtin.zone_file"
+}
+
+func (r *zoneFileReader) Next() (*common.Seed, error) {
+	record, err := r.parser.Next()
+	parseError := &zone_parser.ParseError{}
+	if errors.As(err, &parseError) {
+		return nil, &common.SeedParseError{LineNumber: parseError.LineNumber, Message: parseError.Message}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &common.Seed{DomainProperties: record.ToDomainProperties(), LineNumber: record.LineNumber}, nil
+}
+
+func (r *zoneFileReader) GetLineCount() int {
+	return r.parser.GetLineCount()
+}
+
+func (r *zoneFileReader) Close() error {
+	return r.file.Close()
+}
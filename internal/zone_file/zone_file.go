@@ -0,0 +1,193 @@
+package zone_file
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Design Note: Zone files are decompressed while being read, so that large zones (i.e. .com) don't need to be
+// extracted to the disk. Compressed streams can't seek, thus readers need to skip lines to resume from a line index.
+
+const (
+	extensionGzip    = ".gz"
+	extensionTar     = ".tar"
+	extensionTarGzip = ".tar.gz"
+	extensionTgz     = ".tgz"
+)
+
+type compression int
+
+const (
+	compressionNone compression = iota
+	compressionGzip
+	compressionTar
+	compressionTarGzip
+)
+
+func getCompression(fileName string) compression {
+	lowerFileName := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(lowerFileName, extensionTarGzip), strings.HasSuffix(lowerFileName, extensionTgz):
+		return compressionTarGzip
+	case strings.HasSuffix(lowerFileName, extensionTar):
+		return compressionTar
+	case strings.HasSuffix(lowerFileName, extensionGzip):
+		return compressionGzip
+	default:
+		return compressionNone
+	}
+}
+
+// GetZoneName returns the zone name of the zone file, i.e. "dev.txt", "dev.txt.gz" and "dev.tar.gz" will all return
+// "dev".
+func GetZoneName(fileName string) string {
+	fileName = filepath.Base(fileName)
+
+	// Let's remove the compression extensions first
+	lowerFileName := strings.ToLower(fileName)
+	for _, extension := range []string{extensionTarGzip, extensionTgz, extensionTar, extensionGzip} {
+		if strings.HasSuffix(lowerFileName, extension) {
+			fileName = fileName[:len(fileName)-len(extension)]
+			break
+		}
+	}
+
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+}
+
+// Open opens the zone file for reading. Compressed zone files are decompressed on the fly, and the regular files of
+// tar archives are read one after another.
+func Open(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch getCompression(filePath) {
+	case compressionGzip:
+		gzipReader, err := gzip.NewReader(bufio.NewReader(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &zoneFileReader{reader: gzipReader, closers: []io.Closer{gzipReader, file}}, nil
+	case compressionTarGzip:
+		gzipReader, err := gzip.NewReader(bufio.NewReader(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &zoneFileReader{
+			reader:  newTarMemberReader(tar.NewReader(gzipReader)),
+			closers: []io.Closer{gzipReader, file},
+		}, nil
+	case compressionTar:
+		return &zoneFileReader{
+			reader:  newTarMemberReader(tar.NewReader(bufio.NewReader(file))),
+			closers: []io.Closer{file},
+		}, nil
+	default:
+		return file, nil
+	}
+}
+
+// CountLines returns the number of lines within the zone file.
+func CountLines(filePath string) (int, error) {
+	reader, err := Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	bufferedReader := bufio.NewReader(reader)
+	var lineCount int
+	for {
+		_, err := bufferedReader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return 0, err
+		}
+		lineCount++
+	}
+	return lineCount, nil
+}
+
+type zoneFileReader struct {
+	reader  io.Reader
+	closers []io.Closer
+}
+
+func (r *zoneFileReader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+func (r *zoneFileReader) Close() error {
+	var firstErr error
+	for _, closer := range r.closers {
+		err := closer.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Reads the regular files of the tar archive one after another. Members which don't end with a new line are
+// terminated with one, so that their lines aren't merged.
+type tarMemberReader struct {
+	tarReader     *tar.Reader
+	bIsInMember   bool
+	bNeedsNewLine bool
+	lastByte      byte
+}
+
+func newTarMemberReader(tarReader *tar.Reader) *tarMemberReader {
+	return &tarMemberReader{tarReader: tarReader}
+}
+
+func (r *tarMemberReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		if r.bNeedsNewLine {
+			r.bNeedsNewLine = false
+			p[0] = '\n'
+			return 1, nil
+		}
+
+		if !r.bIsInMember {
+			header, err := r.tarReader.Next()
+			if err != nil {
+				return 0, err
+			}
+			if !header.FileInfo().Mode().IsRegular() {
+				continue
+			}
+			r.bIsInMember = true
+			r.lastByte = '\n'
+		}
+
+		n, err := r.tarReader.Read(p)
+		if n > 0 {
+			r.lastByte = p[n-1]
+		}
+		if err == io.EOF {
+			r.bIsInMember = false
+			r.bNeedsNewLine = r.lastByte != '\n'
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
@@ -0,0 +1,102 @@
+package zone_file
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testZoneFileContent = "example.dev.\t3600\tin\tns\tns1.example.dev.\nexample2.dev.\t3600\tin\tns\tns1.example.dev.\n"
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	buffer := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&buffer)
+	_, err := gzipWriter.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	return buffer.Bytes()
+}
+
+func tarBytes(t *testing.T, members map[string]string, memberNames []string) []byte {
+	buffer := bytes.Buffer{}
+	tarWriter := tar.NewWriter(&buffer)
+
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "zones/", Typeflag: tar.TypeDir, Mode: 0700}))
+	for _, memberName := range memberNames {
+		content := members[memberName]
+		header := &tar.Header{Name: memberName, Typeflag: tar.TypeReg, Mode: 0600, Size: int64(len(content))}
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	return buffer.Bytes()
+}
+
+func readAll(t *testing.T, filePath string) string {
+	reader, err := Open(filePath)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestGetZoneName(t *testing.T) {
+	assert.Equal(t, "dev", GetZoneName("dev.txt"))
+	assert.Equal(t, "dev", GetZoneName("dev.txt.gz"))
+	assert.Equal(t, "dev", GetZoneName("dev.TXT.GZ"))
+	assert.Equal(t, "dev", GetZoneName("dev.zone.tar.gz"))
+	assert.Equal(t, "dev", GetZoneName("dev.tgz"))
+	assert.Equal(t, "dev", GetZoneName("dev.tar"))
+	assert.Equal(t, "dev", GetZoneName("dev"))
+	assert.Equal(t, "example_zone_file", GetZoneName("/zone-files/example_zone_file.txt"))
+}
+
+func TestOpen(t *testing.T) {
+	folderPath := t.TempDir()
+
+	files := map[string][]byte{
+		"plain.txt":   []byte(testZoneFileContent),
+		"gzip.txt.gz": gzipBytes(t, []byte(testZoneFileContent)),
+		"tar.tar.gz": gzipBytes(
+			t, tarBytes(
+				t, map[string]string{"zones/a.txt": "example.dev.\t3600\tin\tns\tns1.example.dev.", "zones/b.txt": ""},
+				[]string{"zones/a.txt", "zones/b.txt"},
+			),
+		),
+		"tar.tar": tarBytes(t, map[string]string{"zones/a.txt": testZoneFileContent}, []string{"zones/a.txt"}),
+	}
+	for fileName, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(folderPath, fileName), data, 0600))
+	}
+
+	assert.Equal(t, testZoneFileContent, readAll(t, filepath.Join(folderPath, "plain.txt")))
+	assert.Equal(t, testZoneFileContent, readAll(t, filepath.Join(folderPath, "gzip.txt.gz")))
+	assert.Equal(t, testZoneFileContent, readAll(t, filepath.Join(folderPath, "tar.tar")))
+	// Members without a trailing new line are terminated with one
+	assert.Equal(
+		t, "example.dev.\t3600\tin\tns\tns1.example.dev.\n", readAll(t, filepath.Join(folderPath, "tar.tar.gz")),
+	)
+
+	for fileName, expectedLines := range map[string]int{
+		"plain.txt": 2, "gzip.txt.gz": 2, "tar.tar": 2, "tar.tar.gz": 1,
+	} {
+		lines, err := CountLines(filepath.Join(folderPath, fileName))
+		require.NoError(t, err)
+		assert.Equal(t, expectedLines, lines, fileName)
+	}
+
+	// Corrupted archives
+	require.NoError(t, os.WriteFile(filepath.Join(folderPath, "corrupted.gz"), []byte("corrupted"), 0600))
+	_, err := Open(filepath.Join(folderPath, "corrupted.gz"))
+	assert.Error(t, err)
+}
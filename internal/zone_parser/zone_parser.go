@@ -0,0 +1,455 @@
+package zone_parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/anthony-ozdemir/zfse/internal/common"
+)
+
+// Design Note: Parser supports the RFC 1035 master file format, i.e. $ORIGIN & $TTL directives, comments, multi-line
+// parenthesized records, relative & omitted owner names, omitted TTL & class fields and quoted character strings.
+// $INCLUDE & $GENERATE directives are not supported, since zone files are expected to be self-contained.
+
+const defaultClass = "in"
+
+var knownClasses = map[string]bool{"in": true, "cs": true, "ch": true, "hs": true}
+
+// Record is a normalized resource record. Names are lower-cased & absolute, i.e. they end with a dot.
+type Record struct {
+	OwnerName string
+	TTL       uint32
+	Class     string
+	Type      string
+	Data      []string
+	// 1-based line number, on which the record starts
+	LineNumber int
+}
+
+// ToDomainProperties converts the record to DomainProperties, where the domain name is the owner name without the
+// trailing dot.
+func (r *Record) ToDomainProperties() common.DomainProperties {
+	domainProperties := common.NewDomainProperties()
+	domainProperties.DomainName = strings.TrimSuffix(r.OwnerName, ".")
+	domainProperties.StringProperties["ttl"] = strconv.FormatUint(uint64(r.TTL), 10)
+	domainProperties.StringProperties["record_class"] = r.Class
+	domainProperties.StringProperties["record_type"] = r.Type
+	domainProperties.StringProperties["record_data"] = strings.Join(r.Data, " ")
+	return domainProperties
+}
+
+// ParseError is returned for malformed records & directives. Parser can continue with the next record afterwards.
+type ParseError struct {
+	LineNumber int
+	Message    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.LineNumber, e.Message)
+}
+
+type token struct {
+	text      string
+	bIsQuoted bool
+}
+
+type Parser struct {
+	reader *bufio.Reader
+	// Number of lines read so far
+	lineCount int
+
+	origin     string
+	defaultTTL *uint32
+	lastOwner  string
+	lastTTL    *uint32
+	lastClass  string
+}
+
+// NewParser creates a parser, where relative names are completed with the origin until an $ORIGIN directive. Origin
+// can be left empty, if the zone file only contains absolute names.
+func NewParser(reader io.Reader, origin string) *Parser {
+	p := &Parser{
+		reader:    bufio.NewReader(reader),
+		lastClass: defaultClass,
+	}
+	if origin != "" {
+		p.origin = strings.ToLower(strings.TrimSuffix(origin, ".")) + "."
+	}
+	return p
+}
+
+// GetLineCount returns the number of lines read so far, including the lines of the last returned record.
+func (p *Parser) GetLineCount() int {
+	return p.lineCount
+}
+
+// Next returns the next record. Returns io.EOF at the end of the zone file and *ParseError for malformed records,
+// other errors are read errors.
+func (p *Parser) Next() (*Record, error) {
+	for {
+		tokens, bIsOwnerOmitted, lineNumber, err := p.readEntry()
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if !tokens[0].bIsQuoted && !bIsOwnerOmitted && strings.HasPrefix(tokens[0].text, "$") {
+			err = p.parseDirective(tokens, lineNumber)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return p.parseRecord(tokens, bIsOwnerOmitted, lineNumber)
+	}
+}
+
+// Reads the tokens of the next entry, which spans multiple lines within parentheses.
+func (p *Parser) readEntry() ([]token, bool, int, error) {
+	tokens := make([]token, 0)
+	bIsOwnerOmitted := false
+	startLineNumber := 0
+	parenthesesDepth := 0
+
+	for {
+		line, err := p.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, false, 0, err
+		}
+		if err == io.EOF && line == "" {
+			if parenthesesDepth > 0 {
+				return nil, false, 0, &ParseError{LineNumber: startLineNumber, Message: "unbalanced parentheses"}
+			}
+			return nil, false, 0, io.EOF
+		}
+		p.lineCount++
+
+		if startLineNumber == 0 {
+			startLineNumber = p.lineCount
+			bIsOwnerOmitted = len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+		}
+
+		lineTokens, depthChange, parseErr := tokenizeLine(strings.TrimRight(line, "\r\n"))
+		if parseErr != nil {
+			// Let's skip the remaining lines of the entry, so that the parser can continue with the next one
+			if parenthesesDepth == 0 {
+				return nil, false, 0, &ParseError{LineNumber: p.lineCount, Message: parseErr.Error()}
+			}
+			return nil, false, 0, p.skipEntry(parenthesesDepth, p.lineCount, parseErr.Error())
+		}
+		tokens = append(tokens, lineTokens...)
+		parenthesesDepth += depthChange
+
+		if parenthesesDepth < 0 {
+			return nil, false, 0, &ParseError{LineNumber: p.lineCount, Message: "unbalanced parentheses"}
+		}
+		if parenthesesDepth > 1 {
+			return nil, false, 0, p.skipEntry(parenthesesDepth, p.lineCount, "nested parentheses")
+		}
+		if parenthesesDepth == 0 {
+			if len(tokens) == 0 {
+				// Empty & comment-only lines
+				startLineNumber = 0
+				continue
+			}
+			return tokens, bIsOwnerOmitted, startLineNumber, nil
+		}
+	}
+}
+
+func (p *Parser) skipEntry(parenthesesDepth int, lineNumber int, message string) error {
+	for parenthesesDepth > 0 {
+		line, err := p.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF && line == "" {
+			break
+		}
+		p.lineCount++
+
+		_, depthChange, tokenizeErr := tokenizeLine(strings.TrimRight(line, "\r\n"))
+		if tokenizeErr == nil {
+			parenthesesDepth += depthChange
+		}
+	}
+	return &ParseError{LineNumber: lineNumber, Message: message}
+}
+
+// Splits the line into tokens, skipping comments & parentheses. Returns the change in parentheses depth.
+func tokenizeLine(line string) ([]token, int, error) {
+	tokens := make([]token, 0)
+	depthChange := 0
+
+	current := strings.Builder{}
+	bHasCurrent := false
+	flush := func() {
+		if bHasCurrent {
+			tokens = append(tokens, token{text: current.String()})
+			current.Reset()
+			bHasCurrent = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			// Escaped characters are kept as they are
+			if i+1 >= len(line) {
+				return nil, 0, fmt.Errorf("dangling escape character")
+			}
+			current.WriteByte(c)
+			current.WriteByte(line[i+1])
+			bHasCurrent = true
+			i++
+		case c == '"':
+			flush()
+			quoted := strings.Builder{}
+			bIsTerminated := false
+			for i++; i < len(line); i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					quoted.WriteByte(line[i])
+					quoted.WriteByte(line[i+1])
+					i++
+					continue
+				}
+				if line[i] == '"' {
+					bIsTerminated = true
+					break
+				}
+				quoted.WriteByte(line[i])
+			}
+			if !bIsTerminated {
+				return nil, 0, fmt.Errorf("unterminated quoted string")
+			}
+			tokens = append(tokens, token{text: quoted.String(), bIsQuoted: true})
+		case c == ';':
+			flush()
+			return tokens, depthChange, nil
+		case c == '(':
+			flush()
+			depthChange++
+		case c == ')':
+			flush()
+			depthChange--
+		case c == ' ' || c == '\t':
+			flush()
+		default:
+			current.WriteByte(c)
+			bHasCurrent = true
+		}
+	}
+	flush()
+
+	return tokens, depthChange, nil
+}
+
+func (p *Parser) parseDirective(tokens []token, lineNumber int) error {
+	directive := strings.ToUpper(tokens[0].text)
+	switch directive {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			return &ParseError{LineNumber: lineNumber, Message: "$ORIGIN expects a single domain name"}
+		}
+		origin, err := p.toAbsoluteName(tokens[1].text)
+		if err != nil {
+			return &ParseError{LineNumber: lineNumber, Message: err.Error()}
+		}
+		p.origin = origin
+	case "$TTL":
+		if len(tokens) != 2 {
+			return &ParseError{LineNumber: lineNumber, Message: "$TTL expects a single TTL"}
+		}
+		ttl, err := parseTTL(tokens[1].text)
+		if err != nil {
+			return &ParseError{LineNumber: lineNumber, Message: err.Error()}
+		}
+		p.defaultTTL = &ttl
+	default:
+		return &ParseError{LineNumber: lineNumber, Message: fmt.Sprintf("unsupported directive %s", tokens[0].text)}
+	}
+	return nil
+}
+
+func (p *Parser) parseRecord(tokens []token, bIsOwnerOmitted bool, lineNumber int) (*Record, error) {
+	newParseError := func(format string, args ...interface{}) error {
+		return &ParseError{LineNumber: lineNumber, Message: fmt.Sprintf(format, args...)}
+	}
+
+	record := &Record{LineNumber: lineNumber}
+
+	// Owner name
+	if bIsOwnerOmitted {
+		if p.lastOwner == "" {
+			return nil, newParseError("owner name is omitted on the first record")
+		}
+		record.OwnerName = p.lastOwner
+	} else {
+		if tokens[0].bIsQuoted {
+			return nil, newParseError("owner name can't be quoted")
+		}
+		ownerName, err := p.toAbsoluteName(tokens[0].text)
+		if err != nil {
+			return nil, newParseError("%s", err.Error())
+		}
+		record.OwnerName = ownerName
+		tokens = tokens[1:]
+	}
+
+	// TTL & class can be in any order, both are optional
+	var ttl *uint32
+	class := ""
+	for len(tokens) > 0 && !tokens[0].bIsQuoted {
+		text := strings.ToLower(tokens[0].text)
+		if ttl == nil && len(text) > 0 && text[0] >= '0' && text[0] <= '9' {
+			parsedTTL, err := parseTTL(text)
+			if err != nil {
+				return nil, newParseError("%s", err.Error())
+			}
+			ttl = &parsedTTL
+		} else if class == "" && knownClasses[text] {
+			class = text
+		} else {
+			break
+		}
+		tokens = tokens[1:]
+	}
+
+	// Type
+	if len(tokens) == 0 || tokens[0].bIsQuoted {
+		return nil, newParseError("record type is missing")
+	}
+	record.Type = strings.ToLower(tokens[0].text)
+	tokens = tokens[1:]
+
+	if class == "" {
+		class = p.lastClass
+	}
+	record.Class = class
+
+	// Design Note: Omitted TTL defaults to the $TTL directive (RFC 2308), or to the last TTL (RFC 1035) otherwise.
+	if ttl == nil {
+		ttl = p.defaultTTL
+	}
+	if ttl == nil {
+		ttl = p.lastTTL
+	}
+	if ttl == nil {
+		return nil, newParseError("TTL is missing and there is no $TTL directive")
+	}
+	record.TTL = *ttl
+
+	// Data
+	if len(tokens) == 0 {
+		return nil, newParseError("record data is missing")
+	}
+	record.Data = make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t.bIsQuoted {
+			record.Data = append(record.Data, `"`+t.text+`"`)
+			continue
+		}
+		record.Data = append(record.Data, t.text)
+	}
+
+	err := p.normalizeDataNames(record)
+	if err != nil {
+		return nil, newParseError("%s", err.Error())
+	}
+
+	p.lastOwner = record.OwnerName
+	p.lastTTL = ttl
+	p.lastClass = record.Class
+	return record, nil
+}
+
+// Returns the indexes of the domain names within the record data of the record type.
+func getDataNameIndexes(recordType string) []int {
+	switch recordType {
+	case "ns", "cname", "ptr", "dname":
+		return []int{0}
+	case "mx":
+		return []int{1}
+	case "srv":
+		return []int{3}
+	case "soa":
+		return []int{0, 1}
+	default:
+		return nil
+	}
+}
+
+// Converts the domain names within the record data to absolute names.
+func (p *Parser) normalizeDataNames(record *Record) error {
+	for _, index := range getDataNameIndexes(record.Type) {
+		if index >= len(record.Data) {
+			return fmt.Errorf("%s record data is missing fields", record.Type)
+		}
+		name, err := p.toAbsoluteName(record.Data[index])
+		if err != nil {
+			return err
+		}
+		record.Data[index] = name
+	}
+	return nil
+}
+
+func (p *Parser) toAbsoluteName(name string) (string, error) {
+	name = strings.ToLower(name)
+	if name == "@" {
+		if p.origin == "" {
+			return "", fmt.Errorf("@ is used without an origin")
+		}
+		return p.origin, nil
+	}
+	if strings.HasSuffix(name, ".") && !strings.HasSuffix(name, "\\.") {
+		return name, nil
+	}
+	if p.origin == "" {
+		return "", fmt.Errorf("relative name %s is used without an origin", name)
+	}
+	if p.origin == "." {
+		return name + ".", nil
+	}
+	return name + "." + p.origin, nil
+}
+
+// Parses TTLs in seconds (i.e. 3600) or with BIND style units (i.e. 1h30m).
+func parseTTL(text string) (uint32, error) {
+	text = strings.ToLower(text)
+	if value, err := strconv.ParseUint(text, 10, 32); err == nil {
+		return uint32(value), nil
+	}
+
+	units := map[byte]uint64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	var total uint64
+	number := ""
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c >= '0' && c <= '9' {
+			number += string(c)
+			continue
+		}
+		multiplier, ok := units[c]
+		if !ok || number == "" {
+			return 0, fmt.Errorf("invalid TTL %s", text)
+		}
+		value, err := strconv.ParseUint(number, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid TTL %s", text)
+		}
+		total += value * multiplier
+		number = ""
+	}
+	if text == "" || number != "" || total > 1<<32-1 {
+		return 0, fmt.Errorf("invalid TTL %s", text)
+	}
+	return uint32(total), nil
+}
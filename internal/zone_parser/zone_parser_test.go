@@ -0,0 +1,154 @@
+package zone_parser
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testZoneFile = `; Hand-written zone file
+$ORIGIN example.dev.
+$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+		2024010101 ; serial
+		7200       ; refresh
+		3600 1209600 300 )
+	IN	NS	ns1
+	IN	NS	ns2.example.com.
+www	300	CNAME	@
+Games.Example.dev.	IN 600	TXT	"v=spf1 -all" "quoted ; not a comment"
+mail	MX	10 mx1
+$ORIGIN sub.example.dev.
+shop	1d	in	a	192.0.2.1
+`
+
+func parseAll(t *testing.T, parser *Parser) ([]*Record, []*ParseError) {
+	records := make([]*Record, 0)
+	parseErrors := make([]*ParseError, 0)
+	for {
+		record, err := parser.Next()
+		if err == io.EOF {
+			return records, parseErrors
+		}
+
+		parseError := &ParseError{}
+		if errors.As(err, &parseError) {
+			parseErrors = append(parseErrors, parseError)
+			continue
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestParser(t *testing.T) {
+	parser := NewParser(strings.NewReader(testZoneFile), "")
+	records, parseErrors := parseAll(t, parser)
+	require.Len(t, parseErrors, 0)
+	require.Len(t, records, 7)
+	assert.Equal(t, 14, parser.GetLineCount())
+
+	assert.Equal(
+		t, &Record{
+			OwnerName: "example.dev.", TTL: 3600, Class: "in", Type: "soa",
+			Data:       []string{"ns1.example.dev.", "hostmaster.example.dev.", "2024010101", "7200", "3600", "1209600", "300"},
+			LineNumber: 4,
+		}, records[0],
+	)
+
+	// Omitted owner names
+	assert.Equal(t, "example.dev.", records[1].OwnerName)
+	assert.Equal(t, []string{"ns1.example.dev."}, records[1].Data)
+	assert.Equal(t, 8, records[1].LineNumber)
+	assert.Equal(t, []string{"ns2.example.com."}, records[2].Data)
+
+	// Relative names & explicit TTL
+	assert.Equal(t, "www.example.dev.", records[3].OwnerName)
+	assert.Equal(t, uint32(300), records[3].TTL)
+	assert.Equal(t, []string{"example.dev."}, records[3].Data)
+
+	// Class before TTL & quoted strings
+	assert.Equal(t, "games.example.dev.", records[4].OwnerName)
+	assert.Equal(t, uint32(600), records[4].TTL)
+	assert.Equal(t, []string{`"v=spf1 -all"`, `"quoted ; not a comment"`}, records[4].Data)
+
+	// Omitted TTL & class default to $TTL & the last class
+	assert.Equal(t, uint32(3600), records[5].TTL)
+	assert.Equal(t, "in", records[5].Class)
+	assert.Equal(t, []string{"10", "mx1.example.dev."}, records[5].Data)
+
+	assert.Equal(t, "shop.sub.example.dev.", records[6].OwnerName)
+	assert.Equal(t, uint32(86400), records[6].TTL)
+
+	domainProperties := records[2].ToDomainProperties()
+	assert.Equal(t, "example.dev", domainProperties.DomainName)
+	assert.Equal(
+		t, map[string]string{
+			"ttl": "3600", "record_class": "in", "record_type": "ns", "record_data": "ns2.example.com.",
+		}, domainProperties.StringProperties,
+	)
+}
+
+func TestParserICANNZoneFile(t *testing.T) {
+	parser := NewParser(
+		strings.NewReader(
+			"galaxiesofeden.com.\t21600\tin\tns\tjason.ns.cloudflare.com.\n"+
+				"\n"+
+				"mircybernetics.com.\t21600\tin\tns\tmarlowe.ns.cloudflare.com.",
+		), "com",
+	)
+	records, parseErrors := parseAll(t, parser)
+	require.Len(t, parseErrors, 0)
+	require.Len(t, records, 2)
+	assert.Equal(t, "galaxiesofeden.com.", records[0].OwnerName)
+	assert.Equal(t, 3, records[1].LineNumber)
+	assert.Equal(t, "marlowe.ns.cloudflare.com.", records[1].ToDomainProperties().StringProperties["record_data"])
+}
+
+func TestParserErrors(t *testing.T) {
+	zoneFile := `example.dev. NS ns1.example.dev.
+$INCLUDE other.zone
+www.example.dev. 300 IN
+relative 300 IN A 192.0.2.1
+example.dev. 300 IN TXT "unterminated
+example.dev. 300 IN SOA ns1.example.dev. hostmaster.example.dev. (
+	1 2 3 4
+example.dev. 300 IN A 192.0.2.1
+`
+	parser := NewParser(strings.NewReader(zoneFile), "")
+	records, parseErrors := parseAll(t, parser)
+	assert.Len(t, records, 0)
+
+	lineNumbers := make([]int, 0, len(parseErrors))
+	for _, parseError := range parseErrors {
+		lineNumbers = append(lineNumbers, parseError.LineNumber)
+	}
+	// Missing TTL, unsupported directive, missing data, relative name without origin, unterminated quote and
+	// unbalanced parentheses
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, lineNumbers)
+	assert.Equal(t, "line 2: unsupported directive $INCLUDE", parseErrors[1].Error())
+
+	// Parser continues after malformed records
+	parser = NewParser(strings.NewReader("$TTL x\nexample.dev. 60 IN A 192.0.2.1\n"), "")
+	records, parseErrors = parseAll(t, parser)
+	assert.Len(t, parseErrors, 1)
+	require.Len(t, records, 1)
+	assert.Equal(t, 2, records[0].LineNumber)
+}
+
+func TestParseTTL(t *testing.T) {
+	for text, expected := range map[string]uint32{"0": 0, "3600": 3600, "1h30m": 5400, "1W": 604800, "2d1s": 172801} {
+		ttl, err := parseTTL(text)
+		require.NoError(t, err, text)
+		assert.Equal(t, expected, ttl, text)
+	}
+
+	for _, text := range []string{"", "1x", "h", "1h30", "99999999999"} {
+		_, err := parseTTL(text)
+		assert.Error(t, err, text)
+	}
+}